@@ -1,44 +1,102 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/Oluwaseun241/mura/internal/history"
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
 	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/Oluwaseun241/mura/internal/units"
+	"github.com/Oluwaseun241/mura/internal/usage"
 	"github.com/labstack/echo/v4"
 )
 
+// maxRegenerations bounds how many extra recipes a strict /recipe request may
+// ask for when the first one needs ingredients the user doesn't have.
+const maxRegenerations = 2
+
+// maxFoodImages caps how many angles of one dish /detect-food takes.
+const maxFoodImages = 4
+
 func FoodHandler(c echo.Context) error {
+	ctx := c.Request().Context()
 	// Parse multipart form data
 	form, err := c.MultipartForm()
 	if err != nil || len(form.File["image"]) == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No images uploaded"})
 	}
 
-	file := form.File["image"][0]
-
-	src, err := file.Open()
+	loc, err := parseUnits(c.FormValue("units"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open uploaded image"})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	defer src.Close()
 
-	fileBytes, err := io.ReadAll(src)
+	// expand picks which detected dishes get a recipe: "all", "none", or a
+	// comma separated list of dish indexes or names
+	expand := c.FormValue("expand")
+	maxExpand := parseMaxDishes(c.FormValue("max_dishes"))
+
+	// Extra photos are other angles of the same dish; the first one is used
+	// wherever a single image is needed
+	files := form.File["image"]
+	if len(files) > maxFoodImages {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("At most %d images of a dish can be uploaded", maxFoodImages)})
+	}
+
+	images, err := readImages(files)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read uploaded image"})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	// Classify the image concurrently
-	imageType, err := service.ClassifyImage(fileBytes)
+	in := history.Input{
+		Images:    len(images),
+		Expand:    expand,
+		MaxDishes: maxExpand,
+		Portions:  c.FormValue("portions") == "true",
+		Reference: c.FormValue("reference"),
+		Units:     c.FormValue("units"),
+	}
+	for _, img := range images {
+		in.ImageBytes += len(img)
+	}
+
+	start := time.Now()
+	response, err := detectFoodResponse(ctx, images, in, loc)
+	if imageURL, ok := response["image_url"].(string); ok {
+		in.ImageURL = imageURL
+	}
+	recordHistory(c, historyEntry(history.DetectFood, in, start, response, err), images, response)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"status": false,
 			"error":  err.Error(),
 		})
 	}
+	startChat(c, response)
+	return c.JSON(http.StatusOK, response)
+}
+
+// detectFoodResponse runs the /detect-food pipeline on photos of one dish.
+// in.ImageURL is reused instead of uploading the photo again when it's set.
+// An error means the photo couldn't be classified; anything that fails after
+// that is reported in the response.
+func detectFoodResponse(ctx context.Context, images [][]byte, in history.Input, loc *units.Locale) (map[string]interface{}, error) {
+	fileBytes := images[0]
+
+	// Classify the image concurrently
+	imageType, err := service.ClassifyImage(ctx, fileBytes)
+	if err != nil {
+		return nil, err
+	}
 
 	response := map[string]interface{}{
 		"status": true,
@@ -54,7 +112,7 @@ func FoodHandler(c echo.Context) error {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			ingredients, err := detectIngredients(fileBytes)
+			ingredients, err := detectIngredients(ctx, fileBytes)
 			mu.Lock()
 			defer mu.Unlock()
 			if err != nil {
@@ -66,40 +124,91 @@ func FoodHandler(c echo.Context) error {
 
 		}()
 	} else if imageType == "cooked food" {
-		// Detect food and get recipe
+		// Detect each dish on the plate and get their recipes
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			food, err := detectFood(fileBytes)
-			mu.Lock()
-			defer mu.Unlock()
+			dishes, err := detectDishes(ctx, images)
+			if err != nil || len(dishes) == 0 {
+				// Fall back to treating the photo as a single dish
+				food, err := detectFood(ctx, images, "", loc)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					response["error"] = err.Error()
+					response["status"] = false
+					return
+				}
+				response["data"] = food
+				return
+			}
+
+			selected, err := service.SelectDishes(dishes, in.Expand, in.MaxDishes)
 			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				response["dishes"] = dishes
 				response["error"] = err.Error()
 				response["status"] = false
 				return
 			}
-			response["data"] = food
-
-		}()
+			expandDishes(ctx, images, dishes, selected, loc)
 
-		// Upload image(data collection)
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := service.UploadImage(fileBytes)
 			mu.Lock()
 			defer mu.Unlock()
-			if err != nil {
-				response["status"] = false
-				response["error"] = err.Error()
+			response["dishes"] = dishes
+			// data stays the recipe of the first expanded dish for clients
+			// that only show one
+			for _, i := range selected {
+				if dishes[i].Recipe != "" {
+					response["data"] = dishes[i].Recipe
+					break
+				}
 			}
 		}()
 
+		// Portion and calorie estimate for food logging
+		if in.Portions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				estimate, err := estimatePortions(ctx, images, in.Reference)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					response["portions_error"] = err.Error()
+					return
+				}
+				response["portions"] = estimate
+			}()
+		}
+
+		// Upload image(data collection), unless this photo already was
+		if in.ImageURL != "" {
+			mu.Lock()
+			response["image_url"] = in.ImageURL
+			mu.Unlock()
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				imageURL, err := service.UploadImage(fileBytes)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					response["status"] = false
+					response["error"] = err.Error()
+					return
+				}
+				response["image_url"] = imageURL
+			}()
+		}
+
 		// YouTube recommendation
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			yt, err := ytVideoRecommendation(fileBytes)
+			yt, err := ytVideoRecommendation(ctx, fileBytes)
 			mu.Lock()
 			defer mu.Unlock()
 			if err != nil {
@@ -116,10 +225,23 @@ func FoodHandler(c echo.Context) error {
 
 	// Wait for all goroutines to finish
 	wg.Wait()
-	return c.JSON(http.StatusOK, response)
+
+	// Suggest related dishes to cook next
+	if imageType == "cooked food" && embedder != nil {
+		if names := plateDishes(response); len(names) > 0 {
+			similar, err := similarDishes(ctx, names, similarDishCount)
+			if err != nil {
+				response["similar_error"] = err.Error()
+			} else {
+				response["similar"] = similar
+			}
+		}
+	}
+	return response, nil
 }
 
 func IngredientHandler(c echo.Context) error {
+	ctx := c.Request().Context()
 	// Parse multiple files from the request
 	form, err := c.MultipartForm()
 	if err != nil || len(form.File["images"]) == 0 {
@@ -148,9 +270,9 @@ func IngredientHandler(c echo.Context) error {
 			}
 
 			// Detect ingredients from the image
-			ingredientsMap, err := detectIngredients(fileBytes)
+			ingredientsMap, err := detectIngredients(ctx, fileBytes)
 			if err != nil {
-				imageChannel <- map[string]interface{}{"status": false, "error": err.Error()}
+				imageChannel <- map[string]interface{}{"status": false, "error": err.Error(), "quota": errors.Is(err, usage.ErrQuotaExceeded)}
 				return
 			}
 
@@ -168,47 +290,216 @@ func IngredientHandler(c echo.Context) error {
 	}()
 
 	var allIngredients []interface{}
+	var failures []string
+	outOfQuota := false
 	for res := range imageChannel {
-		if res["status"].(bool) {
-			if data, ok := res["data"].([]interface{}); ok {
-				allIngredients = append(allIngredients, data...)
+		if ok, _ := res["status"].(bool); !ok {
+			failures = append(failures, fmt.Sprintf("%v", res["error"]))
+			if quota, _ := res["quota"].(bool); quota {
+				outOfQuota = true
 			}
+			continue
+		}
+		if data, ok := res["data"].([]interface{}); ok {
+			allIngredients = append(allIngredients, data...)
 		}
 	}
 
 	// Remove duplicate
 	uniqueIngredients := removeDuplicates(allIngredients)
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"status": true,
 		"data":   uniqueIngredients,
-	})
+	}
+
+	// Fridge mode compares against the user's last fridge photo
+	if c.FormValue("mode") == "fridge" {
+		userID, err := currentUser(c)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		// A photo we couldn't read would look like food had been used up,
+		// so the fridge is only compared when every photo was read.
+		if len(failures) > 0 {
+			code := http.StatusUnprocessableEntity
+			if outOfQuota {
+				code = http.StatusTooManyRequests
+			}
+			return c.JSON(code, map[string]string{
+				"error": fmt.Sprintf("Fridge not updated: %d of %d photos could not be read: %s", len(failures), len(form.File["images"]), strings.Join(failures, "; ")),
+			})
+		}
+		names := make([]string, 0, len(uniqueIngredients))
+		for _, v := range uniqueIngredients {
+			names = append(names, fmt.Sprintf("%v", v))
+		}
+		diff, err := fridgeDiff(userID, names, c.FormValue("apply") == "true")
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		response["diff"] = diff
+		return c.JSON(http.StatusOK, response)
+	}
+
+	// One-click "add to pantry" for signed-in users
+	if c.FormValue("add_to_pantry") == "true" {
+		userID, err := currentUser(c)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		names := make([]string, 0, len(uniqueIngredients))
+		for _, v := range uniqueIngredients {
+			names = append(names, fmt.Sprintf("%v", v))
+		}
+		added, err := addDetectedToPantry(userID, names)
+		if err != nil {
+			response["pantry_error"] = err.Error()
+		} else {
+			response["pantry"] = added
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 func RecipeHandler(c echo.Context) error {
+	ctx := c.Request().Context()
 	var data struct {
 		Ingredients []string `json:"ingredients"`
+		Text        string   `json:"text"`
 		Dish        string   `json:"dish"`
+		Units       string   `json:"units"`
+		Strict      bool     `json:"strict"`
+		UsePantry   *bool    `json:"use_pantry"`
+		// Mode "offline" only matches the corpus, without Gemini
+		Mode string `json:"mode"`
 	}
 
 	if err := c.Bind(&data); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No ingredients provided"})
 	}
 
-	//Get food recipes using detected ingredients from Gemini API
-	recipe, err := getFoodRecipes(data.Ingredients, data.Dish)
+	if data.Units == "" {
+		data.Units = c.QueryParam("units")
+	}
+	loc, err := parseUnits(data.Units)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if data.Mode == "" {
+		data.Mode = c.QueryParam("mode")
+	}
+	if data.Mode != "" && data.Mode != "offline" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown mode " + data.Mode})
+	}
+
+	items := parseIngredientInput(data.Ingredients, data.Text)
+
+	// Cook from the pantry when no ingredients were given, or alongside them
+	// when asked to
+	usePantry := len(items) == 0
+	if data.UsePantry != nil {
+		usePantry = *data.UsePantry
+	}
+	if usePantry {
+		userID, err := currentUser(c)
+		if err != nil && data.UsePantry != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		if err == nil {
+			have, err := pantryIngredients(userID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+			items = append(items, have...)
+		}
+	}
+
+	ingredients := make([]string, 0, len(items))
+	for _, item := range items {
+		ingredients = append(ingredients, item.Raw)
 	}
-	query := fmt.Sprintf("How to make %s", data.Dish)
-	yt, err := service.YoutubeSearch(query)
+	in := history.Input{Ingredients: ingredients, Dish: data.Dish, Strict: data.Strict, Units: data.Units}
+
+	// Corpus recipes are ready straight away, and stand in for the generated
+	// one when Gemini is down or too slow
+	matches := corpusMatches(items, loc)
+	if data.Mode == "offline" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status":      true,
+			"generated":   false,
+			"matches":     matches,
+			"ingredients": items,
+		})
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, RecipeTimeout)
+	defer cancel()
+	start := time.Now()
+	response, err := recipeResponse(genCtx, items, in, loc)
+	recordHistory(c, historyEntry(history.Recipe, in, start, response, err), nil, response)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		if len(matches) == 0 {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status":           true,
+			"generated":        false,
+			"matches":          matches,
+			"ingredients":      items,
+			"generation_error": err.Error(),
+		})
 	}
+	response["generated"] = true
+	response["matches"] = matches
+	startChat(c, response)
+	return c.JSON(http.StatusOK, response)
+}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status": true,
-		"data":   recipe,
-		"yt":     yt,
-	})
+// recipeResponse runs the /recipe pipeline for the ingredients in items,
+// in.Ingredients being their raw text.
+func recipeResponse(ctx context.Context, items []ingredient.Item, in history.Input, loc *units.Locale) (map[string]interface{}, error) {
+	ingredients := in.Ingredients
+
+	//Get food recipes using detected ingredients from Gemini API
+	r, err := getFoodRecipes(ctx, ingredients, in.Dish, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the recipe sticks to what the user has. In strict mode ask for
+	// another recipe without the extras before settling for a shopping list.
+	have := ingredient.Names(items)
+	compliance := recipe.Check(r, have)
+	for attempt := 0; in.Strict && len(have) > 0 && !compliance.Compliant && attempt < maxRegenerations; attempt++ {
+		retry, err := getFoodRecipes(ctx, ingredients, in.Dish, loc, compliance.Missing)
+		if err != nil {
+			break
+		}
+		if retryCompliance := recipe.Check(retry, have); len(retryCompliance.Missing) < len(compliance.Missing) {
+			r, compliance = retry, retryCompliance
+		}
+	}
+
+	dish := in.Dish
+	if dish == "" {
+		dish = r.Title
+	}
+	response := map[string]interface{}{
+		"status":      true,
+		"data":        r.Markdown(),
+		"recipe":      r,
+		"ingredients": items,
+		"compliance":  compliance,
+	}
+
+	// The recipe stands without videos
+	query := fmt.Sprintf("How to make %s", dish)
+	yt, err := service.YoutubeSearch(ctx, query)
+	if err != nil {
+		response["yt_error"] = err.Error()
+	}
+	response["yt"] = yt
+	return response, nil
 }
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/usage"
+	"github.com/labstack/echo/v4"
+)
+
+// apiKeyIDKey is where Authenticate leaves the ID of the API key a request
+// was made with.
+const apiKeyIDKey = "api_key_id"
+
+// MaxKeyQuota is the most any API key may use. Keys can be issued with lower
+// limits but not higher ones.
+var MaxKeyQuota = apikey.Quota{
+	GeminiDaily:    200,
+	GeminiMonthly:  3000,
+	YouTubeDaily:   50,
+	YouTubeMonthly: 1000,
+}
+
+// withAPIKey runs a request made with an API key on behalf of the key's
+// owner. Every Gemini and YouTube call the request makes is counted against
+// the key, and calls beyond what is left of its quota fail. Since nearly
+// every endpoint needs Gemini, a key with no Gemini calls left is refused
+// outright.
+//
+// Concurrent requests on one key each start from the usage stored when they
+// began, so a burst can go over by what the requests in flight use.
+func withAPIKey(c echo.Context, next echo.HandlerFunc, secret string) error {
+	key, err := apiKeyStore.Lookup(secret)
+	if errors.Is(err, apikey.ErrNotFound) || errors.Is(err, apikey.ErrRevoked) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now()
+	day, month, err := apiKeyStore.Usage(key.ID, now)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	status := key.Check(day, month, now)
+	if status.Remaining[usage.Gemini] == 0 {
+		setQuotaHeaders(c, status, nil)
+		setRetryAfter(c, status, []usage.Service{usage.Gemini}, now)
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Gemini quota exceeded for this API key"})
+	}
+
+	meter := usage.NewMeter(status.Remaining)
+	c.SetRequest(c.Request().WithContext(usage.WithMeter(c.Request().Context(), meter)))
+	c.Set(userIDKey, key.UserID)
+	c.Set(apiKeyIDKey, key.ID)
+	c.Response().Before(func() {
+		setQuotaHeaders(c, status, meter.Counts())
+		// Handlers answer a refused call like any other failure, but it's
+		// the caller's quota that ran out, so tell them when to come back.
+		res := c.Response()
+		if refused := meter.Refused(); len(refused) > 0 && (res.Status >= 500 || res.Status == http.StatusTooManyRequests) {
+			res.Status = http.StatusTooManyRequests
+			setRetryAfter(c, status, refused, time.Now())
+		}
+	})
+
+	err = next(c)
+	if err := apiKeyStore.AddUsage(key.ID, meter.Counts(), now); err != nil {
+		c.Logger().Errorf("Failed to record usage for API key %s: %v", key.ID, err)
+	}
+	return err
+}
+
+// setRetryAfter tells the client how long until the first of the services
+// it ran out of resets.
+func setRetryAfter(c echo.Context, status apikey.Status, services []usage.Service, now time.Time) {
+	var wait time.Duration
+	for i, s := range services {
+		if d := status.Reset[s].Sub(now); i == 0 || d < wait {
+			wait = d
+		}
+	}
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+}
+
+// setQuotaHeaders tells the client what its key has left after this
+// request's calls, and when each quota resets.
+func setQuotaHeaders(c echo.Context, status apikey.Status, used map[usage.Service]int) {
+	h := c.Response().Header()
+	for s, name := range map[usage.Service]string{usage.Gemini: "Gemini", usage.YouTube: "YouTube"} {
+		h.Set("X-Quota-"+name+"-Remaining", strconv.Itoa(max(status.Remaining[s]-used[s], 0)))
+		h.Set("X-Quota-"+name+"-Reset", status.Reset[s].Format(time.RFC3339))
+	}
+}
+
+// accountUser is currentUser for endpoints that manage the account itself,
+// which a leaked API key mustn't be able to use.
+func accountUser(c echo.Context) (string, int, error) {
+	if c.Get(apiKeyIDKey) != nil {
+		return "", http.StatusForbidden, errors.New("API keys cannot manage API keys, sign in instead")
+	}
+	userID, err := currentUser(c)
+	if err != nil {
+		return "", http.StatusUnauthorized, err
+	}
+	return userID, http.StatusOK, nil
+}
+
+// CreateAPIKeyHandler issues a key. The secret is in this response only.
+func CreateAPIKeyHandler(c echo.Context) error {
+	userID, code, err := accountUser(c)
+	if err != nil {
+		return c.JSON(code, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		Name  string       `json:"name"`
+		Quota apikey.Quota `json:"quota"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name is required"})
+	}
+
+	key, secret, err := apiKeyStore.Create(userID, data.Name, data.Quota.Within(MaxKeyQuota), time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"status": true,
+		"data":   key,
+		"key":    secret,
+	})
+}
+
+// ListAPIKeysHandler lists the user's keys with what each has left today
+// and this month.
+func ListAPIKeysHandler(c echo.Context) error {
+	userID, code, err := accountUser(c)
+	if err != nil {
+		return c.JSON(code, map[string]string{"error": err.Error()})
+	}
+	keys, err := apiKeyStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type keyUsage struct {
+		apikey.Key
+		Today     apikey.Usage  `json:"today"`
+		ThisMonth apikey.Usage  `json:"this_month"`
+		Status    apikey.Status `json:"quota_status"`
+	}
+	now := time.Now()
+	out := make([]keyUsage, 0, len(keys))
+	for _, k := range keys {
+		day, month, err := apiKeyStore.Usage(k.ID, now)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		out = append(out, keyUsage{Key: k, Today: day, ThisMonth: month, Status: k.Check(day, month, now)})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   out,
+	})
+}
+
+func RevokeAPIKeyHandler(c echo.Context) error {
+	userID, code, err := accountUser(c)
+	if err != nil {
+		return c.JSON(code, map[string]string{"error": err.Error()})
+	}
+	key, err := apiKeyStore.Revoke(userID, c.Param("id"), time.Now())
+	if errors.Is(err, apikey.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   key,
+	})
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/auth"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/Oluwaseun241/mura/internal/usage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer sets up fresh stores and an echo instance that
+// authenticates requests the way main does.
+func newTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	InitStorage(storagetest.Open(t))
+	InitAuth(auth.NewIssuer([]byte("secret"), 15*time.Minute, time.Hour))
+	e := echo.New()
+	e.Use(Authenticate)
+	return e
+}
+
+// newAPIKey issues a key for ada with the given quota.
+func newAPIKey(t *testing.T, quota apikey.Quota) string {
+	t.Helper()
+	_, secret, err := apiKeyStore.Create("ada", "test", quota.Within(MaxKeyQuota), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func serve(e *echo.Echo, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIKeyRunningOutOfQuota(t *testing.T) {
+	e := newTestServer(t)
+	// Each request wants two Gemini calls and fails like a handler does
+	// when one is refused
+	e.GET("/generate", func(c echo.Context) error {
+		for i := 0; i < 2; i++ {
+			if err := usage.Use(c.Request().Context(), usage.Gemini); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+	})
+	key := newAPIKey(t, apikey.Quota{GeminiDaily: 3})
+
+	rec := serve(e, http.MethodGet, "/generate", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Quota-Gemini-Remaining"))
+
+	// The second call of the next request is refused
+	rec = serve(e, http.MethodGet, "/generate", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-Quota-Gemini-Remaining"))
+	wait, err := strconv.Atoi(rec.Header().Get(echo.HeaderRetryAfter))
+	assert.NoError(t, err)
+	assert.Greater(t, wait, 0)
+
+	// and then the key is refused before any handler runs
+	rec = serve(e, http.MethodGet, "/generate", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+}
+
+func TestAuthenticateRefusesBadCredentials(t *testing.T) {
+	e := newTestServer(t)
+	e.GET("/whoami", func(c echo.Context) error {
+		userID, _ := currentUser(c)
+		return c.String(http.StatusOK, userID)
+	})
+	tokens, err := tokenIssuer.Issue("ada")
+	assert.NoError(t, err)
+	key := newAPIKey(t, apikey.Quota{})
+
+	for name, header := range map[string]map[string]string{
+		"garbage bearer":     {echo.HeaderAuthorization: "Bearer not-a-token"},
+		"refresh as access":  {echo.HeaderAuthorization: "Bearer " + tokens.RefreshToken},
+		"not bearer":         {echo.HeaderAuthorization: "Basic YWRhOnB3"},
+		"not an API key":     {"X-API-Key": "nonsense"},
+		"unknown API key":    {"X-API-Key": key + "x"},
+		"unknown key bearer": {echo.HeaderAuthorization: "Bearer " + key + "x"},
+	} {
+		rec := serve(e, http.MethodGet, "/whoami", header)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, name)
+	}
+
+	// Anonymous requests go through without a user
+	rec := serve(e, http.MethodGet, "/whoami", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	for _, header := range []map[string]string{
+		{echo.HeaderAuthorization: "Bearer " + tokens.AccessToken},
+		{"X-API-Key": key},
+		{echo.HeaderAuthorization: "Bearer " + key},
+	} {
+		rec := serve(e, http.MethodGet, "/whoami", header)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "ada", rec.Body.String())
+	}
+}
+
+func TestRevokedAPIKey(t *testing.T) {
+	e := newTestServer(t)
+	e.GET("/pantry", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, RequireUser)
+	key := newAPIKey(t, apikey.Quota{})
+
+	rec := serve(e, http.MethodGet, "/pantry", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	keys, err := apiKeyStore.List("ada")
+	assert.NoError(t, err)
+	_, err = apiKeyStore.Revoke("ada", keys[0].ID, time.Now())
+	assert.NoError(t, err)
+	rec = serve(e, http.MethodGet, "/pantry", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), apikey.ErrRevoked.Error())
+}
+
+func TestAPIKeysCantManageAPIKeys(t *testing.T) {
+	e := newTestServer(t)
+	e.GET("/api-keys", ListAPIKeysHandler, RequireUser)
+	e.POST("/api-keys", CreateAPIKeyHandler, RequireUser)
+	key := newAPIKey(t, apikey.Quota{})
+
+	rec := serve(e, http.MethodGet, "/api-keys", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serve(e, http.MethodPost, "/api-keys", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	tokens, err := tokenIssuer.Issue("ada")
+	assert.NoError(t, err)
+	rec = serve(e, http.MethodGet, "/api-keys", map[string]string{echo.HeaderAuthorization: "Bearer " + tokens.AccessToken})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"quota_status"`)
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// userIDKey is where Authenticate leaves the signed-in user's ID on the
+// echo context.
+const userIDKey = "user_id"
+
+var tokenIssuer *auth.Issuer
+
+// InitAuth sets the issuer used to sign and check tokens.
+func InitAuth(issuer *auth.Issuer) {
+	tokenIssuer = issuer
+}
+
+// Authenticate attaches the caller to requests that carry a bearer token or
+// an API key, in either Authorization or X-API-Key. Requests without one go
+// through anonymously, so detection and recipes keep working signed out; a
+// bad or expired credential is refused rather than treated as anonymous, so
+// clients know to fix it.
+func Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if key := strings.TrimSpace(c.Request().Header.Get("X-API-Key")); key != "" {
+			if !apikey.IsKey(key) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": apikey.ErrNotFound.Error()})
+			}
+			return withAPIKey(c, next, key)
+		}
+
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		if header == "" {
+			return next(c)
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Authorization header must be a Bearer token"})
+		}
+		token = strings.TrimSpace(token)
+		if apikey.IsKey(token) {
+			return withAPIKey(c, next, token)
+		}
+		userID, err := tokenIssuer.Verify(token, auth.AccessToken)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		c.Set(userIDKey, userID)
+		return next(c)
+	}
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+func RegisterHandler(c echo.Context) error {
+	var data credentials
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	user, err := auth.Register(authStore, data.Email, data.Password, data.Name, time.Now())
+	switch {
+	case errors.Is(err, auth.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrLongPassword):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return signedIn(c, http.StatusCreated, user)
+}
+
+func LoginHandler(c echo.Context) error {
+	var data credentials
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	user, err := auth.Login(authStore, data.Email, data.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return signedIn(c, http.StatusOK, user)
+}
+
+// RefreshTokenHandler swaps a refresh token for a new pair of tokens. Each
+// refresh token works once; presenting one that was already spent means it
+// leaked, so every session the user has is ended.
+func RefreshTokenHandler(c echo.Context) error {
+	var data struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.Bind(&data); err != nil || data.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No refresh_token provided"})
+	}
+
+	claims, err := tokenIssuer.Parse(data.RefreshToken, auth.RefreshToken)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	err = authStore.EndSession(claims.Subject, claims.Id)
+	if errors.Is(err, auth.ErrNotFound) {
+		if err := authStore.EndSessions(claims.Subject); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": auth.ErrInvalidToken.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	user, err := authStore.ByID(claims.Subject)
+	if errors.Is(err, auth.ErrNotFound) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": auth.ErrInvalidToken.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return signedIn(c, http.StatusOK, user)
+}
+
+// LogoutHandler revokes a refresh token, or with all every one the user
+// holds. Access tokens already issued run out on their own.
+func LogoutHandler(c echo.Context) error {
+	var data struct {
+		RefreshToken string `json:"refresh_token"`
+		All          bool   `json:"all"`
+	}
+	if err := c.Bind(&data); err != nil || data.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No refresh_token provided"})
+	}
+
+	claims, err := tokenIssuer.Parse(data.RefreshToken, auth.RefreshToken)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if data.All {
+		err = authStore.EndSessions(claims.Subject)
+	} else if err = authStore.EndSession(claims.Subject, claims.Id); errors.Is(err, auth.ErrNotFound) {
+		err = nil
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+}
+
+func MeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	user, err := authStore.ByID(userID)
+	if errors.Is(err, auth.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   user,
+	})
+}
+
+func signedIn(c echo.Context, code int, user auth.User) error {
+	tokens, err := tokenIssuer.Issue(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err := authStore.AddSession(user.ID, tokens.RefreshID, tokens.RefreshExpiresAt); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(code, map[string]interface{}{
+		"status": true,
+		"data":   user,
+		"tokens": tokens,
+	})
+}
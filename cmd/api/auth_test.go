@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/auth"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// postJSON calls a handler with a JSON body and decodes what it answers.
+func postJSON(t *testing.T, handler echo.HandlerFunc, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, handler(echo.New().NewContext(req, rec)))
+	var out map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &out)
+	return rec.Code, out
+}
+
+// refreshToken pulls the refresh token out of a sign in response.
+func refreshToken(out map[string]interface{}) string {
+	tokens, _ := out["tokens"].(map[string]interface{})
+	token, _ := tokens["refresh_token"].(string)
+	return token
+}
+
+func withRefresh(token string) string {
+	return `{"refresh_token": "` + token + `"}`
+}
+
+func TestRequireUser(t *testing.T) {
+	e := echo.New()
+	handler := RequireUser(func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get(userIDKey).(string))
+	})
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/diary", nil), rec)
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// A user header is no way to sign in
+	req := httptest.NewRequest(http.MethodGet, "/diary", nil)
+	req.Header.Set("X-User-ID", "someone-else")
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/diary", nil), rec)
+	c.Set(userIDKey, "u1")
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "u1", rec.Body.String())
+}
+
+func TestRefreshTokensRotate(t *testing.T) {
+	InitStorage(storagetest.Open(t))
+	InitAuth(auth.NewIssuer([]byte("secret"), 15*time.Minute, time.Hour))
+
+	code, out := postJSON(t, RegisterHandler, `{"email": "ada@example.com", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	first := refreshToken(out)
+
+	code, out = postJSON(t, RefreshTokenHandler, withRefresh(first))
+	assert.Equal(t, http.StatusOK, code)
+	second := refreshToken(out)
+	assert.NotEqual(t, first, second)
+
+	// Spending the first token again ends the session it was rotated into
+	code, _ = postJSON(t, RefreshTokenHandler, withRefresh(first))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = postJSON(t, RefreshTokenHandler, withRefresh(second))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, out = postJSON(t, LoginHandler, `{"email": "ada@example.com", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusOK, code)
+	third := refreshToken(out)
+	code, _ = postJSON(t, LogoutHandler, withRefresh(third))
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = postJSON(t, RefreshTokenHandler, withRefresh(third))
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/calendar"
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+	"github.com/labstack/echo/v4"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// PublicURL is where clients reach the server, such as
+// "https://api.example.com", with no trailing slash. Calendar feed URLs are
+// built from it rather than from the request's Host header, which the client
+// controls. Feeds aren't offered while it's empty.
+var PublicURL string
+
+var errNoPublicURL = errors.New("Calendar feeds are not available")
+
+// PlanCalendarHandler downloads one meal plan as an .ics file.
+func PlanCalendarHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	plan, err := mealPlanStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+
+	name := "Meal plan"
+	if len(plan.Days) > 0 {
+		name = fmt.Sprintf("Meal plan from %s", plan.Days[0].Date)
+	}
+	cal := calendar.FromPlans(name, []mealplan.Plan{*plan})
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="meal-plan-%s.ics"`, plan.ID))
+	return c.Blob(http.StatusOK, calendarContentType, []byte(cal.Encode()))
+}
+
+// CalendarFeedHandler returns the URL of the user's calendar feed, which
+// calendar apps can subscribe to. POST /calendar/feed/rotate replaces it if
+// it has been shared by mistake.
+func CalendarFeedHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if PublicURL == "" {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": errNoPublicURL.Error()})
+	}
+	token, err := calendarStore.Token(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, feedResponse(token))
+}
+
+func RotateCalendarFeedHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if PublicURL == "" {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": errNoPublicURL.Error()})
+	}
+	token, err := calendarStore.Rotate(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, feedResponse(token))
+}
+
+func feedResponse(token string) map[string]interface{} {
+	feed := PublicURL + "/calendar/" + token + ".ics"
+	_, rest, _ := strings.Cut(feed, "://")
+	return map[string]interface{}{
+		"status": true,
+		"data": map[string]string{
+			"url":    feed,
+			"webcal": "webcal://" + rest,
+		},
+	}
+}
+
+// CalendarSubscriptionHandler serves every meal plan of the user the token
+// belongs to. It is the only per-user endpoint that takes no bearer token or
+// API key, since calendar apps can't send one; the feed token in the URL
+// stands in for it, and rotating it cuts off old subscriptions.
+func CalendarSubscriptionHandler(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	userID, err := calendarStore.User(token)
+	if errors.Is(err, calendar.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	plans, err := mealPlanStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	cal := calendar.FromPlans("Mura meal plans", plans)
+	return c.Blob(http.StatusOK, calendarContentType, []byte(cal.Encode()))
+}
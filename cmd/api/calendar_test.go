@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeedUsesPublicURL(t *testing.T) {
+	e := newTestServer(t)
+	defer func(old string) { PublicURL = old }(PublicURL)
+
+	feed := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/calendar/feed", nil)
+		req.Host = "evil.example"
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(userIDKey, "ada")
+		assert.NoError(t, CalendarFeedHandler(c))
+		return rec
+	}
+
+	PublicURL = ""
+	assert.Equal(t, http.StatusServiceUnavailable, feed().Code)
+
+	PublicURL = "https://api.example.com"
+	rec := feed()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Data map[string]string `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, strings.HasPrefix(body.Data["url"], "https://api.example.com/calendar/"), body.Data["url"])
+	assert.True(t, strings.HasPrefix(body.Data["webcal"], "webcal://api.example.com/calendar/"), body.Data["webcal"])
+	assert.NotContains(t, rec.Body.String(), "evil.example")
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/cmd/client"
+	"github.com/Oluwaseun241/mura/internal/chat"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/usage"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	chatTTL = 30 * time.Minute
+	// maxChatHistory caps how many earlier messages are replayed to the model
+	// on each turn. Keep it even so the replay starts on a user message.
+	maxChatHistory = 20
+	// maxChatSessions and maxChatBytes bound the memory sessions take; past
+	// either the idlest sessions are dropped.
+	maxChatSessions = 10000
+	maxChatBytes    = 256 << 20
+)
+
+// chatSessions holds follow-up conversations about a recipe. They live in
+// memory and are dropped after chatTTL without activity.
+var chatSessions = chat.NewStore(chatTTL, maxChatSessions, maxChatBytes)
+
+// chatOwner is who a session belongs to: the signed-in user, or for
+// anonymous requests the IP address they came from.
+func chatOwner(c echo.Context) string {
+	if userID, err := currentUser(c); err == nil {
+		return "user:" + userID
+	}
+	return "ip:" + c.RealIP()
+}
+
+func CreateChatHandler(c echo.Context) error {
+	session := chat.Session{Owner: chatOwner(c)}
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid form data"})
+		}
+		if files := form.File["image"]; len(files) > 0 {
+			src, err := files[0].Open()
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open uploaded image"})
+			}
+			defer src.Close()
+
+			if session.Image, err = io.ReadAll(io.LimitReader(src, chat.MaxImageBytes+1)); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read uploaded image"})
+			}
+			if len(session.Image) > chat.MaxImageBytes {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("Image must be at most %d MB", chat.MaxImageBytes>>20)})
+			}
+		}
+		session.RecipeText = c.FormValue("recipe_text")
+	} else {
+		var data struct {
+			Recipe     *recipe.Recipe `json:"recipe"`
+			RecipeText string         `json:"recipe_text"`
+		}
+		if err := c.Bind(&data); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		}
+		session.Recipe, session.RecipeText = data.Recipe, data.RecipeText
+	}
+
+	if session.Recipe == nil && session.RecipeText == "" && len(session.Image) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Provide a recipe or an image to chat about"})
+	}
+
+	created, err := chatSessions.Create(session)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   created,
+	})
+}
+
+func GetChatHandler(c echo.Context) error {
+	session, err := chatSessions.Get(c.Param("id"), chatOwner(c))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   session,
+	})
+}
+
+func ChatMessageHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var data struct {
+		Message string `json:"message"`
+	}
+
+	if err := c.Bind(&data); err != nil || strings.TrimSpace(data.Message) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No message provided"})
+	}
+
+	id := c.Param("id")
+	owner := chatOwner(c)
+	session, err := chatSessions.Get(id, owner)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	sent := time.Now()
+	reply, err := sendChatMessage(ctx, session, data.Message)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	session, err = chatSessions.Append(id, owner,
+		chat.Message{Role: chat.RoleUser, Text: data.Message, Time: sent},
+		chat.Message{Role: chat.RoleModel, Text: reply, Time: time.Now()},
+	)
+	if errors.Is(err, chat.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data": map[string]interface{}{
+			"reply":   reply,
+			"history": session.History,
+		},
+	})
+}
+
+func DeleteChatHandler(c echo.Context) error {
+	chatSessions.Delete(c.Param("id"), chatOwner(c))
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+}
+
+// startChat opens a session for the recipe in a response we just generated
+// so the client can ask follow-up questions straight away, and sets its
+// chat_id. These sessions are opened for every recipe, so they hold the
+// recipe only; a client that wants to discuss the photo opens its own with
+// POST /chat. A failure here shouldn't fail the recipe itself, so it only
+// leaves the ID empty.
+func startChat(c echo.Context, response map[string]interface{}) {
+	session := chat.Session{Owner: chatOwner(c)}
+	if r, ok := response["recipe"].(*recipe.Recipe); ok && r != nil {
+		session.Recipe = r
+	} else if text, ok := response["data"].(string); ok {
+		session.RecipeText = text
+	} else {
+		return
+	}
+	created, err := chatSessions.Create(session)
+	if err != nil {
+		response["chat_id"] = ""
+		return
+	}
+	response["chat_id"] = created.ID
+}
+
+func sendChatMessage(ctx context.Context, session chat.Session, message string) (string, error) {
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return "", err
+	}
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
+	model.SystemInstruction = genai.NewUserContent(genai.Text(fmt.Sprintf("You are a helpful, AI assistant devoted to providing accurate and delightful recipes. The user has already been given the recipe below and is asking follow-up questions about it, such as changing the cooking method, equipment or ingredients. Keep every answer grounded in this recipe, say exactly which steps, times and temperatures change, and answer in markdown.\n\n%s", session.Context())))
+
+	cs := model.StartChat()
+	// The photo goes in once as the opening turn so later questions can
+	// refer to it without it being uploaded again.
+	if len(session.Image) > 0 {
+		cs.History = append(cs.History,
+			&genai.Content{Role: chat.RoleUser, Parts: []genai.Part{genai.ImageData("jpeg", session.Image), genai.Text("This is a photo of the dish the recipe is for.")}},
+			&genai.Content{Role: chat.RoleModel, Parts: []genai.Part{genai.Text("Thanks, I can see the dish.")}},
+		)
+	}
+
+	history := session.History
+	if len(history) > maxChatHistory {
+		history = history[len(history)-maxChatHistory:]
+	}
+	for _, m := range history {
+		cs.History = append(cs.History, &genai.Content{Role: m.Role, Parts: []genai.Part{genai.Text(m.Text)}})
+	}
+
+	resp, err := cs.SendMessage(ctx, genai.Text(message))
+	if err != nil {
+		return "", fmt.Errorf("error generating content: %w", err)
+	}
+	if resp == nil {
+		return "", fmt.Errorf("no response received from Gemini API")
+	}
+	return strings.TrimSpace(printResponse(resp)), nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/chat"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// chatRequest runs handler for a request by userID, anonymous if empty.
+func chatRequest(handler echo.HandlerFunc, req *http.Request, userID, id string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if userID != "" {
+		c.Set(userIDKey, userID)
+	}
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	handler(c)
+	return rec
+}
+
+func TestChatSessionsBelongToTheirOwner(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/chat", bytes.NewBufferString(`{"recipe_text": "# Jollof"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := chatRequest(CreateChatHandler, req, "ada", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var out struct {
+		Data chat.Session `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	id := out.Data.ID
+
+	get := func(userID string) int {
+		return chatRequest(GetChatHandler, httptest.NewRequest(http.MethodGet, "/chat/"+id, nil), userID, id).Code
+	}
+	assert.Equal(t, http.StatusNotFound, get("bola"))
+	assert.Equal(t, http.StatusNotFound, get(""))
+	chatRequest(DeleteChatHandler, httptest.NewRequest(http.MethodDelete, "/chat/"+id, nil), "bola", id)
+	assert.Equal(t, http.StatusOK, get("ada"))
+}
+
+func TestChatRefusesLargeImages(t *testing.T) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, _ := w.CreateFormFile("image", "dish.jpeg")
+	part.Write(make([]byte, chat.MaxImageBytes+1))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/chat", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, chatRequest(CreateChatHandler, req, "ada", "").Code)
+}
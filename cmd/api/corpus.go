@@ -0,0 +1,32 @@
+package api
+
+import (
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/corpus"
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// RecipeCorpus holds the recipes /recipe matches ingredients against
+// without asking Gemini. main adds any recipes imported at startup.
+var RecipeCorpus = corpus.Default()
+
+// RecipeTimeout is how long /recipe waits for Gemini before answering with
+// corpus recipes instead. It's kept under the request timeout so there's
+// time left to answer.
+var RecipeTimeout = 20 * time.Second
+
+// corpusMatchLimit is how many corpus recipes /recipe returns.
+const corpusMatchLimit = 5
+
+// corpusMatches ranks the corpus recipes that use the ingredients in items.
+func corpusMatches(items []ingredient.Item, loc *units.Locale) []corpus.Match {
+	matches := RecipeCorpus.Match(ingredient.Names(items), corpusMatchLimit)
+	if loc != nil {
+		for _, m := range matches {
+			m.Recipe.Localize(*loc)
+		}
+	}
+	return matches
+}
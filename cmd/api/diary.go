@@ -0,0 +1,289 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/diary"
+	"github.com/Oluwaseun241/mura/internal/nutrition"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/labstack/echo/v4"
+)
+
+// CreateDiaryEntryHandler logs a meal. The food can come from a portion
+// estimate (the portions of a /detect-food or /estimate-portion response), a
+// structured recipe and a number of servings, explicit nutrition, or just a
+// name and weight we can look up.
+func CreateDiaryEntryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		Time      *time.Time        `json:"time"`
+		Meal      string            `json:"meal"`
+		Name      string            `json:"name"`
+		Grams     float64           `json:"grams"`
+		Servings  float64           `json:"servings"`
+		Nutrition *recipe.Nutrition `json:"nutrition"`
+		Portions  *PlateEstimate    `json:"portions"`
+		Recipe    *recipe.Recipe    `json:"recipe"`
+		Note      string            `json:"note"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	entry := diary.Entry{Time: time.Now(), Name: strings.TrimSpace(data.Name), Grams: data.Grams, Servings: data.Servings, Note: data.Note}
+	if data.Time != nil {
+		entry.Time = *data.Time
+	}
+	entry.Meal = diary.MealFor(entry.Time)
+	if data.Meal != "" {
+		if entry.Meal, err = diary.ValidMeal(data.Meal); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	switch {
+	case data.Portions != nil && len(data.Portions.Components) > 0:
+		total := nutrition.Total(data.Portions.Components)
+		entry.Components = data.Portions.Components
+		entry.Grams = total.Grams.Estimate
+		entry.Nutrition = recipe.Nutrition{
+			Calories: total.Calories.Estimate,
+			Protein:  total.Protein.Estimate,
+			Carbs:    total.Carbs.Estimate,
+			Fat:      total.Fat.Estimate,
+		}
+		if entry.Name == "" {
+			var names []string
+			for _, p := range data.Portions.Components {
+				names = append(names, p.Name)
+			}
+			entry.Name = strings.Join(names, ", ")
+		}
+		entry.Source = "photo"
+	case data.Recipe != nil:
+		if entry.Servings <= 0 {
+			entry.Servings = 1
+		}
+		n := data.Recipe.Nutrition
+		entry.Nutrition = recipe.Nutrition{
+			Calories: n.Calories * entry.Servings,
+			Protein:  n.Protein * entry.Servings,
+			Carbs:    n.Carbs * entry.Servings,
+			Fat:      n.Fat * entry.Servings,
+		}
+		if entry.Name == "" {
+			entry.Name = data.Recipe.Title
+		}
+		entry.Source = "recipe"
+	case data.Nutrition != nil:
+		entry.Nutrition = *data.Nutrition
+		entry.Source = "manual"
+	case entry.Name != "" && entry.Grams > 0:
+		if _, ok := nutrition.Lookup(entry.Name); !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("No nutrition data for %q, please provide nutrition", entry.Name)})
+		}
+		grams := nutrition.Range{Low: entry.Grams, Estimate: entry.Grams, High: entry.Grams}
+		p := nutrition.Estimate(entry.Name, grams, recipe.Nutrition{})
+		entry.Nutrition = recipe.Nutrition{
+			Calories: p.Calories.Estimate,
+			Protein:  p.Protein.Estimate,
+			Carbs:    p.Carbs.Estimate,
+			Fat:      p.Fat.Estimate,
+		}
+		entry.Source = "table"
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Provide portions, a recipe, nutrition, or a name and grams"})
+	}
+	if entry.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name is required"})
+	}
+
+	entry, err = diaryStore.Add(userID, entry)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   entry,
+	})
+}
+
+// GetDiaryHandler returns one day's entries and totals. date defaults to
+// today and tz (an IANA zone such as Africa/Lagos) to UTC.
+func GetDiaryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	loc, day, err := parseDiaryDay(c.QueryParam("tz"), c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	from, to := diary.DayBounds(day, 1, loc)
+	entries, summary, err := diarySummary(userID, from, to, loc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":  true,
+		"data":    entries,
+		"summary": summary.Days[0],
+	})
+}
+
+// GetDiaryWeekHandler totals the seven days starting at start, which
+// defaults to six days ago so the week ends today.
+func GetDiaryWeekHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	loc, day, err := parseDiaryDay(c.QueryParam("tz"), c.QueryParam("start"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if c.QueryParam("start") == "" {
+		day = day.AddDate(0, 0, -6)
+	}
+
+	from, to := diary.DayBounds(day, 7, loc)
+	_, summary, err := diarySummary(userID, from, to, loc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   summary,
+	})
+}
+
+// ExportDiaryHandler downloads the entries from from to to inclusive as CSV.
+// Both default to the last 30 days.
+func ExportDiaryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	loc, end, err := parseDiaryDay(c.QueryParam("tz"), c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	_, start, err := parseDiaryDay(c.QueryParam("tz"), c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if c.QueryParam("from") == "" {
+		start = end.AddDate(0, 0, -29)
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from must not be after to"})
+	}
+
+	from, _ := diary.DayBounds(start, 1, loc)
+	_, to := diary.DayBounds(end, 1, loc)
+	entries, err := diaryStore.List(userID, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q",
+		fmt.Sprintf("mura-diary-%s-to-%s.csv", start.Format(time.DateOnly), end.Format(time.DateOnly))))
+	c.Response().WriteHeader(http.StatusOK)
+	return diary.WriteCSV(c.Response(), entries, loc)
+}
+
+func DeleteDiaryEntryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err := diaryStore.Delete(userID, c.Param("id")); err != nil {
+		if errors.Is(err, diary.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+}
+
+func GetDiaryTargetsHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	targets, err := diaryStore.Targets(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   targets,
+	})
+}
+
+func SetDiaryTargetsHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	var targets diary.Targets
+	if err := c.Bind(&targets); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if targets.Calories < 0 || targets.Protein < 0 || targets.Carbs < 0 || targets.Fat < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Targets cannot be negative"})
+	}
+	if err := diaryStore.SetTargets(userID, targets); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   targets,
+	})
+}
+
+// parseDiaryDay reads the tz and date (YYYY-MM-DD) query parameters. An
+// empty date is today in that zone.
+func parseDiaryDay(tz, date string) (*time.Location, time.Time, error) {
+	loc := time.UTC
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, time.Time{}, fmt.Errorf("unknown time zone %q", tz)
+		}
+	}
+	if date == "" {
+		return loc, time.Now().In(loc), nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, loc)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	return loc, day, nil
+}
+
+func diarySummary(userID string, from, to time.Time, loc *time.Location) ([]diary.Entry, diary.Summary, error) {
+	entries, err := diaryStore.List(userID, from, to)
+	if err != nil {
+		return nil, diary.Summary{}, err
+	}
+	targets, err := diaryStore.Targets(userID)
+	if err != nil {
+		return nil, diary.Summary{}, err
+	}
+	// to is the start of the day after the range.
+	return entries, diary.Summarize(entries, from, to.Add(-time.Nanosecond), loc, targets), nil
+}
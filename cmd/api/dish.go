@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// maxDishes caps how many dishes from one photo get a recipe, whatever the
+// client asks for.
+const maxDishes = 6
+
+// DishConcurrency is how many dish recipes are generated at once for a
+// multi-dish photo. main sets it from the environment.
+var DishConcurrency = 3
+
+// parseMaxDishes reads the client's max_dishes value, defaulting to and
+// never exceeding maxDishes.
+func parseMaxDishes(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || n > maxDishes {
+		return maxDishes
+	}
+	return n
+}
+
+// expandDishes writes a recipe for each selected dish, at most
+// DishConcurrency at a time. A dish that fails keeps its error instead of a
+// recipe so the others are still returned.
+func expandDishes(ctx context.Context, images [][]byte, dishes []service.Dish, selected []int, loc *units.Locale) {
+	limit := DishConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for _, i := range selected {
+		wg.Add(1)
+		go func(d *service.Dish) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			text, err := detectFood(ctx, images, d.Name, loc)
+			if err != nil {
+				d.Error = err.Error()
+				return
+			}
+			d.Recipe = text
+		}(&dishes[i])
+	}
+	wg.Wait()
+}
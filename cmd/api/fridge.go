@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/fridge"
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+)
+
+// fridgeChange is a fridge diff and, when it was applied, what it did to the
+// pantry.
+type fridgeChange struct {
+	fridge.Diff
+	Previous      *time.Time    `json:"previous_snapshot,omitempty"`
+	Applied       bool          `json:"applied"`
+	PantryAdded   []pantry.Item `json:"pantry_added,omitempty"`
+	PantryRemoved []pantry.Item `json:"pantry_removed,omitempty"`
+}
+
+// fridgeDiff compares what was just detected with the user's last fridge
+// snapshot and saves the new one. With apply, added items go into the pantry
+// and pantry items no longer seen are taken out.
+func fridgeDiff(userID string, detected []string, apply bool) (*fridgeChange, error) {
+	prev, err := fridgeStore.Latest(userID)
+	if err != nil && !errors.Is(err, fridge.ErrNotFound) {
+		return nil, err
+	}
+
+	change := &fridgeChange{Diff: fridge.Compare(prev.Items, detected)}
+	if !prev.TakenAt.IsZero() {
+		change.Previous = &prev.TakenAt
+	}
+
+	if apply {
+		if change.PantryAdded, err = addDetectedToPantry(userID, change.Added); err != nil {
+			return nil, err
+		}
+		if change.PantryRemoved, err = removeFromPantry(userID, change.Removed); err != nil {
+			return nil, err
+		}
+		change.Applied = true
+	}
+
+	if err := fridgeStore.Save(userID, fridge.Snapshot{Items: detected, TakenAt: time.Now()}); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// removeFromPantry deletes the pantry items that are any of the named
+// ingredients. Only exact matches go: a missing "pepper" says nothing about
+// the black pepper.
+func removeFromPantry(userID string, names []string) ([]pantry.Item, error) {
+	items, err := pantryStore.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	var removed []pantry.Item
+	for _, item := range items {
+		for _, name := range names {
+			if key := ingredient.Normalize(name); key == "" || ingredient.Normalize(item.Name) != key {
+				continue
+			}
+			if err := pantryStore.Delete(userID, item.ID); err != nil && !errors.Is(err, pantry.ErrNotFound) {
+				return nil, err
+			}
+			removed = append(removed, item)
+			break
+		}
+	}
+	return removed, nil
+}
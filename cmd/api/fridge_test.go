@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// fridgeRequest posts photos to /detect in fridge mode. Each photo's bytes
+// are the comma-separated foods the stubbed detector finds in it.
+func fridgeRequest(t *testing.T, photos []string, apply bool) *httptest.ResponseRecorder {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for _, p := range photos {
+		part, err := w.CreateFormFile("images", "fridge.jpeg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(p))
+	}
+	w.WriteField("mode", "fridge")
+	if apply {
+		w.WriteField("apply", "true")
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/detect", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(userIDKey, "ada")
+	assert.NoError(t, IngredientHandler(c))
+	return rec
+}
+
+func pantryNames(t *testing.T) []string {
+	t.Helper()
+	items, err := pantryStore.List("ada")
+	assert.NoError(t, err)
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestFridgeModeKeepsPantryWhenAPhotoFails(t *testing.T) {
+	InitStorage(storagetest.Open(t))
+	detect := detectIngredients
+	defer func() { detectIngredients = detect }()
+	detectIngredients = func(ctx context.Context, file []byte) (map[string]interface{}, error) {
+		if string(file) == "blurry" {
+			return nil, errors.New("error generating content")
+		}
+		var foods []interface{}
+		for _, f := range strings.Split(string(file), ",") {
+			foods = append(foods, f)
+		}
+		return map[string]interface{}{"foods": foods}, nil
+	}
+
+	for _, name := range []string{"milk", "pepper", "black pepper"} {
+		_, err := pantryStore.Put("ada", pantry.Item{Name: name})
+		assert.NoError(t, err)
+	}
+	rec := fridgeRequest(t, []string{"milk", "pepper"}, false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The milk photo was read but the other wasn't, so nothing is removed
+	rec = fridgeRequest(t, []string{"milk", "blurry"}, true)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "1 of 2 photos")
+	assert.ElementsMatch(t, []string{"milk", "pepper", "black pepper"}, pantryNames(t))
+	snap, err := fridgeStore.Latest("ada")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"milk", "pepper"}, snap.Items)
+
+	// Using up the pepper leaves the black pepper alone
+	rec = fridgeRequest(t, []string{"milk"}, true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.ElementsMatch(t, []string{"milk", "black pepper"}, pantryNames(t))
+}
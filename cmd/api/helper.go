@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"strings"
 
+	"github.com/Oluwaseun241/mura/internal/units"
 	"github.com/google/generative-ai-go/genai"
 )
 
@@ -33,3 +37,56 @@ func removeDuplicates(elements []interface{}) []interface{} {
 
 	return result
 }
+
+// parseUnits reads the optional units= parameter. A nil locale means the
+// recipe is returned in whatever units the model chose.
+func parseUnits(value string) (*units.Locale, error) {
+	if value == "" {
+		return nil, nil
+	}
+	loc, err := units.ParseLocale(value)
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// unitsPrompt asks the model to write measurements in the requested system so
+// that the post-processing in units.ConvertText has less to fix up.
+func unitsPrompt(loc *units.Locale) string {
+	if loc == nil {
+		return ""
+	}
+	switch loc.System {
+	case units.US:
+		return " Write every measurement in US customary units (cups, tablespoons, teaspoons, ounces, pounds) and oven temperatures in °F."
+	case units.Imperial:
+		return " Write every measurement in UK imperial units (fluid ounces, pints, ounces, pounds) and oven temperatures as gas marks."
+	}
+	return " Write every measurement in metric units (grams, kilograms, millilitres, litres) and oven temperatures in °C."
+}
+
+func localizeRecipe(recipe string, loc *units.Locale) string {
+	if loc == nil {
+		return recipe
+	}
+	return loc.ConvertText(recipe)
+}
+
+// readImages reads every uploaded photo into memory.
+func readImages(files []*multipart.FileHeader) ([][]byte, error) {
+	images := make([][]byte, 0, len(files))
+	for _, file := range files {
+		src, err := file.Open()
+		if err != nil {
+			return nil, errors.New("Failed to open uploaded image")
+		}
+		fileBytes, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			return nil, errors.New("Failed to read uploaded image")
+		}
+		images = append(images, fileBytes)
+	}
+	return images, nil
+}
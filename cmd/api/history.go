@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oluwaseun241/mura/cmd/client"
+	"github.com/Oluwaseun241/mura/internal/history"
+	"github.com/labstack/echo/v4"
+)
+
+// promptVersions name the prompts behind each pipeline. Bump one when its
+// prompts change so history entries from before and after can be told apart.
+var promptVersions = map[string]string{
+	history.DetectFood: "detect-food/1",
+	history.Recipe:     "recipe/1",
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxPage keeps page*per_page well inside an int; no list is that long
+	maxPage = 1 << 20
+)
+
+// pageParams reads the 1-based page and per_page query parameters of a
+// paginated list.
+func pageParams(c echo.Context) (page, perPage int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+	perPage, err = strconv.Atoi(c.QueryParam("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPageSize
+	}
+	if perPage > maxPageSize {
+		perPage = maxPageSize
+	}
+	return page, perPage
+}
+
+// historyEntry describes a run of a pipeline that started at start and
+// ended with response, or with err if it failed outright.
+func historyEntry(kind string, in history.Input, start time.Time, response map[string]interface{}, err error) *history.Entry {
+	e := &history.Entry{
+		Kind:          kind,
+		Summary:       in.Summary(kind),
+		Input:         in,
+		LatencyMS:     time.Since(start).Milliseconds(),
+		Model:         client.GeminiModel,
+		PromptVersion: promptVersions[kind],
+		CreatedAt:     start,
+	}
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	e.Type, _ = response["type"].(string)
+	e.Error, _ = response["error"].(string)
+
+	// Chat sessions expire, so a stored chat_id would only lead nowhere
+	result := make(map[string]interface{}, len(response))
+	for k, v := range response {
+		if k != "chat_id" {
+			result[k] = v
+		}
+	}
+	e.Result, _ = json.Marshal(result)
+	return e
+}
+
+// recordHistory adds e to the signed-in user's history; anonymous requests
+// aren't recorded. The request has already done its work by now, so failing
+// to record it is only reported alongside the result.
+func recordHistory(c echo.Context, e *history.Entry, images [][]byte, response map[string]interface{}) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return
+	}
+	err = historyStore.Add(userID, e, images)
+	if response == nil {
+		if err != nil {
+			c.Logger().Errorf("recording history: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		response["history_error"] = err.Error()
+		return
+	}
+	invalidateRecipeIndex(userID)
+	response["history_id"] = e.ID
+}
+
+// ListHistoryHandler pages through the user's history, newest first, by
+// cursor: pass next_cursor from one page as cursor to get the next.
+// Results are left out; get an entry to see what it returned.
+func ListHistoryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	// Paging by number stopped working when history moved to cursors; say
+	// so rather than serve page 1 forever
+	if c.QueryParam("page") != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "History is paged by cursor: pass next_cursor from the previous page as cursor instead of page"})
+	}
+	_, perPage := pageParams(c)
+	entries, next, err := historyStore.List(userID, c.QueryParam("cursor"), perPage)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	total, err := historyStore.Count(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      true,
+		"data":        entries,
+		"per_page":    perPage,
+		"total":       total,
+		"next_cursor": next,
+		"has_more":    next != "",
+	})
+}
+
+// GetHistoryHandler re-opens an entry with the result as it was returned,
+// without generating anything.
+func GetHistoryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	e, err := historyStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return historyError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   e,
+	})
+}
+
+// RegenerateHistoryHandler runs an entry's pipeline again with the same
+// inputs and records the new run, returning both to compare.
+func RegenerateHistoryHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	original, err := historyStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return historyError(c, err)
+	}
+
+	in := original.Input
+	loc, err := parseUnits(in.Units)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var images [][]byte
+	var response map[string]interface{}
+	start := time.Now()
+	switch original.Kind {
+	case history.DetectFood:
+		images, err = historyStore.Images(userID, original.ID)
+		if err != nil {
+			return historyError(c, err)
+		}
+		response, err = detectFoodResponse(ctx, images, in, loc)
+	case history.Recipe:
+		response, err = recipeResponse(ctx, parseIngredientInput(in.Ingredients, ""), in, loc)
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "This entry can't be regenerated"})
+	}
+
+	e := historyEntry(original.Kind, in, start, response, err)
+	e.RegeneratedFrom = original.ID
+	if err := historyStore.Add(userID, e, images); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	invalidateRecipeIndex(userID)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   e.Error == "",
+		"data":     e,
+		"original": original,
+	})
+}
+
+func historyError(c echo.Context, err error) error {
+	if errors.Is(err, history.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/history"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func listHistory(t *testing.T, query string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/history?"+query, nil), rec)
+	c.Set(userIDKey, "ada")
+	assert.NoError(t, ListHistoryHandler(c))
+	var out map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return rec.Code, out
+}
+
+func TestListHistoryByCursor(t *testing.T) {
+	InitStorage(storagetest.Open(t))
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, historyStore.Add("ada", &history.Entry{Kind: history.Recipe, CreatedAt: now.Add(time.Duration(i) * time.Minute)}, nil))
+	}
+
+	code, out := listHistory(t, "per_page=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, out["data"], 2)
+	assert.Equal(t, true, out["has_more"])
+	assert.Equal(t, 3.0, out["total"])
+
+	code, out = listHistory(t, "per_page=2&cursor="+out["next_cursor"].(string))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, out["data"], 1)
+	assert.Equal(t, false, out["has_more"])
+
+	code, out = listHistory(t, "page=2")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, out["error"], "cursor")
+}
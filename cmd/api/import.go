@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/importer"
+	"github.com/Oluwaseun241/mura/internal/saved"
+	"github.com/labstack/echo/v4"
+)
+
+// RecipeFetcher downloads the pages /recipes/import reads. It won't reach
+// private addresses.
+var RecipeFetcher = importer.NewFetcher(10*time.Second, false)
+
+// ImportRecipeHandler saves a recipe from a web page into the user's
+// collection, read from the page's schema.org markup. The page is fetched
+// from url, or sent as html when it can't be fetched from here; url then
+// only resolves relative links and is kept as the source.
+func ImportRecipeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		URL       string   `json:"url"`
+		HTML      string   `json:"html"`
+		Tags      []string `json:"tags"`
+		Notes     string   `json:"notes"`
+		Favourite bool     `json:"favourite"`
+	}
+	// The html is held to the same size as a fetched page. Escaping can
+	// nearly double it in JSON, so the body gets twice that
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, 2*importer.MaxPageSize)
+	if err := c.Bind(&data); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": importer.ErrTooLarge.Error()})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if len(data.HTML) > importer.MaxPageSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": importer.ErrTooLarge.Error()})
+	}
+	data.URL = strings.TrimSpace(data.URL)
+
+	var page []byte
+	var base *url.URL
+	switch {
+	case strings.TrimSpace(data.HTML) != "":
+		page = []byte(data.HTML)
+		if data.URL != "" {
+			if base, err = importer.ParseURL(data.URL); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		}
+	case data.URL != "":
+		page, base, err = RecipeFetcher.Fetch(c.Request().Context(), data.URL)
+		if err != nil {
+			return c.JSON(importError(err), map[string]string{"error": err.Error()})
+		}
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Provide a url or html"})
+	}
+
+	imported, err := importer.Extract(page, base)
+	if err != nil {
+		return c.JSON(importError(err), map[string]string{"error": err.Error()})
+	}
+
+	source := "import"
+	if data.URL != "" {
+		source = data.URL
+	}
+	r := &saved.Recipe{
+		Recipe:    imported.Recipe,
+		ImageURL:  imported.ImageURL,
+		Source:    source,
+		Tags:      append(imported.Categories, data.Tags...),
+		Notes:     strings.TrimSpace(data.Notes),
+		Favourite: data.Favourite,
+	}
+	r.Prepare(time.Now())
+	if err := savedStore.Save(userID, r); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	invalidateRecipeIndex(userID)
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"status": true,
+		"data":   r,
+	})
+}
+
+// importError picks the status for an import failure: the caller's
+// mistake, a page we can't use, or the other site failing us.
+func importError(err error) int {
+	switch {
+	case errors.Is(err, importer.ErrBadURL), errors.Is(err, importer.ErrPrivateAddress):
+		return http.StatusBadRequest
+	case errors.Is(err, importer.ErrNoRecipe), errors.Is(err, importer.ErrIncomplete),
+		errors.Is(err, importer.ErrNotHTML), errors.Is(err, importer.ErrTooLarge):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadGateway
+	}
+}
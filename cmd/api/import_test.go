@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/importer"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func importRequest(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/recipes/import", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(userIDKey, "ada")
+	assert.NoError(t, ImportRecipeHandler(c))
+	return rec
+}
+
+func TestImportRefusesHugePages(t *testing.T) {
+	InitStorage(storagetest.Open(t))
+
+	html, _ := json.Marshal(map[string]string{"html": strings.Repeat("a", importer.MaxPageSize+1)})
+	rec := importRequest(t, string(html))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// Escaped past the body limit before the html is even read
+	html, _ = json.Marshal(map[string]string{"html": strings.Repeat(`"`, importer.MaxPageSize)})
+	rec = importRequest(t, string(html))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = importRequest(t, `{"html": "<html><body>No recipe here</body></html>"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
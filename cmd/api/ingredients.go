@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/labstack/echo/v4"
+)
+
+func ParseIngredientsHandler(c echo.Context) error {
+	var data struct {
+		Text        string   `json:"text"`
+		Ingredients []string `json:"ingredients"`
+	}
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	items := parseIngredientInput(data.Ingredients, data.Text)
+	if len(items) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No ingredients provided"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   items,
+	})
+}
+
+// parseIngredientInput structures ingredients given either as a list of
+// strings (each of which may itself hold several comma separated items) or
+// as one block of free text.
+func parseIngredientInput(list []string, text string) []ingredient.Item {
+	all := append(list[:len(list):len(list)], text)
+	return ingredient.ParseList(strings.Join(all, "\n"))
+}
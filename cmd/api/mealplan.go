@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/shopping"
+	"github.com/Oluwaseun241/mura/internal/suggest"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+// CreateMealPlanHandler plans a week of meals for the household around what
+// is in the pantry. Each meal comes with its ingredients and nutrition; the
+// full method is written the first time the meal is opened.
+func CreateMealPlanHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var req mealplan.Request
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	now := time.Now()
+	start, err := req.Validate(now)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	have, err := pantryStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	plan, err := getMealPlan(ctx, req, start, have, now)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	plan.CreatedAt, plan.UpdatedAt = now, now
+	if err := mealPlanStore.Save(userID, plan); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   plan,
+	})
+}
+
+func ListMealPlansHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	plans, err := mealPlanStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   plans,
+	})
+}
+
+func GetMealPlanHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	plan, err := mealPlanStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   plan,
+	})
+}
+
+// GetPlanMealHandler returns one meal of a plan with its full recipe,
+// writing the method the first time and keeping it with the plan.
+func GetPlanMealHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	plan, err := mealPlanStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+	meal, err := plan.Meal(c.Param("day"), c.Param("slot"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	if !meal.Expanded {
+		full, err := expandPlannedMeal(ctx, meal.Recipe)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		// The plan may have changed while the method was written; only this
+		// meal is touched, and only if it's still the one expanded
+		written := *meal
+		written.Recipe, written.Expanded = full, true
+		err = mealPlanStore.Update(userID, plan.ID, func(p *mealplan.Plan) error {
+			current, err := p.Meal(c.Param("day"), c.Param("slot"))
+			if err != nil {
+				return err
+			}
+			if !current.Expanded && current.Recipe.Title == meal.Recipe.Title {
+				*current = written
+				p.UpdatedAt = time.Now()
+			}
+			meal = current
+			return nil
+		})
+		if err != nil {
+			return mealPlanError(c, err)
+		}
+		if !meal.Expanded {
+			// Swapped for something else meanwhile; show what was written
+			meal = &written
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   meal.Recipe.Markdown(),
+		"meal":   meal,
+	})
+}
+
+// SwapMealHandler replaces one meal of a plan with something else that fits
+// the rest of the week.
+func SwapMealHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		Day    string `json:"day"`
+		Slot   string `json:"slot"`
+		Reason string `json:"reason"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	plan, err := mealPlanStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+	meal, err := plan.Meal(data.Day, strings.ToLower(data.Slot))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	have, err := pantryStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	replacement, err := getReplacementMeal(ctx, plan, meal, data.Reason, have, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Other meals may have changed while this one was picked, so the swap
+	// is made on the plan as it is now
+	replaced := *meal
+	replacement.Slot = meal.Slot
+	err = mealPlanStore.Update(userID, plan.ID, func(p *mealplan.Plan) error {
+		current, err := p.Meal(data.Day, strings.ToLower(data.Slot))
+		if err != nil {
+			return err
+		}
+		replaced = *current
+		*current = replacement
+		p.Refresh()
+		p.UpdatedAt = time.Now()
+		plan, meal = p, current
+		return nil
+	})
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   true,
+		"data":     plan,
+		"meal":     meal,
+		"replaced": replaced.Recipe.Title,
+	})
+}
+
+// PlanShoppingListHandler builds the shopping list for a whole plan minus
+// the pantry, in the same formats as /shopping-list.
+func PlanShoppingListHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	plan, err := mealPlanStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return mealPlanError(c, err)
+	}
+	have, err := pantryStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return renderShoppingList(c, shopping.Build(plan.Needs(), have, time.Now()), c.QueryParam("format"))
+}
+
+func mealPlanError(c echo.Context, err error) error {
+	if errors.Is(err, mealplan.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// planConstraints describes the household and its limits for a prompt.
+func planConstraints(req mealplan.Request) string {
+	prompt := fmt.Sprintf(" The household is %d people, so write every ingredient quantity for %d servings.", req.HouseholdSize, req.HouseholdSize)
+	if len(req.Diet) > 0 {
+		prompt += fmt.Sprintf(" Every meal must be %s.", strings.Join(req.Diet, ", "))
+	}
+	if req.CaloriesPerDay > 0 {
+		prompt += fmt.Sprintf(" Breakfast, lunch and dinner together should come to about %.0f kcal per person per day.", req.CaloriesPerDay)
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	if req.Budget > 0 {
+		prompt += fmt.Sprintf(" The whole week's shopping must cost no more than %.2f %s.", req.Budget, currency)
+	}
+	prompt += fmt.Sprintf(" Estimate each meal's ingredient cost in %s.", currency)
+	if req.Cuisine != "" {
+		prompt += fmt.Sprintf(" Favour %s cuisine.", req.Cuisine)
+	}
+	return prompt
+}
+
+// pantryPrompt lists what the household already has, soonest to expire
+// first, so meals are built around it.
+func pantryPrompt(have []pantry.Item, now time.Time) string {
+	expiring := map[string]bool{}
+	var soon, rest []string
+	for _, item := range suggest.Expiring(have, now) {
+		expiring[item.ID] = true
+		soon = append(soon, item.Ingredient().Raw)
+	}
+	for _, item := range have {
+		if !expiring[item.ID] && !item.Expired(now) {
+			rest = append(rest, item.Ingredient().Raw)
+		}
+	}
+
+	var prompt string
+	if len(soon) > 0 {
+		prompt += fmt.Sprintf(" Use these soon, early in the week, as they are about to expire: %s.", strings.Join(soon, ", "))
+	}
+	if len(rest) > 0 {
+		prompt += fmt.Sprintf(" They already have: %s.", strings.Join(rest, ", "))
+	}
+	return prompt
+}
+
+func getMealPlan(ctx context.Context, req mealplan.Request, start time.Time, have []pantry.Item, now time.Time) (*mealplan.Plan, error) {
+	prompt := fmt.Sprintf("Plan %d days of breakfast, lunch and dinner for a home cook.", mealplan.Days)
+	prompt += planConstraints(req)
+	prompt += pantryPrompt(have, now)
+	prompt += " Reuse ingredients across days so that nothing bought is left half used: buy a bunch of greens or a pack of chicken once and use it in two or three meals, or cook a pot of stew or rice once and serve it twice. Vary the dishes so no dinner repeats."
+	prompt += " Respond only with JSON in this format: " + mealplan.Schema
+
+	data, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
+	return mealplan.Parse(data, start, req)
+}
+
+func getReplacementMeal(ctx context.Context, plan *mealplan.Plan, meal *mealplan.Meal, reason string, have []pantry.Item, now time.Time) (mealplan.Meal, error) {
+	prompt := fmt.Sprintf("Here is a week's meal plan: %s. Suggest a different %s to replace %q.", plan.Summary(), meal.Slot, meal.Recipe.Title)
+	if reason = strings.TrimSpace(reason); reason != "" {
+		prompt += fmt.Sprintf(" The cook wants it changed because: %s.", reason)
+	}
+	prompt += planConstraints(plan.Request)
+	prompt += pantryPrompt(have, now)
+
+	var shared []string
+	for _, s := range plan.Shared {
+		shared = append(shared, s.Ingredient)
+	}
+	if len(shared) > 0 {
+		prompt += fmt.Sprintf(" Prefer ingredients the rest of the plan already buys: %s.", strings.Join(shared, ", "))
+	}
+	prompt += " Respond only with JSON in this format: " + mealplan.MealSchema
+
+	data, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return mealplan.Meal{}, err
+	}
+	return mealplan.ParseMeal(data)
+}
+
+// expandPlannedMeal writes the full method for a planned meal, keeping its
+// ingredients and servings as planned. It's a variable so tests can stand
+// in for Gemini.
+var expandPlannedMeal = geminiExpandPlannedMeal
+
+func geminiExpandPlannedMeal(ctx context.Context, r *recipe.Recipe) (*recipe.Recipe, error) {
+	current, err := r.SchemaJSON()
+	if err != nil {
+		return nil, err
+	}
+	prompt := fmt.Sprintf(recipeGuidelines+". Here is a planned meal as JSON: %s. Write the complete recipe for it: equipment, detailed steps and tips. Keep the title, servings and ingredients exactly as they are.", current)
+	prompt += " Respond only with JSON in this format: " + recipe.Schema
+
+	data, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
+	return recipe.Parse(data)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandingKeepsChangesMadeMeanwhile(t *testing.T) {
+	InitStorage(storagetest.Open(t))
+	plan := &mealplan.Plan{Days: []mealplan.Day{{Date: "2026-10-19", Meals: []mealplan.Meal{
+		{Slot: "lunch", Recipe: &recipe.Recipe{Title: "Jollof rice"}},
+		{Slot: "dinner", Recipe: &recipe.Recipe{Title: "Egusi soup"}},
+	}}}}
+	assert.NoError(t, mealPlanStore.Save("ada", plan))
+
+	expand := expandPlannedMeal
+	defer func() { expandPlannedMeal = expand }()
+	expandPlannedMeal = func(ctx context.Context, r *recipe.Recipe) (*recipe.Recipe, error) {
+		// Dinner is swapped while lunch's method is being written
+		assert.NoError(t, mealPlanStore.Update("ada", plan.ID, func(p *mealplan.Plan) error {
+			p.Days[0].Meals[1].Recipe = &recipe.Recipe{Title: "Okra soup"}
+			return nil
+		}))
+		return &recipe.Recipe{Title: r.Title, Steps: []string{"Cook the rice"}}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(userIDKey, "ada")
+	c.SetParamNames("id", "day", "slot")
+	c.SetParamValues(plan.ID, "1", "lunch")
+	assert.NoError(t, GetPlanMealHandler(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	saved, err := mealPlanStore.Get("ada", plan.ID)
+	assert.NoError(t, err)
+	assert.True(t, saved.Days[0].Meals[0].Expanded)
+	assert.Equal(t, []string{"Cook the rice"}, saved.Days[0].Meals[0].Recipe.Steps)
+	assert.Equal(t, "Okra soup", saved.Days[0].Meals[1].Recipe.Title)
+}
@@ -9,15 +9,19 @@ import (
 	"time"
 
 	"github.com/Oluwaseun241/mura/cmd/client"
+	"github.com/Oluwaseun241/mura/internal/recipe"
 	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/Oluwaseun241/mura/internal/units"
+	"github.com/Oluwaseun241/mura/internal/usage"
 	"github.com/google/generative-ai-go/genai"
 )
 
-func getFoodRecipes(ingredients []string, dish string) (string, error) {
-	ctx := context.Background()
+// recipeGuidelines opens every prompt that asks the model to write a recipe.
+const recipeGuidelines = "You are a helpful, AI assistant devoted to providing accurate and delightful recipes.These are the guildlines for you to follow when delivering a recipe response to a request 1. List out the ingredients first,including quantities.Provide detailed cooking times, temperatures and any special kitchen equipment needed 2.Provide step-by-step instructions for prepping, mixing, cooking, plating and any other necessary steps, detailed enough to follow. Include Pro chef tips and special techniques as applicable"
 
-	prompt1 := fmt.Sprintf("You are a helpful, AI assistant devoted to providing accurate and delightful recipes.These are the guildlines for you to follow when delivering a recipe response to a request 1. List out the ingredients first,including quantities.Provide detailed cooking times, temperatures and any special kitchen equipment needed 2.Provide step-by-step instructions for prepping, mixing, cooking, plating and any other necessary steps, detailed enough to follow. Include Pro chef tips and special techniques as applicable Here are the ingredients I have: %s. Can you give me a specific recipe that includes only these ingredients, and detailed preparation steps? Lastly Nutritional information like Calories, Protein and Carbs", strings.Join(ingredients, ", "))
-	prompt2 := fmt.Sprintf("You are a helpful, AI assistant devoted to providing accurate and delightful recipes.These are the guildlines for you to follow when delivering a recipe response to a request 1. List out the ingredients first,including quantities.Provide detailed cooking times, temperatures and any special kitchen equipment needed 2.Provide step-by-step instructions for prepping, mixing, cooking, plating and any other necessary steps, detailed enough to follow. Include Pro chef tips and special techniques as applicable Here are the ingredients I have: %s. Can you give me a specific recipe that includes only these ingredients, Nutritional information like Calories, Protein and Carbs, and detailed preparation steps for %s", strings.Join(ingredients, ", "), dish)
+func getFoodRecipes(ctx context.Context, ingredients []string, dish string, loc *units.Locale, avoid []string) (*recipe.Recipe, error) {
+	prompt1 := fmt.Sprintf(recipeGuidelines+" Here are the ingredients I have: %s. Can you give me a specific recipe that includes only these ingredients, and detailed preparation steps? Lastly Nutritional information like Calories, Protein and Carbs", strings.Join(ingredients, ", "))
+	prompt2 := fmt.Sprintf(recipeGuidelines+" Here are the ingredients I have: %s. Can you give me a specific recipe that includes only these ingredients, Nutritional information like Calories, Protein and Carbs, and detailed preparation steps for %s", strings.Join(ingredients, ", "), dish)
 
 	// S.elect the appropriate prompt
 	var prompt string
@@ -26,46 +30,97 @@ func getFoodRecipes(ingredients []string, dish string) (string, error) {
 	} else {
 		prompt = prompt1
 	}
+	prompt += "."
+	if len(avoid) > 0 {
+		prompt += fmt.Sprintf(" I do not have %s, so do not use them. Salt, pepper, oil and water are fine.", strings.Join(avoid, ", "))
+	}
+	prompt += unitsPrompt(loc)
+	prompt += " Respond only with JSON in this format: " + recipe.Schema
+
+	data, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
 
-	model := client.GeminiClient.GenerativeModel("gemini-2.0-flash")
-	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	r, err := recipe.Parse(data)
 	if err != nil {
-		return "", fmt.Errorf("Error generating content: %v", err)
+		return nil, err
 	}
-	if resp == nil {
-		return "", fmt.Errorf("no response received from Gemini API")
+	if loc != nil {
+		r.Localize(*loc)
 	}
-	return printResponse(resp), nil
+	return r, nil
 }
 
-func detectFood(fileBytes []byte) (string, error) {
-	ctx := context.Background()
+// imageParts turns photos of the same food into prompt parts. Several photos
+// are described as angles of one dish so the model identifies it once from
+// all of them.
+func imageParts(images [][]byte) []genai.Part {
+	parts := make([]genai.Part, 0, len(images)+1)
+	for _, img := range images {
+		parts = append(parts, genai.ImageData("jpeg", img))
+	}
+	if len(images) > 1 {
+		parts = append(parts, genai.Text(fmt.Sprintf("These %d photos show the same food from different angles, such as from the top, the side or cut open. Use all of them together to tell apart dishes that look alike from one angle.", len(images))))
+	}
+	return parts
+}
 
-	prompt := []genai.Part{
-		genai.ImageData("jpeg", fileBytes),
-		genai.Text("Accurately identify the food in the image and provide an appropriate recipe consistent with your analysis.These are the guildlines for you to follow when delivering a recipe response to a request 1. List out the ingredients first, including quantities. Provide detailed cooking times, temperatures and any special kitchen equipment needed 2.Provide step-by-step instructions for prepping, mixing, cooking, plating and any other necessary steps, detailed enough to follow. Include pro chef tips and special techniques as applicable. Lastly Nutritional information like Calories, Protein and Carbs"),
+// detectFood writes a recipe for the food in the photos. When dish is set the
+// photos may hold several dishes and only that one is described.
+func detectFood(ctx context.Context, images [][]byte, dish string, loc *units.Locale) (string, error) {
+	focus := "Accurately identify the food in the image and provide an appropriate recipe consistent with your analysis."
+	if dish != "" {
+		focus = fmt.Sprintf("The image may show several dishes. Provide an appropriate recipe for the %s only, consistent with how it looks in the image.", dish)
 	}
 
-	model := client.GeminiClient.GenerativeModel("gemini-2.0-flash")
+	prompt := append(imageParts(images),
+		genai.Text(focus+" These are the guildlines for you to follow when delivering a recipe response to a request 1. List out the ingredients first, including quantities. Provide detailed cooking times, temperatures and any special kitchen equipment needed 2.Provide step-by-step instructions for prepping, mixing, cooking, plating and any other necessary steps, detailed enough to follow. Include pro chef tips and special techniques as applicable. Lastly Nutritional information like Calories, Protein and Carbs."+unitsPrompt(loc)),
+	)
+
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return "", err
+	}
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
 	//model.ResponseMIMEType = "application/json"
 
 	resp, err := model.GenerateContent(ctx, prompt...)
 	if err != nil {
 		return "", fmt.Errorf("Error generating content")
 	}
-	return printResponse(resp), nil
+	return localizeRecipe(printResponse(resp), loc), nil
 }
 
-func detectIngredients(file []byte) (map[string]interface{}, error) {
-	model := client.GeminiClient.GenerativeModel("gemini-2.0-flash")
+// detectDishes lists every distinct dish in the photos with where it is in
+// the first photo and how sure the model is about it.
+func detectDishes(ctx context.Context, images [][]byte) ([]service.Dish, error) {
+	prompt := append(imageParts(images),
+		genai.Text(`Identify every distinct cooked dish in this image, such as each item on a plate or each serving dish on a table. Treat sides and sauces served separately as their own dishes. Respond only with JSON in this format: {"dishes": [{"name": "dish name", "confidence": 0.0 to 1.0, "box_2d": [ymin, xmin, ymax, xmax]}]}, with box_2d normalized to 0-1000 and measured on the first photo.`),
+	)
+	data, err := generateJSON(ctx, prompt...)
+	if err != nil {
+		return nil, err
+	}
+	return service.ParseDishes(data)
+}
+
+// detectIngredients lists the food in a photo. It's a variable so tests can
+// stand in for Gemini.
+var detectIngredients = geminiDetectIngredients
+
+func geminiDetectIngredients(ctx context.Context, file []byte) (map[string]interface{}, error) {
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return nil, err
+	}
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
 	model.ResponseMIMEType = "application/json"
 	prompt := []genai.Part{
 		genai.ImageData("jpeg", file),
 		genai.Text("Identify and list all food items in this image with accurate labels in JSON format. Please return the result as a valid JSON object formatted as {'foods': ['item1', 'item2', ...]} without any additional text, comments, or formatting issues."),
 	}
-	resp, err := model.GenerateContent(context.Background(), prompt...)
+	resp, err := model.GenerateContent(ctx, prompt...)
 	if err != nil {
-		return nil, fmt.Errorf("error generating content: %v", err)
+		return nil, fmt.Errorf("error generating content: %w", err)
 	}
 
 	// Extract the content from the response
@@ -85,7 +140,7 @@ func detectIngredients(file []byte) (map[string]interface{}, error) {
 	var parsedResponse map[string]interface{}
 	err = json.Unmarshal([]byte(combinedContent), &parsedResponse)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing response: %v", err)
+		return nil, fmt.Errorf("error parsing response: %w", err)
 	}
 
 	if foods, ok := parsedResponse["foods"].([]interface{}); ok {
@@ -96,20 +151,56 @@ func detectIngredients(file []byte) (map[string]interface{}, error) {
 	return parsedResponse, nil
 }
 
-func getVideoPrompt(file []byte) (*service.VideoPromptResponse, error) {
-	ctx := context.Background()
+// errNoGemini is returned when the server was started without a Gemini key.
+var errNoGemini = errors.New("Gemini is not configured")
 
+// generateJSON sends a prompt in JSON mode and returns the model's answer.
+func generateJSON(ctx context.Context, prompt ...genai.Part) ([]byte, error) {
+	if client.GeminiClient == nil {
+		return nil, errNoGemini
+	}
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return nil, err
+	}
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
+	model.ResponseMIMEType = "application/json"
+
+	resp, err := model.GenerateContent(ctx, prompt...)
+	if err != nil {
+		return nil, fmt.Errorf("error generating content: %w", err)
+	}
+
+	// Extract the content from the response
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content generated")
+	}
+
+	var combinedContent string
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if textPart, ok := part.(genai.Text); ok {
+			combinedContent += string(textPart)
+		} else {
+			return nil, fmt.Errorf("unexpected part type: %T", part)
+		}
+	}
+	return []byte(combinedContent), nil
+}
+
+func getVideoPrompt(ctx context.Context, file []byte) (*service.VideoPromptResponse, error) {
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return nil, err
+	}
 	prompt := []genai.Part{
 		genai.ImageData("jpeg", file),
 		genai.Text("Analyze this food image and return a JSON response with two fields: 'food_name' (the name of the dish) and 'youtube_search_prompt' (a search query for finding cooking tutorials). Format: {\"food_name\": \"dish name\", \"youtube_search_prompt\": \"how to cook dish name recipe tutorial\"}. Make the search prompt specific and include terms like 'recipe', 'tutorial', or 'how to cook'."),
 	}
 
-	model := client.GeminiClient.GenerativeModel("gemini-2.0-flash")
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
 	model.ResponseMIMEType = "application/json"
 
 	resp, err := model.GenerateContent(ctx, prompt...)
 	if err != nil {
-		return nil, fmt.Errorf("Error generating content: %v", err)
+		return nil, fmt.Errorf("Error generating content: %w", err)
 	}
 
 	// Extract the content from the response
@@ -128,7 +219,7 @@ func getVideoPrompt(file []byte) (*service.VideoPromptResponse, error) {
 
 	var result service.VideoPromptResponse
 	if err := json.Unmarshal([]byte(combinedContent), &result); err != nil {
-		return nil, fmt.Errorf("error decoding response JSON: %v", err)
+		return nil, fmt.Errorf("error decoding response JSON: %w", err)
 	}
 
 	// Validate the response
@@ -139,29 +230,29 @@ func getVideoPrompt(file []byte) (*service.VideoPromptResponse, error) {
 	return &result, nil
 }
 
-func ytVideoRecommendation(file []byte) ([]service.YouTubeVideo, error) {
+func ytVideoRecommendation(ctx context.Context, file []byte) ([]service.YouTubeVideo, error) {
 	var err error
 	var p *service.VideoPromptResponse
 	maxAttempts := 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		p, err = getVideoPrompt(file)
+		p, err = getVideoPrompt(ctx, file)
 		if err == nil {
 			break
 		}
 		// Retry only if the error is related to timeout
 		if !errors.Is(err, context.DeadlineExceeded) {
-			return nil, fmt.Errorf("failed to retrieve video prompt: %v", err)
+			return nil, fmt.Errorf("failed to retrieve video prompt: %w", err)
 		}
 		time.Sleep(2 * time.Second)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("YouTube API request failed after %d attempts: %v", maxAttempts, err)
+		return nil, fmt.Errorf("YouTube API request failed after %d attempts: %w", maxAttempts, err)
 	}
 
-	video, err := service.YoutubeSearch(p.YouTubeSearchPrompt)
+	video, err := service.YoutubeSearch(ctx, p.YouTubeSearchPrompt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search for YouTube videos: %v", err)
+		return nil, fmt.Errorf("failed to search for YouTube videos: %w", err)
 	}
 
 	return video, nil
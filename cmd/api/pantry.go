@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/labstack/echo/v4"
+)
+
+// pantryItemInput is a pantry item as clients send it. Dates may be given as
+// YYYY-MM-DD or RFC 3339.
+type pantryItemInput struct {
+	Name        *string  `json:"name"`
+	Quantity    *float64 `json:"quantity"`
+	Unit        *string  `json:"unit"`
+	PurchasedAt *string  `json:"purchased_at"`
+	ExpiresAt   *string  `json:"expires_at"`
+}
+
+// apply copies the fields that were set onto item.
+func (in pantryItemInput) apply(item *pantry.Item) error {
+	if in.Name != nil {
+		item.Name = strings.TrimSpace(*in.Name)
+		item.Canonical = ""
+	}
+	if in.Quantity != nil {
+		if *in.Quantity < 0 {
+			return errors.New("quantity cannot be negative")
+		}
+		item.Quantity = *in.Quantity
+	}
+	if in.Unit != nil {
+		item.Unit = strings.TrimSpace(*in.Unit)
+	}
+	if in.PurchasedAt != nil {
+		t, err := parseDate(*in.PurchasedAt)
+		if err != nil {
+			return err
+		}
+		item.PurchasedAt = t
+	}
+	if in.ExpiresAt != nil {
+		t, err := parseDate(*in.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		item.ExpiresAt, item.ExpiryEstimated = t, false
+	}
+	return nil
+}
+
+func GetPantryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	items, err := pantryStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   items,
+	})
+}
+
+// AddPantryItemsHandler adds items given as structured objects, as free
+// text ("2 onions, 1kg rice"), or both. Items already in the pantry have
+// their quantities added to.
+func AddPantryItemsHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		Items []pantryItemInput `json:"items"`
+		Text  string            `json:"text"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	var items []pantry.Item
+	for _, in := range data.Items {
+		item := pantry.Item{Source: "manual"}
+		if err := in.apply(&item); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if item.Name == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Every item needs a name"})
+		}
+		items = append(items, item)
+	}
+	for _, it := range ingredient.ParseList(data.Text) {
+		items = append(items, pantry.FromIngredient(it, "manual"))
+	}
+	if len(items) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No items provided"})
+	}
+
+	added, err := addToPantry(userID, items)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   added,
+	})
+}
+
+// AddDetectedToPantryHandler takes the ingredient names a /detect response
+// returned and adds them to the pantry in one go.
+func AddDetectedToPantryHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		Ingredients []string `json:"ingredients"`
+	}
+	if err := c.Bind(&data); err != nil || len(data.Ingredients) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No ingredients provided"})
+	}
+
+	added, err := addDetectedToPantry(userID, data.Ingredients)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   added,
+	})
+}
+
+func UpdatePantryItemHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	item, err := pantryStore.Get(userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, pantry.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	var in pantryItemInput
+	if err := c.Bind(&in); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if err := in.apply(&item); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if item.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name cannot be empty"})
+	}
+	item.Prepare(time.Now())
+
+	if item, err = pantryStore.Put(userID, item); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   item,
+	})
+}
+
+func DeletePantryItemHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err := pantryStore.Delete(userID, c.Param("id")); err != nil {
+		if errors.Is(err, pantry.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+}
+
+// addToPantry saves new items, merging each into an existing item of the
+// same ingredient where there is one.
+func addToPantry(userID string, items []pantry.Item) ([]pantry.Item, error) {
+	existing, err := pantryStore.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	added := make([]pantry.Item, 0, len(items))
+	for _, item := range items {
+		item.Prepare(now)
+		merged := pantry.Merge(existing, item)
+		merged.UpdatedAt = now
+		saved, err := pantryStore.Put(userID, merged)
+		if err != nil {
+			return nil, err
+		}
+		if merged.ID == "" {
+			existing = append(existing, saved)
+		} else {
+			for i := range existing {
+				if existing[i].ID == saved.ID {
+					existing[i] = saved
+				}
+			}
+		}
+		added = append(added, saved)
+	}
+	return added, nil
+}
+
+func addDetectedToPantry(userID string, names []string) ([]pantry.Item, error) {
+	items := make([]pantry.Item, 0, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		items = append(items, pantry.FromIngredient(ingredient.Parse(name), "detected"))
+	}
+	return addToPantry(userID, items)
+}
+
+// pantryIngredients lists what the user has that hasn't expired, as
+// ingredients for a recipe prompt.
+func pantryIngredients(userID string) ([]ingredient.Item, error) {
+	items, err := pantryStore.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var have []ingredient.Item
+	for _, item := range items {
+		if !item.Expired(now) {
+			have = append(have, item.Ingredient())
+		}
+	}
+	return have, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+}
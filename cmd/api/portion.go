@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/nutrition"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+// PlateEstimate is how much food is in a photo, per component and in total.
+type PlateEstimate struct {
+	References []string            `json:"reference_objects"`
+	Components []nutrition.Portion `json:"components"`
+	Total      nutrition.Portion   `json:"total"`
+}
+
+func EstimatePortionHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["image"]) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No images uploaded"})
+	}
+
+	files := form.File["image"]
+	if len(files) > maxFoodImages {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("At most %d images of a dish can be uploaded", maxFoodImages)})
+	}
+	images, err := readImages(files)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	estimate, err := estimatePortions(ctx, images, c.FormValue("reference"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"status": false,
+			"error":  err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   estimate,
+	})
+}
+
+// estimatePortions asks the model how much of each food is in the photos,
+// judging scale from objects of known size, and prices the weights with our
+// nutrition table. reference is the user's own hint, e.g. "26 cm plate".
+func estimatePortions(ctx context.Context, images [][]byte, reference string) (*PlateEstimate, error) {
+	text := `Estimate how much of each food is in this photo. Judge scale from objects of known size, such as a dinner plate (about 26 cm across), a side plate (about 20 cm), a fork (about 19 cm), a tablespoon, a can or an adult hand (palm about 8 cm wide), and list the ones you used. For every food component give its cooked weight in grams with a low and high bound, and its calories and macros per 100 g. Respond only with JSON in this format: {"reference_objects": ["dinner plate"], "components": [{"name": "jollof rice", "grams": 250, "grams_low": 200, "grams_high": 300, "per_100g": {"calories": 150, "protein_g": 3, "carbs_g": 25, "fat_g": 4}}]}`
+	if reference = strings.TrimSpace(reference); reference != "" {
+		text += fmt.Sprintf(". The user says the photo shows: %s. Use it to judge scale.", reference)
+	}
+
+	data, err := generateJSON(ctx, append(imageParts(images), genai.Text(text))...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		References []string `json:"reference_objects"`
+		Components []struct {
+			Name      string           `json:"name"`
+			Grams     float64          `json:"grams"`
+			GramsLow  float64          `json:"grams_low"`
+			GramsHigh float64          `json:"grams_high"`
+			Per100g   recipe.Nutrition `json:"per_100g"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	referenced := len(result.References) > 0 || reference != ""
+	estimate := &PlateEstimate{
+		References: result.References,
+		Components: []nutrition.Portion{},
+	}
+	if estimate.References == nil {
+		estimate.References = []string{}
+	}
+	for _, comp := range result.Components {
+		if comp.Name == "" {
+			continue
+		}
+		grams := nutrition.Weight(comp.GramsLow, comp.Grams, comp.GramsHigh, referenced)
+		estimate.Components = append(estimate.Components, nutrition.Estimate(comp.Name, grams, comp.Per100g))
+	}
+	estimate.Total = nutrition.Total(estimate.Components)
+	return estimate, nil
+}
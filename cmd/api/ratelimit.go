@@ -0,0 +1,121 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ratelimit"
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimits are the per-route limits, checked in order. Anything that sends
+// a photo to Gemini is limited hardest, then other generation, then sign in
+// attempts and imports, which fetch other sites; the rest falls to
+// DefaultRateLimit.
+var RateLimits = []ratelimit.Rule{
+	{Name: "health", Paths: []string{"/health"}},
+	{
+		Name:  "image",
+		Paths: []string{"/detect-food", "/detect", "/estimate-portion", "/history/:id/regenerate"},
+		Limit: ratelimit.Every(10, time.Minute, 5),
+	},
+	{
+		Name: "ai",
+		Paths: []string{
+			"/recipe", "/recipe/variation", "/substitute", "POST /chat", "/chat/:id/messages",
+			"POST /meal-plans", "/meal-plans/:id/swap", "/meal-plans/:id/days/:day/:slot", "/pantry/suggestions",
+			"/recipes/:id/similar",
+		},
+		Limit: ratelimit.Every(30, time.Minute, 10),
+	},
+	{
+		Name:  "auth",
+		Paths: []string{"/auth/login", "/auth/register", "/auth/refresh", "/auth/logout"},
+		Limit: ratelimit.Every(10, time.Minute, 5),
+	},
+	{
+		Name:  "import",
+		Paths: []string{"/recipes/import"},
+		Limit: ratelimit.Every(20, time.Minute, 5),
+	},
+}
+
+var DefaultRateLimit = ratelimit.Every(120, time.Minute, 60)
+
+// IPRateLimit bounds everything one IP sends, whoever it signs in as. It's
+// checked before credentials are, so guessing API keys or tokens is limited
+// too.
+var IPRateLimit = ratelimit.Every(600, time.Minute, 200)
+
+// RateLimitStore holds the buckets. Replace it with a shared store when
+// running more than one replica.
+var RateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+// RateLimit limits each caller per route group: by API key or signed-in user
+// when Authenticate found one, otherwise by IP. It must run after
+// Authenticate. If the store fails the request is let through, since the
+// API being down is worse than a burst getting past.
+func RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	limiter := &ratelimit.Limiter{Store: RateLimitStore, Rules: RateLimits, Default: DefaultRateLimit}
+	return func(c echo.Context) error {
+		r, limited, err := limiter.Allow(c.Request().Method, c.Path(), caller(c), time.Now())
+		if err != nil {
+			c.Logger().Errorf("Rate limiter: %v", err)
+			return next(c)
+		}
+		if !limited {
+			return next(c)
+		}
+		return limit(c, next, r)
+	}
+}
+
+// LimitIP limits each IP to IPRateLimit across all routes. It must run
+// before Authenticate, which refuses bad credentials before RateLimit sees
+// them. Its headers are replaced by RateLimit's on routes that are limited
+// per caller.
+func LimitIP(next echo.HandlerFunc) echo.HandlerFunc {
+	store := RateLimitStore
+	return func(c echo.Context) error {
+		if IPRateLimit.Unlimited() {
+			return next(c)
+		}
+		r, err := store.Take("ip|"+c.RealIP(), IPRateLimit, time.Now())
+		if err != nil {
+			c.Logger().Errorf("Rate limiter: %v", err)
+			return next(c)
+		}
+		return limit(c, next, r)
+	}
+}
+
+// limit sets the rate limit headers for r and refuses the request if it
+// wasn't allowed.
+func limit(c echo.Context, next echo.HandlerFunc, r ratelimit.Result) error {
+	h := c.Response().Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(r.Reset)))
+	if !r.Allowed {
+		h.Set(echo.HeaderRetryAfter, strconv.Itoa(ceilSeconds(r.RetryAfter)))
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many requests, please slow down"})
+	}
+	return next(c)
+}
+
+// caller identifies who a request counts against.
+func caller(c echo.Context) string {
+	if id, ok := c.Get(apiKeyIDKey).(string); ok {
+		return "key:" + id
+	}
+	if id, ok := c.Get(userIDKey).(string); ok {
+		return "user:" + id
+	}
+	return "ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/ratelimit"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPerRoute(t *testing.T) {
+	store := RateLimitStore
+	defer func() { RateLimitStore = store }()
+	RateLimitStore = ratelimit.NewMemoryStore()
+
+	e := newTestServer(t)
+	e.Use(RateLimit)
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/detect-food", ok)
+	e.GET("/recipes/search", ok)
+
+	// Photos are limited hardest, to a burst of five
+	burst := RateLimits[1].Limit.Burst
+	for i := 0; i < burst; i++ {
+		rec := serve(e, http.MethodPost, "/detect-food", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, strconv.Itoa(burst-i-1), rec.Header().Get("X-RateLimit-Remaining"))
+	}
+	rec := serve(e, http.MethodPost, "/detect-food", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	wait, err := strconv.Atoi(rec.Header().Get(echo.HeaderRetryAfter))
+	assert.NoError(t, err)
+	assert.Greater(t, wait, 0)
+
+	// Other routes have their own allowance
+	rec = serve(e, http.MethodGet, "/recipes/search", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// and so does a signed-in user on the same IP
+	tokens, err := tokenIssuer.Issue("ada")
+	assert.NoError(t, err)
+	rec = serve(e, http.MethodPost, "/detect-food", map[string]string{echo.HeaderAuthorization: "Bearer " + tokens.AccessToken})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestLimitIPBeforeAuthenticate(t *testing.T) {
+	store, limit := RateLimitStore, IPRateLimit
+	defer func() { RateLimitStore, IPRateLimit = store, limit }()
+	RateLimitStore = ratelimit.NewMemoryStore()
+	IPRateLimit = ratelimit.Every(10, time.Minute, 3)
+
+	newTestServer(t)
+	e := echo.New()
+	e.Use(LimitIP, Authenticate)
+	e.GET("/history", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	// Bad keys and tokens are refused, then limited before they're checked
+	bad := []map[string]string{
+		{"X-API-Key": apikey.Prefix + strings.Repeat("x", 43)},
+		{echo.HeaderAuthorization: "Bearer not-a-token"},
+		{"X-API-Key": apikey.Prefix + strings.Repeat("y", 43)},
+	}
+	for _, header := range bad {
+		assert.Equal(t, http.StatusUnauthorized, serve(e, http.MethodGet, "/history", header).Code)
+	}
+	key := newAPIKey(t, apikey.Quota{})
+	rec := serve(e, http.MethodGet, "/history", map[string]string{"X-API-Key": key})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/chat"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/saved"
+	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// SaveRecipeHandler keeps a recipe in the user's collection. The recipe can
+// be sent as returned by /recipe (recipe) or /detect-food (markdown), or
+// picked up from the chat session either of them started (chat_id).
+func SaveRecipeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	var data struct {
+		ChatID    string                 `json:"chat_id"`
+		Recipe    *recipe.Recipe         `json:"recipe"`
+		Markdown  string                 `json:"markdown"`
+		Title     string                 `json:"title"`
+		Dish      string                 `json:"dish"`
+		ImageURL  string                 `json:"image_url"`
+		Videos    []service.YouTubeVideo `json:"videos"`
+		Source    string                 `json:"source"`
+		Tags      []string               `json:"tags"`
+		Notes     string                 `json:"notes"`
+		Favourite bool                   `json:"favourite"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	if data.Recipe == nil && data.Markdown == "" && data.ChatID != "" {
+		session, err := chatSessions.Get(data.ChatID, chatOwner(c))
+		if errors.Is(err, chat.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		data.Recipe, data.Markdown = session.Recipe, session.RecipeText
+	}
+	if data.Recipe == nil && strings.TrimSpace(data.Markdown) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No recipe provided"})
+	}
+
+	r := &saved.Recipe{
+		Title:     data.Title,
+		Recipe:    data.Recipe,
+		Markdown:  data.Markdown,
+		Dish:      data.Dish,
+		ImageURL:  data.ImageURL,
+		Videos:    data.Videos,
+		Source:    data.Source,
+		Tags:      data.Tags,
+		Notes:     strings.TrimSpace(data.Notes),
+		Favourite: data.Favourite,
+	}
+	if r.Recipe != nil {
+		// Keep one form of the recipe; the markdown is rendered from it.
+		r.Markdown = ""
+	}
+	r.Prepare(time.Now())
+	if err := savedStore.Save(userID, r); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	invalidateRecipeIndex(userID)
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"status": true,
+		"data":   r,
+	})
+}
+
+// ListSavedRecipesHandler lists the collection, optionally searched with q,
+// filtered to a tag, or to favourites only.
+func ListSavedRecipesHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	recipes, err := savedStore.List(userID, saved.Query{
+		Text:      c.QueryParam("q"),
+		Tag:       c.QueryParam("tag"),
+		Favourite: c.QueryParam("favourite") == "true",
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   recipes,
+	})
+}
+
+func SavedRecipeTagsHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	recipes, err := savedStore.List(userID, saved.Query{})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   saved.Tags(recipes),
+	})
+}
+
+func GetSavedRecipeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	r, err := savedStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return savedRecipeError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   true,
+		"data":     r,
+		"markdown": r.Text(),
+	})
+}
+
+// UpdateSavedRecipeHandler changes the title, tags, notes or favourite flag.
+// Fields left out are kept.
+func UpdateSavedRecipeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	r, err := savedStore.Get(userID, c.Param("id"))
+	if err != nil {
+		return savedRecipeError(c, err)
+	}
+
+	var data struct {
+		Title     *string   `json:"title"`
+		Tags      *[]string `json:"tags"`
+		Notes     *string   `json:"notes"`
+		Favourite *bool     `json:"favourite"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if data.Title != nil {
+		if strings.TrimSpace(*data.Title) == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Title cannot be empty"})
+		}
+		r.Title = *data.Title
+	}
+	if data.Tags != nil {
+		r.Tags = *data.Tags
+	}
+	if data.Notes != nil {
+		r.Notes = strings.TrimSpace(*data.Notes)
+	}
+	if data.Favourite != nil {
+		r.Favourite = *data.Favourite
+	}
+	r.Prepare(time.Now())
+
+	if err := savedStore.Save(userID, r); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	invalidateRecipeIndex(userID)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   r,
+	})
+}
+
+func DeleteSavedRecipeHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err := savedStore.Delete(userID, c.Param("id")); err != nil {
+		return savedRecipeError(c, err)
+	}
+	invalidateRecipeIndex(userID)
+	return c.JSON(http.StatusOK, map[string]interface{}{"status": true})
+}
+
+func savedRecipeError(c echo.Context, err error) error {
+	if errors.Is(err, saved.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Oluwaseun241/mura/internal/embed"
+	"github.com/Oluwaseun241/mura/internal/history"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/saved"
+	"github.com/Oluwaseun241/mura/internal/search"
+	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// maxCachedIndexes bounds how many users' search indexes are kept at once.
+// Past it the cache starts over; an index takes little time to rebuild.
+const maxCachedIndexes = 1000
+
+// userRecipes is the index over a user's saved recipes and every recipe
+// generated for them. The vectors for "more like this" cost embedding calls,
+// so they're only worked out when first asked for.
+type userRecipes struct {
+	docs []search.Doc
+	text *search.Index
+
+	mu      sync.Mutex
+	vectors *embed.Index
+}
+
+// recipeIndexes holds each user's recipes until their saved recipes or
+// history change. gen counts each user's changes, so an index built from
+// recipes read before a change isn't cached after it.
+var recipeIndexes = struct {
+	sync.Mutex
+	m   map[string]*userRecipes
+	gen map[string]uint64
+}{m: map[string]*userRecipes{}, gen: map[string]uint64{}}
+
+func invalidateRecipeIndex(userID string) {
+	recipeIndexes.Lock()
+	defer recipeIndexes.Unlock()
+	delete(recipeIndexes.m, userID)
+	recipeIndexes.gen[userID]++
+}
+
+// recipeIndex returns the user's recipes, indexing them if need be.
+func recipeIndex(userID string) (*userRecipes, error) {
+	recipeIndexes.Lock()
+	u, ok := recipeIndexes.m[userID]
+	gen := recipeIndexes.gen[userID]
+	recipeIndexes.Unlock()
+	if ok {
+		return u, nil
+	}
+
+	docs, err := recipeDocs(userID)
+	if err != nil {
+		return nil, err
+	}
+	u = &userRecipes{docs: docs, text: search.NewIndex(docs)}
+
+	recipeIndexes.Lock()
+	defer recipeIndexes.Unlock()
+	if recipeIndexes.gen[userID] != gen {
+		// Changed while indexing; use it this once but don't keep it
+		return u, nil
+	}
+	if len(recipeIndexes.m) >= maxCachedIndexes {
+		recipeIndexes.m = map[string]*userRecipes{}
+	}
+	recipeIndexes.m[userID] = u
+	return u, nil
+}
+
+func recipeDocs(userID string) ([]search.Doc, error) {
+	var docs []search.Doc
+
+	recipes, err := savedStore.List(userID, saved.Query{})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recipes {
+		var d search.Doc
+		if r.Recipe != nil {
+			d = search.FromRecipe(r.Recipe)
+		} else {
+			d = search.FromMarkdown(r.Markdown)
+		}
+		d.ID, d.Source, d.Title, d.Tags, d.CreatedAt = r.ID, "saved", r.Title, r.Tags, r.CreatedAt
+		if r.Notes != "" {
+			d.Steps = append(d.Steps, r.Notes)
+		}
+		docs = append(docs, d)
+	}
+
+	err = historyStore.Each(userID, func(e *history.Entry) error {
+		for _, d := range historyDocs(e) {
+			d.ID, d.Source, d.CreatedAt = e.ID, "history", e.CreatedAt
+			docs = append(docs, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// historyDocs returns the recipes in a history entry's result: the recipe
+// from /recipe, or one for each dish /detect-food wrote a recipe for.
+func historyDocs(e *history.Entry) []search.Doc {
+	var result struct {
+		Data   interface{}    `json:"data"`
+		Recipe *recipe.Recipe `json:"recipe"`
+		Dishes []service.Dish `json:"dishes"`
+	}
+	if len(e.Result) == 0 || json.Unmarshal(e.Result, &result) != nil {
+		return nil
+	}
+	if result.Recipe != nil {
+		return []search.Doc{search.FromRecipe(result.Recipe)}
+	}
+
+	var docs []search.Doc
+	for _, dish := range result.Dishes {
+		if dish.Recipe != "" {
+			d := search.FromMarkdown(dish.Recipe)
+			if d.Title == "" {
+				d.Title = dish.Name
+			}
+			docs = append(docs, d)
+		}
+	}
+	if text, ok := result.Data.(string); ok && len(docs) == 0 {
+		docs = append(docs, search.FromMarkdown(text))
+	}
+	return docs
+}
+
+// SearchRecipesHandler searches the user's saved and generated recipes by
+// title, ingredients, tags and steps. Results can be filtered by cuisine,
+// dietary flags (diet=vegan,gluten-free), most minutes of prep and cooking
+// (max_cook_time) and calories per serving (min_calories, max_calories).
+func SearchRecipesHandler(c echo.Context) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	filter := search.Filter{Cuisine: strings.TrimSpace(c.QueryParam("cuisine"))}
+	for _, flag := range strings.Split(c.QueryParam("diet"), ",") {
+		flag = strings.ToLower(strings.TrimSpace(flag))
+		if flag == "" {
+			continue
+		}
+		if !containsString(search.Flags, flag) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown diet " + flag + ", expected one of " + strings.Join(search.Flags, ", ")})
+		}
+		filter.Diet = append(filter.Diet, flag)
+	}
+	if v := c.QueryParam("max_cook_time"); v != "" {
+		if filter.MaxMinutes, err = strconv.Atoi(v); err != nil || filter.MaxMinutes <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "max_cook_time must be a number of minutes"})
+		}
+	}
+	for name, dst := range map[string]*float64{"min_calories": &filter.MinCalories, "max_calories": &filter.MaxCalories} {
+		if v := c.QueryParam(name); v != "" {
+			if *dst, err = strconv.ParseFloat(v, 64); err != nil || *dst < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": name + " must be a number"})
+			}
+		}
+	}
+
+	u, err := recipeIndex(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	hits := u.text.Search(c.QueryParam("q"), filter)
+
+	page, perPage := pageParams(c)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":   true,
+		"data":     pageOf(hits, page, perPage),
+		"page":     page,
+		"per_page": perPage,
+		"total":    len(hits),
+		"has_more": page*perPage < len(hits),
+	})
+}
+
+// pageOf returns the items on a 1-based page of perPage items. Pages past
+// the end are empty, however far past; page is checked before multiplying so
+// it can't overflow.
+func pageOf[T any](items []T, page, perPage int) []T {
+	if page < 1 || perPage < 1 || len(items) == 0 || page-1 > (len(items)-1)/perPage {
+		return []T{}
+	}
+	start := (page - 1) * perPage
+	return items[start : start+min(perPage, len(items)-start)]
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
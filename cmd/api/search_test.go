@@ -0,0 +1,20 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageOf(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	assert.Equal(t, []int{1, 2}, pageOf(items, 1, 2))
+	assert.Equal(t, []int{5}, pageOf(items, 3, 2))
+	assert.Equal(t, []int{}, pageOf(items, 4, 2))
+	assert.Equal(t, []int{}, pageOf(items, 0, 2))
+	assert.Equal(t, []int{}, pageOf(items[:3], 184467440737095517, 100))
+	assert.Equal(t, []int{}, pageOf(items, math.MaxInt, math.MaxInt))
+	assert.Equal(t, items, pageOf(items, 1, math.MaxInt))
+	assert.Equal(t, []int{}, pageOf([]int{}, 1, 2))
+}
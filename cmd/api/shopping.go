@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/shopping"
+	"github.com/labstack/echo/v4"
+)
+
+// ShoppingListHandler builds one shopping list for several recipes, each
+// optionally scaled to a number of servings, and/or a saved meal plan, minus
+// what's in the user's pantry. format (in the body or query) is json, text,
+// markdown or csv.
+func ShoppingListHandler(c echo.Context) error {
+	var data struct {
+		Recipes []struct {
+			Recipe   *recipe.Recipe `json:"recipe"`
+			Servings float64        `json:"servings"`
+		} `json:"recipes"`
+		PlanID    string `json:"plan_id"`
+		UsePantry *bool  `json:"use_pantry"`
+		Format    string `json:"format"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	var needs []shopping.Need
+	for _, r := range data.Recipes {
+		if r.Recipe == nil {
+			continue
+		}
+		needs = append(needs, shopping.FromRecipe(r.Recipe, r.Servings)...)
+	}
+	if data.PlanID != "" {
+		userID, err := currentUser(c)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		plan, err := mealPlanStore.Get(userID, data.PlanID)
+		if err != nil {
+			return mealPlanError(c, err)
+		}
+		needs = append(needs, plan.Needs()...)
+	}
+	if len(needs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No recipes or meal plan provided"})
+	}
+
+	have, err := shoppingPantry(c, data.UsePantry)
+	if errors.Is(err, errNoUser) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	format := data.Format
+	if format == "" {
+		format = c.QueryParam("format")
+	}
+	return renderShoppingList(c, shopping.Build(needs, have, time.Now()), format)
+}
+
+// shoppingPantry loads the pantry to subtract from a list: by default when
+// the user is known, always when usePantry is true and never when false.
+func shoppingPantry(c echo.Context, usePantry *bool) ([]pantry.Item, error) {
+	if usePantry != nil && !*usePantry {
+		return nil, nil
+	}
+	userID, err := currentUser(c)
+	if err != nil {
+		if usePantry != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return pantryStore.List(userID)
+}
+
+func renderShoppingList(c echo.Context, list shopping.List, format string) error {
+	switch format {
+	case "", "json":
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status": true,
+			"data":   list,
+		})
+	case "text":
+		return c.String(http.StatusOK, list.Text())
+	case "markdown":
+		return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(list.Markdown()))
+	case "csv":
+		text, err := list.CSV()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="shopping-list.csv"`)
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", []byte(text))
+	}
+	return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Unknown format %q, use json, text, markdown or csv", format)})
+}
@@ -0,0 +1,387 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/embed"
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/search"
+	"github.com/Oluwaseun241/mura/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// embedder embeds recipes and dish names for "more like this". It's nil,
+// and similar dishes are left out of responses, until InitEmbeddings runs.
+var embedder embed.Embedder
+
+// maxCachedVectors bounds how many embedded texts are remembered in memory.
+const maxCachedVectors = 50000
+
+// InitEmbeddings sets the embedder, remembering the vectors it returns so
+// the same text isn't embedded twice while the server runs. Only the dish
+// catalog is kept on disk. It needs InitStorage to have run.
+func InitEmbeddings(e embed.Embedder) {
+	embedder = embed.Cached(e, maxCachedVectors)
+	dropVectorCaches()
+}
+
+// dropVectorCaches deletes the caches of every embedded text, users' recipes
+// included, that earlier versions kept on disk.
+func dropVectorCaches() {
+	collections, err := vectorStore.Collections()
+	if err != nil {
+		log.Printf("Listing vector collections: %v", err)
+		return
+	}
+	for _, name := range collections {
+		if strings.HasPrefix(name, "cache/") {
+			if err := vectorStore.Drop(name); err != nil {
+				log.Printf("Dropping %s: %v", name, err)
+			}
+		}
+	}
+}
+
+var errNoEmbeddings = errors.New("Recommendations are not available")
+
+// similarDishCount is how many related dishes /detect-food suggests.
+const similarDishCount = 5
+
+// seedDishes start the catalog similar dishes are picked from, along with
+// the corpus recipes. Only these vetted names are ever suggested; the dishes
+// users photograph are compared against them but never added.
+var seedDishes = []string{
+	"jollof rice", "fried rice", "coconut rice", "egusi soup", "ogbono soup",
+	"efo riro", "okra soup", "pepper soup", "banga soup", "afang soup",
+	"edikang ikong", "moi moi", "akara", "suya", "puff puff", "pounded yam",
+	"amala", "eba", "fried plantain", "ofada stew", "beef stew", "chicken stew",
+	"waakye", "kelewele", "red red", "groundnut soup", "light soup", "fufu",
+	"chicken curry", "thai green curry", "lentil dal", "chana masala", "biryani",
+	"pad thai", "ramen", "pho", "spaghetti bolognese", "lasagne", "risotto",
+	"paella", "shakshuka", "hummus", "falafel", "chili con carne", "tacos",
+	"burrito", "caesar salad", "pancakes", "omelette", "banana bread",
+}
+
+// dishCatalog holds the vectors of the vetted dish names, keyed by name.
+// WarmDishCatalog fills it at startup; until then similar dishes aren't
+// available.
+var dishCatalog = struct {
+	sync.Mutex
+	index *embed.Index
+}{}
+
+var errCatalogNotReady = errors.New("Recommendations are not available yet")
+
+// catalogRetry is how long WarmDishCatalog waits after a failure.
+const catalogRetry = time.Minute
+
+func dishCollection() string { return "dishes/" + embedder.Model() }
+
+func dishKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// WarmDishCatalog embeds the vetted dish names, reusing the vectors stored
+// for them, and retries until it succeeds or ctx is cancelled. It runs
+// outside any request so no caller is charged for it, and does nothing
+// without an embedder.
+func WarmDishCatalog(ctx context.Context) {
+	if embedder == nil {
+		return
+	}
+	for {
+		ix, err := buildDishIndex(ctx)
+		if err == nil {
+			dishCatalog.Lock()
+			dishCatalog.index = ix
+			dishCatalog.Unlock()
+			log.Printf("Dish catalog ready with %d dishes", ix.Len())
+			return
+		}
+		log.Printf("Dish catalog: %v, retrying in %s", err, catalogRetry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(catalogRetry):
+		}
+	}
+}
+
+func buildDishIndex(ctx context.Context) (*embed.Index, error) {
+	ix := embed.NewIndex()
+	var missing []string
+	seen := map[string]bool{}
+	names := append(append([]string{}, seedDishes...), RecipeCorpus.Titles()...)
+	for _, name := range names {
+		key := dishKey(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		v, err := vectorStore.Get(dishCollection(), key)
+		if errors.Is(err, embed.ErrNotFound) {
+			missing = append(missing, key)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ix.Set(key, v)
+	}
+	if err := pruneDishes(seen, ix); err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return ix, nil
+	}
+	vectors, err := embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vectors {
+		if err := vectorStore.Put(dishCollection(), missing[i], v); err != nil {
+			return nil, err
+		}
+		ix.Set(missing[i], v)
+	}
+	return ix, nil
+}
+
+// pruneDishes rewrites the stored catalog with only the vetted dishes when
+// it holds others, such as users' dish names earlier versions added.
+func pruneDishes(vetted map[string]bool, ix *embed.Index) error {
+	extra := false
+	err := vectorStore.Each(dishCollection(), func(key string, _ []float32) error {
+		extra = extra || !vetted[key]
+		return nil
+	})
+	if err != nil || !extra {
+		return err
+	}
+	if err := vectorStore.Drop(dishCollection()); err != nil {
+		return err
+	}
+	for key := range vetted {
+		if v, ok := ix.Get(key); ok {
+			if err := vectorStore.Put(dishCollection(), key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SimilarDish is a dish like the ones asked about. Ask /recipe for it by
+// name to cook it.
+type SimilarDish struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// similarDishes returns the dishes in the catalog most like the given ones
+// together, leaving those out. Names the catalog doesn't have are embedded
+// to compare with, at the caller's cost, but aren't added to it.
+func similarDishes(ctx context.Context, names []string, k int) ([]SimilarDish, error) {
+	if embedder == nil {
+		return nil, errNoEmbeddings
+	}
+	dishCatalog.Lock()
+	ix := dishCatalog.index
+	dishCatalog.Unlock()
+	if ix == nil {
+		return nil, errCatalogNotReady
+	}
+
+	asked := map[string]bool{}
+	var vectors [][]float32
+	var missing []string
+	for _, name := range names {
+		key := dishKey(name)
+		if key == "" || asked[key] {
+			continue
+		}
+		asked[key] = true
+		if v, ok := ix.Get(key); ok {
+			vectors = append(vectors, v)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		embedded, err := embedder.Embed(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	dishes := []SimilarDish{}
+	for _, m := range ix.Nearest(embed.Mean(vectors), k, func(key string) bool { return asked[key] }) {
+		dishes = append(dishes, SimilarDish{Name: m.Key, Score: roundScore(m.Score)})
+	}
+	return dishes, nil
+}
+
+func roundScore(s float64) float64 {
+	return float64(int(s*1000+0.5)) / 1000
+}
+
+// plateDishes names the dishes in a /detect-food response: each detected
+// dish, or the title of the one recipe when the plate wasn't split up.
+func plateDishes(response map[string]interface{}) []string {
+	var names []string
+	if dishes, ok := response["dishes"].([]service.Dish); ok {
+		for _, d := range dishes {
+			names = append(names, d.Name)
+		}
+	}
+	if text, ok := response["data"].(string); ok && len(names) == 0 {
+		if title := search.FromMarkdown(text).Title; title != "" {
+			names = append(names, title)
+		}
+	}
+	return names
+}
+
+// recipeText is what a recipe's embedding is made from: what it is and what
+// goes in it, leaving out amounts and method.
+func recipeText(d search.Doc) string {
+	text := d.Title
+	if d.Cuisine != "" {
+		text += fmt.Sprintf(". %s cuisine", d.Cuisine)
+	}
+	var names []string
+	for _, line := range d.Ingredients {
+		if name := ingredient.Parse(line).Name; name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		text += ". Made with " + strings.Join(names, ", ")
+	}
+	return text
+}
+
+// recipeVectors embeds each of the user's recipes, keyed by position in
+// docs.
+func (u *userRecipes) recipeVectors(ctx context.Context) (*embed.Index, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.vectors != nil {
+		return u.vectors, nil
+	}
+	if embedder == nil {
+		return nil, errNoEmbeddings
+	}
+
+	texts := make([]string, len(u.docs))
+	for i, d := range u.docs {
+		texts[i] = recipeText(d)
+	}
+	ix := embed.NewIndex()
+	if len(texts) > 0 {
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range vectors {
+			ix.Set(strconv.Itoa(i), v)
+		}
+	}
+	u.vectors = ix
+	return ix, nil
+}
+
+// SimilarRecipe is one of the user's recipes like another.
+type SimilarRecipe struct {
+	ID     string  `json:"id"`
+	Source string  `json:"source"`
+	Title  string  `json:"title"`
+	Score  float64 `json:"score"`
+}
+
+// SimilarRecipesHandler finds the user's saved and generated recipes most
+// like one of theirs, and dishes like it they haven't made. The id is a
+// saved recipe's or a history entry's, as search returns them; source
+// tells them apart if both have it.
+func SimilarRecipesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if embedder == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": errNoEmbeddings.Error()})
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 5
+	}
+	limit = min(limit, 20)
+
+	u, err := recipeIndex(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	id, source := c.Param("id"), c.QueryParam("source")
+	var titles []string
+	var at []int
+	for i, d := range u.docs {
+		if d.ID == id && (source == "" || d.Source == source) {
+			at = append(at, i)
+			titles = append(titles, d.Title)
+		}
+	}
+	if len(at) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Recipe not found"})
+	}
+
+	ix, err := u.recipeVectors(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	var vectors [][]float32
+	for _, i := range at {
+		if v, ok := ix.Get(strconv.Itoa(i)); ok {
+			vectors = append(vectors, v)
+		}
+	}
+
+	// Ask for extra matches, as a history entry with several dishes can
+	// come up more than once
+	recipes := []SimilarRecipe{}
+	seen := map[string]bool{}
+	skip := func(key string) bool {
+		i, _ := strconv.Atoi(key)
+		return u.docs[i].ID == id
+	}
+	for _, m := range ix.Nearest(embed.Mean(vectors), limit*2, skip) {
+		i, _ := strconv.Atoi(m.Key)
+		d := u.docs[i]
+		if seen[d.Source+d.ID] || len(recipes) == limit {
+			continue
+		}
+		seen[d.Source+d.ID] = true
+		recipes = append(recipes, SimilarRecipe{ID: d.ID, Source: d.Source, Title: d.Title, Score: roundScore(m.Score)})
+	}
+
+	response := map[string]interface{}{
+		"status": true,
+		"data":   recipes,
+	}
+	dishes, err := similarDishes(ctx, titles, similarDishCount)
+	if err != nil {
+		response["dishes_error"] = err.Error()
+	} else {
+		response["dishes"] = dishes
+	}
+	return c.JSON(http.StatusOK, response)
+}
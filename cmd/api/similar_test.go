@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/embed"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarDishesDontLearnNames(t *testing.T) {
+	defer func() {
+		embedder = nil
+		dishCatalog.index = nil
+	}()
+	InitStorage(storagetest.Open(t))
+	InitEmbeddings(embed.Fake{})
+	ctx := context.Background()
+	// Learned from a user by an earlier version
+	assert.NoError(t, vectorStore.Put(dishCollection(), "aunty bisi's stew", []float32{1}))
+
+	_, err := similarDishes(ctx, []string{"jollof rice"}, 3)
+	assert.ErrorIs(t, err, errCatalogNotReady)
+
+	WarmDishCatalog(ctx)
+	size := dishCatalog.index.Len()
+	_, err = vectorStore.Get(dishCollection(), "aunty bisi's stew")
+	assert.ErrorIs(t, err, embed.ErrNotFound)
+	_, err = vectorStore.Get(dishCollection(), "jollof rice")
+	assert.NoError(t, err)
+
+	dishes, err := similarDishes(ctx, []string{"Grandma Ada's party jollof"}, 3)
+	assert.NoError(t, err)
+	assert.Len(t, dishes, 3)
+	for _, d := range dishes {
+		assert.NotEqual(t, "grandma ada's party jollof", d.Name)
+	}
+	assert.Equal(t, size, dishCatalog.index.Len())
+	_, err = vectorStore.Get(dishCollection(), "grandma ada's party jollof")
+	assert.ErrorIs(t, err, embed.ErrNotFound)
+
+	// Vetted names asked about are left out of their own suggestions
+	dishes, err = similarDishes(ctx, []string{"Jollof Rice"}, 3)
+	assert.NoError(t, err)
+	for _, d := range dishes {
+		assert.NotEqual(t, "jollof rice", d.Name)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/auth"
+	"github.com/Oluwaseun241/mura/internal/calendar"
+	"github.com/Oluwaseun241/mura/internal/diary"
+	"github.com/Oluwaseun241/mura/internal/embed"
+	"github.com/Oluwaseun241/mura/internal/fridge"
+	"github.com/Oluwaseun241/mura/internal/history"
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/saved"
+	"github.com/Oluwaseun241/mura/internal/storage"
+	"github.com/Oluwaseun241/mura/internal/suggest"
+	"github.com/labstack/echo/v4"
+)
+
+// Per-user data lives in these stores, which InitStorage sets up once main
+// has opened the database.
+var (
+	diaryStore      diary.Store
+	pantryStore     pantry.Store
+	suggestionStore suggest.Store
+	fridgeStore     fridge.Store
+	mealPlanStore   mealplan.Store
+	calendarStore   calendar.Store
+	authStore       auth.Store
+	apiKeyStore     apikey.Store
+	savedStore      saved.Store
+	historyStore    history.Store
+	vectorStore     embed.Store
+)
+
+func InitStorage(db *storage.DB) {
+	diaryStore = diary.NewBoltStore(db)
+	pantryStore = pantry.NewBoltStore(db)
+	suggestionStore = suggest.NewBoltStore(db)
+	fridgeStore = fridge.NewBoltStore(db)
+	mealPlanStore = mealplan.NewBoltStore(db)
+	calendarStore = calendar.NewBoltStore(db)
+	authStore = auth.NewBoltStore(db)
+	apiKeyStore = apikey.NewBoltStore(db)
+	savedStore = saved.NewBoltStore(db)
+	historyStore = history.NewBoltStore(db)
+	vectorStore = embed.NewBoltStore(db)
+}
+
+var errNoUser = errors.New("Sign in required")
+
+// currentUser identifies who a per-user request is for: the user the
+// Authenticate middleware found in the bearer token, or the owner of the API
+// key it was made with.
+func currentUser(c echo.Context) (string, error) {
+	id, _ := c.Get(userIDKey).(string)
+	if id == "" {
+		return "", errNoUser
+	}
+	return id, nil
+}
+
+// RequireUser refuses anonymous requests to routes that serve per-user
+// data, so a handler can't serve one by mistake.
+func RequireUser(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, err := currentUser(c); err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		return next(c)
+	}
+}
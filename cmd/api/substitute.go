@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/substitute"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+func SubstituteHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var data struct {
+		Recipe      *recipe.Recipe `json:"recipe"`
+		Dish        string         `json:"dish"`
+		Ingredient  string         `json:"ingredient"`
+		Constraints []string       `json:"constraints"`
+		Have        []string       `json:"have"`
+	}
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if strings.TrimSpace(data.Ingredient) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No ingredient to substitute"})
+	}
+
+	dish := data.Dish
+	if dish == "" && data.Recipe != nil {
+		dish = data.Recipe.Title
+	}
+
+	// Prefer the curated table and only ask the model when it has nothing
+	// that meets the constraints.
+	subs := substitute.Lookup(data.Ingredient, data.Constraints, data.Have)
+	if len(subs) == 0 {
+		var err error
+		subs, err = getSubstitutions(ctx, dish, data.Ingredient, data.Constraints)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if data.Recipe != nil {
+		for i := range subs {
+			substitute.Apply(data.Recipe, data.Ingredient, &subs[i])
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data": map[string]interface{}{
+			"ingredient":    data.Ingredient,
+			"dish":          dish,
+			"substitutions": subs,
+		},
+	})
+}
+
+func getSubstitutions(ctx context.Context, dish, name string, constraints []string) ([]substitute.Substitution, error) {
+	prompt := fmt.Sprintf("Suggest up to 3 substitutes for %s", name)
+	if dish != "" {
+		prompt += fmt.Sprintf(" in %s", dish)
+	}
+	if len(constraints) > 0 {
+		prompt += fmt.Sprintf(". Every substitute must be %s", strings.Join(constraints, ", "))
+	}
+	prompt += ". Rank them best first. Return a JSON array formatted as [{\"ingredient\": \"substitute\", \"ratio\": amount of substitute per 1 unit of the original as a number, \"ratio_note\": \"how much to use\", \"taste\": \"effect on taste\", \"texture\": \"effect on texture\", \"prep\": \"any preparation the substitute needs, or empty\", \"confidence\": number from 0 to 1}] without any additional text."
+
+	content, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []struct {
+		substitute.Substitution
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal(content, &suggestions); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("no substitutes found for %s", name)
+	}
+
+	subs := make([]substitute.Substitution, 0, len(suggestions))
+	for _, s := range suggestions {
+		s.Substitution.Source = "model"
+		s.Substitution.Score = s.Confidence
+		s.Substitution.Tags = constraints
+		subs = append(subs, s.Substitution)
+	}
+	return subs, nil
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/suggest"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+// numSuggestions is how many recipe ideas we ask the model for.
+const numSuggestions = 6
+
+// suggestionJobTimeout is how long the daily job waits on one user's
+// suggestions before moving on to the next.
+const suggestionJobTimeout = time.Minute
+
+// SuggestionsHandler returns recipe ideas ranked by how many soon-to-expire
+// pantry items they use. The daily job usually has them ready; they are
+// recomputed when missing, out of date, or refresh=true.
+func SuggestionsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUser(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+
+	items, err := pantryStore.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now()
+	latest, err := suggestionStore.Latest(userID)
+	if err != nil && !errors.Is(err, suggest.ErrNotFound) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if err != nil || c.QueryParam("refresh") == "true" || latest.Stale(items, now) {
+		if latest, err = computeSuggestions(ctx, userID, items, now); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": true,
+		"data":   latest,
+	})
+}
+
+// computeSuggestions asks the model for ideas, ranks them against the pantry
+// and stores the result.
+func computeSuggestions(ctx context.Context, userID string, items []pantry.Item, now time.Time) (suggest.Suggestions, error) {
+	s := suggest.Suggestions{
+		GeneratedAt: now,
+		Pantry:      suggest.Fingerprint(items),
+		Expiring:    suggest.Expiring(items, now),
+		Ideas:       []suggest.Idea{},
+	}
+
+	var fresh []pantry.Item
+	for _, item := range items {
+		if !item.Expired(now) {
+			fresh = append(fresh, item)
+		}
+	}
+	if len(fresh) > 0 {
+		ideas, err := getUseItUpIdeas(ctx, s.Expiring, fresh, now)
+		if err != nil {
+			return suggest.Suggestions{}, err
+		}
+		s.Ideas = suggest.Rank(ideas, items, now)
+	}
+
+	if err := suggestionStore.Save(userID, s); err != nil {
+		return suggest.Suggestions{}, err
+	}
+	return s, nil
+}
+
+func getUseItUpIdeas(ctx context.Context, expiring, items []pantry.Item, now time.Time) ([]suggest.Idea, error) {
+	var soon, rest []string
+	isExpiring := map[string]bool{}
+	for _, item := range expiring {
+		isExpiring[item.ID] = true
+		days, _ := item.DaysLeft(now)
+		soon = append(soon, fmt.Sprintf("%s (expires in %d days)", item.Ingredient().Raw, days))
+	}
+	for _, item := range items {
+		if !isExpiring[item.ID] {
+			rest = append(rest, item.Ingredient().Raw)
+		}
+	}
+
+	prompt := fmt.Sprintf("Suggest %d different recipe ideas for a home cook who wants to waste as little food as possible.", numSuggestions)
+	if len(soon) > 0 {
+		prompt += fmt.Sprintf(" These ingredients are about to go off, so each idea should use as many of them as it sensibly can, the soonest first: %s.", strings.Join(soon, ", "))
+	}
+	if len(rest) > 0 {
+		prompt += fmt.Sprintf(" They also have: %s.", strings.Join(rest, ", "))
+	}
+	prompt += ` Prefer ideas that need nothing else beyond pantry staples. Respond only with JSON in this format: {"ideas": [{"title": "recipe name", "description": "one sentence", "ingredients": ["ingredient with quantity"]}]}`
+
+	data, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Ideas []suggest.Idea `json:"ideas"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	return result.Ideas, nil
+}
+
+// RunSuggestionJob precomputes suggestions every day at the given hour for
+// each user with something about to expire, until ctx is cancelled.
+func RunSuggestionJob(ctx context.Context, hour int) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(suggest.NextRun(now, hour).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		refreshSuggestions(ctx)
+		// Step past the hour so the same run isn't picked again.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+func refreshSuggestions(ctx context.Context) {
+	users, err := pantryStore.Users()
+	if err != nil {
+		log.Printf("Suggestion job: failed to list users: %v", err)
+		return
+	}
+
+	var done int
+	for _, userID := range users {
+		items, err := pantryStore.List(userID)
+		if err != nil {
+			log.Printf("Suggestion job: failed to load pantry for %s: %v", userID, err)
+			continue
+		}
+		now := time.Now()
+		if len(suggest.Expiring(items, now)) == 0 {
+			continue
+		}
+		userCtx, cancel := context.WithTimeout(ctx, suggestionJobTimeout)
+		_, err = computeSuggestions(userCtx, userID, items, now)
+		cancel()
+		if err != nil {
+			log.Printf("Suggestion job: failed for %s: %v", userID, err)
+			continue
+		}
+		done++
+	}
+	log.Printf("Suggestion job: refreshed suggestions for %d of %d users", done, len(users))
+}
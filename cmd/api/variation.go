@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/units"
+	"github.com/google/generative-ai-go/genai"
+	"github.com/labstack/echo/v4"
+)
+
+func VariationHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	var data struct {
+		Recipe         *recipe.Recipe `json:"recipe"`
+		Transformation string         `json:"transformation"`
+		Units          string         `json:"units"`
+	}
+
+	if err := c.Bind(&data); err != nil || data.Recipe == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No recipe provided"})
+	}
+	if _, ok := recipe.Variations[data.Transformation]; !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Unknown transformation %q: use one of %s", data.Transformation, strings.Join(recipe.VariationNames(), ", ")),
+		})
+	}
+
+	loc, err := parseUnits(data.Units)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	variation, err := getRecipeVariation(ctx, data.Recipe, data.Transformation, loc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":         true,
+		"transformation": data.Transformation,
+		"data":           variation.Markdown(),
+		"recipe":         variation,
+		"diff":           recipe.Compare(data.Recipe, variation),
+	})
+}
+
+func getRecipeVariation(ctx context.Context, original *recipe.Recipe, transformation string, loc *units.Locale) (*recipe.Recipe, error) {
+	current, err := original.SchemaJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(recipeGuidelines+". Here is a recipe as JSON: %s. Rewrite it as follows. %s Keep every ingredient and step that doesn't need to change exactly as it is, worded the same, and update the times and nutrition to match.", current, recipe.Variations[transformation])
+	prompt += unitsPrompt(loc)
+	prompt += " Respond only with JSON in this format: " + recipe.Schema
+
+	content, err := generateJSON(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, err
+	}
+
+	variation, err := recipe.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		variation.Localize(*loc)
+	}
+	return variation, nil
+}
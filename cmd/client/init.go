@@ -9,6 +9,9 @@ import (
 	"google.golang.org/api/option"
 )
 
+// GeminiModel is the model every prompt is sent to.
+const GeminiModel = "gemini-2.0-flash"
+
 var (
 	GeminiClient *genai.Client
 )
@@ -0,0 +1,131 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/usage"
+)
+
+var (
+	ErrNotFound = errors.New("API key not found")
+	ErrRevoked  = errors.New("API key has been revoked")
+)
+
+// Prefix starts every key, so a key can be told apart from a JWT in an
+// Authorization header and spotted by secret scanners.
+const Prefix = "mura_"
+
+// displayLength is how much of a key is kept in the clear to tell keys
+// apart in a list.
+const displayLength = len(Prefix) + 6
+
+// Quota caps how many calls a key may make to each service per UTC day and
+// per UTC month. Zero means no calls.
+type Quota struct {
+	GeminiDaily    int `json:"gemini_daily"`
+	GeminiMonthly  int `json:"gemini_monthly"`
+	YouTubeDaily   int `json:"youtube_daily"`
+	YouTubeMonthly int `json:"youtube_monthly"`
+}
+
+// Within returns q with every limit capped at max, so a key never gets more
+// than the server allows. Limits left at zero take max's value.
+func (q Quota) Within(max Quota) Quota {
+	limit := func(v, max int) int {
+		if v <= 0 || v > max {
+			return max
+		}
+		return v
+	}
+	return Quota{
+		GeminiDaily:    limit(q.GeminiDaily, max.GeminiDaily),
+		GeminiMonthly:  limit(q.GeminiMonthly, max.GeminiMonthly),
+		YouTubeDaily:   limit(q.YouTubeDaily, max.YouTubeDaily),
+		YouTubeMonthly: limit(q.YouTubeMonthly, max.YouTubeMonthly),
+	}
+}
+
+// Key is an issued API key. The secret itself is only shown once, when the
+// key is created; only its hash is stored.
+type Key struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	Display   string     `json:"display"`
+	Quota     Quota      `json:"quota"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash is how a key is stored and looked up. Keys are long and random, so a
+// fast hash is enough; there's nothing to brute force.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey reports whether a credential looks like an API key rather than a
+// JWT.
+func IsKey(credential string) bool {
+	return strings.HasPrefix(credential, Prefix)
+}
+
+// Usage is how many calls were made to each service in one period.
+type Usage map[usage.Service]int
+
+// Periods names the UTC day and month now falls in, which is how usage is
+// counted.
+func Periods(now time.Time) (day, month string) {
+	now = now.UTC()
+	return now.Format(time.DateOnly), now.Format("2006-01")
+}
+
+// Resets returns when the current UTC day and month end.
+func Resets(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return midnight.AddDate(0, 0, 1), first.AddDate(0, 1, 0)
+}
+
+// Status is what a key has left of each service's quota, and when the limit
+// that runs out first resets.
+type Status struct {
+	Remaining map[usage.Service]int       `json:"remaining"`
+	Reset     map[usage.Service]time.Time `json:"reset"`
+}
+
+// Check works out what's left of the key's quota given its usage so far
+// this day and month.
+func (k Key) Check(day, month Usage, now time.Time) Status {
+	dayReset, monthReset := Resets(now)
+	st := Status{Remaining: map[usage.Service]int{}, Reset: map[usage.Service]time.Time{}}
+	limits := map[usage.Service][2]int{
+		usage.Gemini:  {k.Quota.GeminiDaily, k.Quota.GeminiMonthly},
+		usage.YouTube: {k.Quota.YouTubeDaily, k.Quota.YouTubeMonthly},
+	}
+	for s, l := range limits {
+		daily, monthly := max(l[0]-day[s], 0), max(l[1]-month[s], 0)
+		if monthly < daily {
+			st.Remaining[s], st.Reset[s] = monthly, monthReset
+		} else {
+			st.Remaining[s], st.Reset[s] = daily, dayReset
+		}
+	}
+	return st
+}
@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/Oluwaseun241/mura/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+var limits = Quota{GeminiDaily: 100, GeminiMonthly: 1000, YouTubeDaily: 20, YouTubeMonthly: 50}
+
+func TestWithin(t *testing.T) {
+	assert.Equal(t, limits, Quota{}.Within(limits))
+	assert.Equal(t,
+		Quota{GeminiDaily: 10, GeminiMonthly: 1000, YouTubeDaily: 20, YouTubeMonthly: 50},
+		Quota{GeminiDaily: 10, GeminiMonthly: 5000, YouTubeDaily: -1}.Within(limits),
+	)
+}
+
+func TestResets(t *testing.T) {
+	now := time.Date(2026, 12, 31, 23, 30, 0, 0, time.FixedZone("WAT", 3600))
+	day, month := Periods(now)
+	assert.Equal(t, "2026-12-31", day)
+	assert.Equal(t, "2026-12", month)
+
+	dayReset, monthReset := Resets(now)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), dayReset)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), monthReset)
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	k := Key{Quota: limits}
+
+	st := k.Check(Usage{usage.Gemini: 30, usage.YouTube: 5}, Usage{usage.Gemini: 400, usage.YouTube: 45}, now)
+	assert.Equal(t, 70, st.Remaining[usage.Gemini])
+	assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), st.Reset[usage.Gemini])
+	// The month runs out before the day does.
+	assert.Equal(t, 5, st.Remaining[usage.YouTube])
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), st.Reset[usage.YouTube])
+
+	st = k.Check(Usage{usage.Gemini: 150}, Usage{usage.Gemini: 150}, now)
+	assert.Equal(t, 0, st.Remaining[usage.Gemini])
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	k, secret, err := s.Create("ada", "Shop app", limits, now)
+	assert.NoError(t, err)
+	assert.True(t, IsKey(secret))
+	assert.Equal(t, secret[:displayLength]+"…", k.Display)
+	assert.False(t, IsKey("eyJhbGciOiJIUzI1NiJ9.e30.x"))
+
+	found, err := s.Lookup(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, k, found)
+	_, err = s.Lookup(secret + "x")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, s.AddUsage(k.ID, Usage{usage.Gemini: 3, usage.YouTube: 1}, now))
+	assert.NoError(t, s.AddUsage(k.ID, Usage{usage.Gemini: 2}, now))
+	assert.NoError(t, s.AddUsage(k.ID, Usage{usage.Gemini: 1}, now.AddDate(0, 0, 1)))
+	day, month, err := s.Usage(k.ID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, Usage{usage.Gemini: 5, usage.YouTube: 1}, day)
+	assert.Equal(t, Usage{usage.Gemini: 6, usage.YouTube: 1}, month)
+
+	keys, err := s.List("ada")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	keys, err = s.List("bob")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = s.Revoke("bob", k.ID, now)
+	assert.ErrorIs(t, err, ErrNotFound)
+	revoked, err := s.Revoke("ada", k.ID, now)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = s.Lookup(secret)
+	assert.ErrorIs(t, err, ErrRevoked)
+}
@@ -0,0 +1,171 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+// Store keeps issued keys and how much each has been used.
+type Store interface {
+	// Create issues a new key and returns it with its secret.
+	Create(userID, name string, quota Quota, now time.Time) (Key, string, error)
+	// List returns the user's keys, newest first, revoked ones included.
+	List(userID string) ([]Key, error)
+	Revoke(userID, id string, now time.Time) (Key, error)
+	// Lookup finds a key by its secret. Revoked keys are returned with
+	// ErrRevoked.
+	Lookup(secret string) (Key, error)
+	// Usage returns the key's usage for the day and month now falls in.
+	Usage(id string, now time.Time) (day, month Usage, err error)
+	// AddUsage adds calls to the key's usage for the day and month now
+	// falls in.
+	AddUsage(id string, calls Usage, now time.Time) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+	// mu serialises usage updates, which read and then write a counter.
+	mu sync.Mutex
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+var (
+	keysPath   = []string{"apikeys", "keys"}
+	hashesPath = []string{"apikeys", "hashes"}
+)
+
+func usagePath(id string) []string { return []string{"apikeys", "usage", id} }
+
+func (s *BoltStore) Create(userID, name string, quota Quota, now time.Time) (Key, string, error) {
+	secret, err := Generate()
+	if err != nil {
+		return Key{}, "", err
+	}
+	id, err := storage.NewID()
+	if err != nil {
+		return Key{}, "", err
+	}
+	k := Key{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Display:   secret[:displayLength] + "…",
+		Quota:     quota,
+		CreatedAt: now,
+	}
+	if err := s.db.Put(keysPath, id, k); err != nil {
+		return Key{}, "", err
+	}
+	return k, secret, s.db.Put(hashesPath, Hash(secret), id)
+}
+
+func (s *BoltStore) List(userID string) ([]Key, error) {
+	keys := []Key{}
+	err := s.db.Each(keysPath, func(_ string, data []byte) error {
+		var k Key
+		if err := json.Unmarshal(data, &k); err != nil {
+			return err
+		}
+		if k.UserID == userID {
+			keys = append(keys, k)
+		}
+		return nil
+	})
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, err
+}
+
+func (s *BoltStore) get(id string) (Key, error) {
+	var k Key
+	err := s.db.Get(keysPath, id, &k)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Key{}, ErrNotFound
+	}
+	return k, err
+}
+
+func (s *BoltStore) Revoke(userID, id string, now time.Time) (Key, error) {
+	k, err := s.get(id)
+	if err != nil {
+		return Key{}, err
+	}
+	if k.UserID != userID {
+		return Key{}, ErrNotFound
+	}
+	if k.RevokedAt == nil {
+		k.RevokedAt = &now
+		if err := s.db.Put(keysPath, id, k); err != nil {
+			return Key{}, err
+		}
+	}
+	return k, nil
+}
+
+func (s *BoltStore) Lookup(secret string) (Key, error) {
+	var id string
+	err := s.db.Get(hashesPath, Hash(secret), &id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Key{}, ErrNotFound
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	k, err := s.get(id)
+	if err != nil {
+		return Key{}, err
+	}
+	if k.RevokedAt != nil {
+		return k, ErrRevoked
+	}
+	return k, nil
+}
+
+func (s *BoltStore) Usage(id string, now time.Time) (Usage, Usage, error) {
+	day, month := Periods(now)
+	d, err := s.usage(id, day)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := s.usage(id, month)
+	return d, m, err
+}
+
+func (s *BoltStore) usage(id, period string) (Usage, error) {
+	u := Usage{}
+	err := s.db.Get(usagePath(id), period, &u)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Usage{}, nil
+	}
+	return u, err
+}
+
+func (s *BoltStore) AddUsage(id string, calls Usage, now time.Time) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day, month := Periods(now)
+	for _, period := range []string{day, month} {
+		u, err := s.usage(id, period)
+		if err != nil {
+			return err
+		}
+		for svc, n := range calls {
+			u[svc] += n
+		}
+		if err := s.db.Put(usagePath(id), period, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
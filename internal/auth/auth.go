@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters")
+	ErrLongPassword       = errors.New("password must be at most 72 bytes")
+	ErrEmailTaken         = errors.New("an account with that email already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// MinPasswordLength is the shortest password accepted at registration.
+const MinPasswordLength = 8
+
+// bcrypt ignores anything past 72 bytes, so longer passwords are refused
+// rather than silently truncated.
+const maxPasswordLength = 72
+
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeEmail lower-cases an address so that an account can be found
+// however its owner types it.
+func NormalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// HashPassword checks a new password is acceptable and hashes it.
+func HashPassword(password string) ([]byte, error) {
+	if len(password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	if len(password) > maxPasswordLength {
+		return nil, ErrLongPassword
+	}
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// Register creates an account.
+func Register(s Store, email, password, name string, now time.Time) (User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return User{}, err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	u := User{Email: email, Name: strings.TrimSpace(name), CreatedAt: now}
+	return s.Create(u, hash)
+}
+
+// Login checks an email and password. Unknown emails and wrong passwords
+// get the same error so the response doesn't reveal who has an account.
+func Login(s Store, email, password string) (User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	u, hash, err := s.ByEmail(email)
+	if errors.Is(err, ErrNotFound) {
+		// Compare anyway so both cases take as long.
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, err
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
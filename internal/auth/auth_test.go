@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func openStore(t *testing.T) *BoltStore {
+	return NewBoltStore(storagetest.Open(t))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	email, err := NormalizeEmail("  Ada@Example.COM ")
+	assert.NoError(t, err)
+	assert.Equal(t, "ada@example.com", email)
+
+	for _, bad := range []string{"", "ada", "Ada <ada@example.com>", "ada@"} {
+		_, err := NormalizeEmail(bad)
+		assert.ErrorIs(t, err, ErrInvalidEmail, bad)
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := openStore(t)
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	u, err := Register(s, "Ada@example.com", "correct horse", "Ada", now)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, u.ID)
+	assert.Equal(t, "ada@example.com", u.Email)
+
+	_, err = Register(s, "ada@EXAMPLE.com", "another password", "", now)
+	assert.ErrorIs(t, err, ErrEmailTaken)
+	_, err = Register(s, "bob@example.com", "short", "", now)
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	_, err = Register(s, "bob@example.com", strings.Repeat("x", 73), "", now)
+	assert.ErrorIs(t, err, ErrLongPassword)
+
+	got, err := Login(s, "ADA@example.com", "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	_, err = Login(s, "ada@example.com", "wrong password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = Login(s, "nobody@example.com", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	byID, err := s.ByID(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, u, byID)
+	_, err = s.ByID("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	issuer := NewIssuer([]byte("secret"), 15*time.Minute, 30*24*time.Hour)
+	issuer.Now = func() time.Time { return now }
+
+	tokens, err := issuer.Issue("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, 900, tokens.ExpiresIn)
+
+	id, err := issuer.Verify(tokens.AccessToken, AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", id)
+	claims, err := issuer.Parse(tokens.RefreshToken, RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", claims.Subject)
+	assert.Equal(t, tokens.RefreshID, claims.Id)
+	assert.Equal(t, now.Add(30*24*time.Hour), tokens.RefreshExpiresAt)
+
+	// Every refresh token can be told apart
+	again, err := issuer.Issue("ada")
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshID, again.RefreshID)
+
+	// Each token only works as its own type.
+	_, err = issuer.Verify(tokens.RefreshToken, AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = issuer.Verify(tokens.AccessToken, RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Another key, a tampered token, or garbage are all rejected.
+	other := NewIssuer([]byte("other"), time.Minute, time.Minute)
+	other.Now = issuer.Now
+	_, err = other.Verify(tokens.AccessToken, AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = issuer.Verify(tokens.AccessToken+"x", AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = issuer.Verify("not a token", AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// The access token expires long before the refresh token.
+	now = now.Add(time.Hour)
+	_, err = issuer.Verify(tokens.AccessToken, AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = issuer.Verify(tokens.RefreshToken, RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestSessions(t *testing.T) {
+	s := openStore(t)
+	later := time.Now().Add(time.Hour)
+
+	assert.NoError(t, s.AddSession("ada", "one", later))
+	assert.NoError(t, s.AddSession("ada", "two", later))
+	assert.NoError(t, s.AddSession("bob", "three", later))
+
+	// A session ends once
+	assert.NoError(t, s.EndSession("ada", "one"))
+	assert.ErrorIs(t, s.EndSession("ada", "one"), ErrNotFound)
+	// and only for its own user
+	assert.ErrorIs(t, s.EndSession("ada", "three"), ErrNotFound)
+
+	assert.NoError(t, s.EndSessions("ada"))
+	assert.ErrorIs(t, s.EndSession("ada", "two"), ErrNotFound)
+	assert.NoError(t, s.EndSession("bob", "three"))
+
+	// Expired sessions are cleared out when another starts
+	assert.NoError(t, s.AddSession("ada", "old", time.Now().Add(-time.Minute)))
+	assert.NoError(t, s.AddSession("ada", "new", later))
+	assert.ErrorIs(t, s.EndSession("ada", "old"), ErrNotFound)
+}
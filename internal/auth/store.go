@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("user not found")
+
+// Store keeps accounts and their password hashes.
+type Store interface {
+	// Create stores a new account, giving it an ID. It fails with
+	// ErrEmailTaken if the email is already registered.
+	Create(u User, passwordHash []byte) (User, error)
+	ByEmail(email string) (User, []byte, error)
+	ByID(id string) (User, error)
+
+	// AddSession records a refresh token ID as live until it expires.
+	AddSession(userID, id string, expires time.Time) error
+	// EndSession revokes a refresh token ID. It fails with ErrNotFound if
+	// the ID isn't live, such as when its token was already used, so a
+	// token can only be spent once.
+	EndSession(userID, id string) error
+	// EndSessions revokes every refresh token the user holds.
+	EndSessions(userID string) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+	// mu stops two registrations for the same email racing.
+	mu sync.Mutex
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+var (
+	accountsPath = []string{"accounts", "users"}
+	emailsPath   = []string{"accounts", "emails"}
+)
+
+func sessionsPath(userID string) []string {
+	return []string{"accounts", "sessions", userID}
+}
+
+// account is how a user is stored; the hash never leaves this package's
+// store.
+type account struct {
+	User
+	PasswordHash []byte `json:"password_hash"`
+}
+
+func (s *BoltStore) Create(u User, passwordHash []byte) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var existing string
+	err := s.db.Get(emailsPath, u.Email, &existing)
+	if err == nil {
+		return User{}, ErrEmailTaken
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return User{}, err
+	}
+
+	id, err := storage.NewID()
+	if err != nil {
+		return User{}, err
+	}
+	u.ID = id
+	if err := s.db.Put(accountsPath, id, account{User: u, PasswordHash: passwordHash}); err != nil {
+		return User{}, err
+	}
+	return u, s.db.Put(emailsPath, u.Email, id)
+}
+
+func (s *BoltStore) ByEmail(email string) (User, []byte, error) {
+	var id string
+	err := s.db.Get(emailsPath, email, &id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return User{}, nil, ErrNotFound
+	}
+	if err != nil {
+		return User{}, nil, err
+	}
+	a, err := s.get(id)
+	return a.User, a.PasswordHash, err
+}
+
+func (s *BoltStore) ByID(id string) (User, error) {
+	a, err := s.get(id)
+	return a.User, err
+}
+
+func (s *BoltStore) get(id string) (account, error) {
+	var a account
+	err := s.db.Get(accountsPath, id, &a)
+	if errors.Is(err, storage.ErrNotFound) {
+		return account{}, ErrNotFound
+	}
+	return a, err
+}
+
+// AddSession also drops the user's sessions that have expired, so they don't
+// pile up from clients that never sign out.
+func (s *BoltStore) AddSession(userID, id string, expires time.Time) error {
+	var expired []string
+	now := time.Now()
+	err := s.db.Each(sessionsPath(userID), func(key string, data []byte) error {
+		var at time.Time
+		if err := json.Unmarshal(data, &at); err != nil || at.Before(now) {
+			expired = append(expired, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range expired {
+		if err := s.db.Delete(sessionsPath(userID), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return s.db.Put(sessionsPath(userID), id, expires)
+}
+
+func (s *BoltStore) EndSession(userID, id string) error {
+	err := s.db.Delete(sessionsPath(userID), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BoltStore) EndSessions(userID string) error {
+	var ids []string
+	err := s.db.Each(sessionsPath(userID), func(key string, _ []byte) error {
+		ids = append(ids, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.db.Delete(sessionsPath(userID), id); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Token types. A refresh token can only be used to get new tokens, and an
+// access token can't be used to do that.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+const issuer = "mura"
+
+type Claims struct {
+	jwt.StandardClaims
+	Type string `json:"typ"`
+}
+
+// Tokens is what a client gets on login, registration or refresh.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the access token's lifetime in seconds.
+	ExpiresIn int `json:"expires_in"`
+	// RefreshID and RefreshExpiresAt identify the refresh token, for the
+	// Store to keep as a live session.
+	RefreshID        string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
+}
+
+// Issuer signs and verifies HS256 tokens.
+type Issuer struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Now is the clock, replaceable in tests.
+	Now func() time.Time
+}
+
+func NewIssuer(secret []byte, accessTTL, refreshTTL time.Duration) *Issuer {
+	return &Issuer{Secret: secret, AccessTTL: accessTTL, RefreshTTL: refreshTTL, Now: time.Now}
+}
+
+// Issue signs a new access and refresh token for the user. The refresh token
+// carries a fresh ID so it can be revoked.
+func (i *Issuer) Issue(userID string) (Tokens, error) {
+	access, err := i.sign(userID, "", AccessToken, i.AccessTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refreshID, err := storage.NewID()
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := i.sign(userID, refreshID, RefreshToken, i.RefreshTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(i.AccessTTL.Seconds()),
+		RefreshID:        refreshID,
+		RefreshExpiresAt: i.Now().Add(i.RefreshTTL),
+	}, nil
+}
+
+func (i *Issuer) sign(userID, id, typ string, ttl time.Duration) (string, error) {
+	now := i.Now()
+	claims := Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        id,
+			Subject:   userID,
+			Issuer:    issuer,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+		Type: typ,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.Secret)
+}
+
+// Verify checks a token's signature, expiry and type and returns the user it
+// was issued to.
+func (i *Issuer) Verify(token, typ string) (string, error) {
+	claims, err := i.Parse(token, typ)
+	return claims.Subject, err
+}
+
+// Parse checks a token like Verify and returns all its claims, including the
+// ID a refresh token is revoked by.
+func (i *Issuer) Parse(token, typ string) (Claims, error) {
+	var claims Claims
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return i.Secret, nil
+	})
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	// Validated here rather than by the parser so the clock can be replaced.
+	now := i.Now().Unix()
+	if !claims.VerifyExpiresAt(now, true) || !claims.VerifyIssuer(issuer, true) || claims.Type != typ || claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	if typ == RefreshToken && claims.Id == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
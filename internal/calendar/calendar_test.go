@@ -0,0 +1,179 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	cal := Calendar{
+		Name:    "Meals",
+		Updated: time.Date(2026, 10, 19, 9, 30, 0, 0, time.FixedZone("WAT", 3600)),
+		Events: []Event{{
+			UID:         "abc@mura",
+			Summary:     "Cook dinner: Rice, beans; plantain",
+			Description: "Line one\nLine two with a backslash \\ and " + strings.Repeat("é", 60),
+			Start:       time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC),
+			End:         time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC),
+			Alarm:       true,
+		}},
+	}
+	out := cal.Encode()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTAMP:20261019T083000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20261019T180000\r\n")
+	assert.Contains(t, out, `SUMMARY:Cook dinner: Rice\, beans\; plantain`)
+	assert.Contains(t, out, `DESCRIPTION:Line one\nLine two with a backslash \\ and`)
+	assert.Contains(t, out, "BEGIN:VALARM\r\nACTION:DISPLAY\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "folding split a character: %q", line)
+	}
+	assert.NotContains(t, strings.ReplaceAll(out, "\r\n", ""), "\n")
+}
+
+func TestFold(t *testing.T) {
+	short := "SUMMARY:short"
+	assert.Equal(t, short, fold(short))
+
+	long := "DESCRIPTION:" + strings.Repeat("a", 200)
+	folded := fold(long)
+	assert.Equal(t, long, strings.ReplaceAll(folded, "\r\n ", ""))
+	for _, line := range strings.Split(folded, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
+
+func TestPreps(t *testing.T) {
+	r := &recipe.Recipe{
+		Steps: []string{
+			"Mix the yoghurt and spices. Marinate the chicken for at least 2-4 hours.",
+			"Simmer the chilli for 2 hours.",
+			"Let the dough rise for 45 minutes.",
+			"Simmer for 2 hours, then rest for 10 minutes.",
+			"Chill the dessert overnight.",
+		},
+		Ingredients: []ingredient.Item{
+			ingredient.Parse("200g dried black-eyed beans, soaked overnight"),
+			ingredient.Parse("1 tbsp chilli flakes"),
+		},
+	}
+	preps := Preps(r)
+	if !assert.Len(t, preps, 3) {
+		return
+	}
+	assert.Equal(t, Prep{Task: "Marinate the chicken for at least 2-4 hours", Lead: 4 * time.Hour}, preps[0])
+	assert.Equal(t, "Chill the dessert overnight", preps[1].Task)
+	assert.Equal(t, overnight, preps[1].Lead)
+	assert.Equal(t, overnight, preps[2].Lead)
+	assert.Contains(t, preps[2].Task, "soaked overnight")
+
+	assert.Empty(t, Preps(&recipe.Recipe{Steps: []string{"Fry the onions for 5 minutes."}}))
+	assert.Nil(t, Preps(nil))
+}
+
+func plan(id, start string, updated time.Time, dinner *recipe.Recipe) mealplan.Plan {
+	date, _ := time.Parse(time.DateOnly, start)
+	p := mealplan.Plan{ID: id, UpdatedAt: updated}
+	for i := 0; i < 2; i++ {
+		p.Days = append(p.Days, mealplan.Day{
+			Date: date.AddDate(0, 0, i).Format(time.DateOnly),
+			Meals: []mealplan.Meal{
+				{Slot: "breakfast", Recipe: &recipe.Recipe{Title: "Oats", Ingredients: []ingredient.Item{ingredient.Parse("50g oats")}}},
+				{Slot: "dinner", Recipe: dinner},
+			},
+		})
+	}
+	return p
+}
+
+func TestFromPlans(t *testing.T) {
+	suya := &recipe.Recipe{
+		Title:       "Suya",
+		PrepTime:    20,
+		CookTime:    40,
+		Ingredients: []ingredient.Item{ingredient.Parse("500g beef")},
+		Steps:       []string{"Marinate the beef overnight.", "Grill."},
+	}
+	older := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	cal := FromPlans("Meals", []mealplan.Plan{
+		plan("new", "2026-10-20", newer, suya),
+		plan("old", "2026-10-19", older, suya),
+	})
+
+	assert.Equal(t, newer, cal.Updated)
+
+	byUID := map[string]Event{}
+	for _, e := range cal.Events {
+		byUID[e.UID] = e
+	}
+	// The old plan's 20th is replaced by the new plan's.
+	assert.Contains(t, byUID, "old-2026-10-19-dinner@mura")
+	assert.NotContains(t, byUID, "old-2026-10-20-dinner@mura")
+	assert.Contains(t, byUID, "new-2026-10-21-breakfast@mura")
+
+	dinner := byUID["new-2026-10-20-dinner@mura"]
+	assert.Equal(t, "Cook dinner: Suya", dinner.Summary)
+	assert.Equal(t, time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC), dinner.Start)
+	assert.Equal(t, time.Date(2026, 10, 20, 19, 0, 0, 0, time.UTC), dinner.End)
+	assert.Contains(t, dinner.Description, "Method:\n1. Marinate the beef overnight.")
+
+	breakfast := byUID["new-2026-10-20-breakfast@mura"]
+	assert.Equal(t, time.Date(2026, 10, 20, 7, 30, 0, 0, time.UTC), breakfast.Start)
+
+	// Overnight from 18:00 would be 06:00, so it moves to the evening before.
+	prep := byUID["new-2026-10-20-dinner-prep-1@mura"]
+	assert.Equal(t, "Prep for Suya: Marinate the beef overnight", prep.Summary)
+	assert.Equal(t, time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC), prep.Start)
+	assert.True(t, prep.Alarm)
+
+	// Same plans, same feed.
+	assert.Equal(t, cal.Encode(), FromPlans("Meals", []mealplan.Plan{
+		plan("new", "2026-10-20", newer, suya),
+		plan("old", "2026-10-19", older, suya),
+	}).Encode())
+}
+
+func TestReminderTime(t *testing.T) {
+	day := func(h, m int) time.Time { return time.Date(2026, 10, 20, h, m, 0, 0, time.UTC) }
+	assert.Equal(t, day(14, 0), reminderTime(day(14, 0)))
+	assert.Equal(t, day(20, 0), reminderTime(day(23, 30)))
+	assert.Equal(t, time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC), reminderTime(day(3, 0)))
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	token, err := s.Token("ada")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	again, err := s.Token("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	user, err := s.User(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", user)
+
+	rotated, err := s.Rotate("ada")
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, rotated)
+	_, err = s.User(token)
+	assert.ErrorIs(t, err, ErrNotFound)
+	user, err = s.User(rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", user)
+}
@@ -0,0 +1,114 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event is one VEVENT. Times are floating local times: dinner planned for
+// 19:00 is at 19:00 wherever the cook is, so no time zone is written.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	// Alarm adds a reminder that goes off when the event starts.
+	Alarm bool
+}
+
+// Calendar is an RFC 5545 VCALENDAR. Updated is written as every event's
+// DTSTAMP and LAST-MODIFIED, so the same data always encodes the same way.
+type Calendar struct {
+	Name    string
+	Events  []Event
+	Updated time.Time
+}
+
+const prodID = "-//Mura//Meal Plans//EN"
+
+const (
+	floatingFormat = "20060102T150405"
+	utcFormat      = "20060102T150405Z"
+)
+
+// Encode writes the calendar as an .ics file.
+func (c Calendar) Encode() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(fold(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	stamp := c.Updated.UTC().Format(utcFormat)
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", stamp)
+		line("LAST-MODIFIED", stamp)
+		line("DTSTART", e.Start.Format(floatingFormat))
+		line("DTEND", e.End.Format(floatingFormat))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Alarm {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escape(e.Summary))
+			line("TRIGGER", "PT0M")
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// maxLine is the longest content line allowed, in octets, not counting the
+// line break.
+const maxLine = 75
+
+// fold splits a content line longer than maxLine octets into continuation
+// lines that start with a space, without splitting a UTF-8 character.
+func fold(line string) string {
+	if len(line) <= maxLine {
+		return line
+	}
+	var b strings.Builder
+	limit := maxLine
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the next line's length.
+		limit = maxLine - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
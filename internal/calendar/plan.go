@@ -0,0 +1,137 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/mealplan"
+)
+
+// mealTimes is when each slot is served, from midnight.
+var mealTimes = map[string]time.Duration{
+	"breakfast": 8 * time.Hour,
+	"lunch":     12*time.Hour + 30*time.Minute,
+	"dinner":    19 * time.Hour,
+}
+
+// defaultCookTime is used when a meal doesn't say how long it takes.
+const defaultCookTime = 30 * time.Minute
+
+// Reminders never go off at night: one that would is moved to eveningReminder
+// the evening before, which is when "marinate the night before" happens
+// anyway.
+const (
+	earliestReminder = 7 * time.Hour
+	latestReminder   = 22 * time.Hour
+	eveningReminder  = 20 * time.Hour
+	reminderLength   = 15 * time.Minute
+)
+
+// FromPlans turns meal plans into a cook schedule: one event per meal,
+// ending when it is served and starting when cooking has to begin, plus a
+// reminder for any prep that has to be started earlier. Plans are taken
+// newest first and a day already covered by a newer plan is skipped, so
+// re-planning a week replaces it rather than doubling it up.
+//
+// UIDs are derived from the plan, date and slot, so a calendar app updates a
+// swapped meal in place instead of adding a new one.
+func FromPlans(name string, plans []mealplan.Plan) Calendar {
+	cal := Calendar{Name: name, Events: []Event{}}
+	covered := map[string]bool{}
+	for _, p := range plans {
+		if p.UpdatedAt.After(cal.Updated) {
+			cal.Updated = p.UpdatedAt
+		}
+		var dates []string
+		for _, day := range p.Days {
+			if covered[day.Date] {
+				continue
+			}
+			dates = append(dates, day.Date)
+			cal.Events = append(cal.Events, dayEvents(p.ID, day)...)
+		}
+		for _, d := range dates {
+			covered[d] = true
+		}
+	}
+	return cal
+}
+
+func dayEvents(planID string, day mealplan.Day) []Event {
+	date, err := time.Parse(time.DateOnly, day.Date)
+	if err != nil {
+		return nil
+	}
+
+	var events []Event
+	for _, meal := range day.Meals {
+		if meal.Recipe == nil {
+			continue
+		}
+		r := meal.Recipe
+		served := date.Add(mealTimes[meal.Slot])
+		cooking := time.Duration(r.PrepTime+r.CookTime) * time.Minute
+		if cooking <= 0 {
+			cooking = defaultCookTime
+		}
+		start := served.Add(-cooking)
+		uid := fmt.Sprintf("%s-%s-%s", planID, day.Date, meal.Slot)
+
+		events = append(events, Event{
+			UID:         uid + "@mura",
+			Summary:     fmt.Sprintf("Cook %s: %s", meal.Slot, r.Title),
+			Description: mealDescription(meal),
+			Start:       start,
+			End:         served,
+		})
+
+		for i, prep := range Preps(r) {
+			at := reminderTime(start.Add(-prep.Lead))
+			events = append(events, Event{
+				UID:         fmt.Sprintf("%s-prep-%d@mura", uid, i+1),
+				Summary:     fmt.Sprintf("Prep for %s: %s", r.Title, prep.Task),
+				Description: fmt.Sprintf("%s.\n\nFor %s on %s.", prep.Task, meal.Slot, date.Format("Monday 2 January")),
+				Start:       at,
+				End:         at.Add(reminderLength),
+				Alarm:       true,
+			})
+		}
+	}
+	return events
+}
+
+// reminderTime moves a reminder that would fall at night to the evening
+// before.
+func reminderTime(at time.Time) time.Time {
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	switch since := at.Sub(midnight); {
+	case since < earliestReminder:
+		return midnight.AddDate(0, 0, -1).Add(eveningReminder)
+	case since >= latestReminder:
+		return midnight.Add(eveningReminder)
+	}
+	return at
+}
+
+func mealDescription(meal mealplan.Meal) string {
+	r := meal.Recipe
+	var b strings.Builder
+	if r.Description != "" {
+		b.WriteString(r.Description + "\n\n")
+	}
+	if r.Servings > 0 {
+		fmt.Fprintf(&b, "Serves %d\n\n", r.Servings)
+	}
+	b.WriteString("Ingredients:\n")
+	for _, item := range r.Ingredients {
+		b.WriteString("- " + item.String() + "\n")
+	}
+	if len(r.Steps) > 0 {
+		b.WriteString("\nMethod:\n")
+		for i, step := range r.Steps {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
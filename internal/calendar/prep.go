@@ -0,0 +1,128 @@
+package calendar
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+// Prep is something that has to be started well before cooking, like
+// marinating or soaking. Lead is how long before cooking it has to start.
+type Prep struct {
+	Task string        `json:"task"`
+	Lead time.Duration `json:"lead"`
+}
+
+// minLead is the shortest wait worth a reminder; anything shorter happens
+// while cooking.
+const minLead = time.Hour
+
+// overnight is how long "overnight" or "the night before" is taken to mean.
+const overnight = 12 * time.Hour
+
+// prepVerbs are the steps that make you wait, matched as whole words so
+// that "chilli" isn't "chill".
+var prepVerbs = []struct {
+	verb    string
+	pattern *regexp.Regexp
+}{
+	{"marinate", regexp.MustCompile(`\bmarinat(e|es|ed|ing)\b`)},
+	{"soak", regexp.MustCompile(`\bsoak(s|ed|ing)?\b`)},
+	{"brine", regexp.MustCompile(`\bbrin(e|es|ed|ing)\b`)},
+	{"chill", regexp.MustCompile(`\bchill(s|ed|ing)?\b`)},
+	{"refrigerate", regexp.MustCompile(`\brefrigerat(e|es|ed|ing)\b`)},
+	{"thaw", regexp.MustCompile(`\b(thaw|defrost)(s|ed|ing)?\b`)},
+	{"rise", regexp.MustCompile(`\b(ris(e|es|en|ing)|prov(e|es|ed|ing)|proof(s|ed|ing)?)\b`)},
+	{"rest", regexp.MustCompile(`\brest(s|ed|ing)?\b`)},
+	{"cure", regexp.MustCompile(`\bcur(e|es|ed|ing)\b`)},
+	{"freeze", regexp.MustCompile(`\bfreez(e|es|ing)\b`)},
+	{"set", regexp.MustCompile(`\bset\b`)},
+}
+
+var (
+	durationPattern  = regexp.MustCompile(`\b(\d+(?:\.\d+)?|an?|one|two|three|four|five|six|eight|twelve|twenty-four)(?:\s*(?:-|–|to|or)\s*(\d+(?:\.\d+)?))?\s*(hours?|hrs?|h|minutes?|mins?)\b`)
+	overnightPattern = regexp.MustCompile(`\b(overnight|night before|day before|day ahead|a day in advance)\b`)
+	sentencePattern  = regexp.MustCompile(`[.!?;]\s+`)
+)
+
+var durationWords = map[string]float64{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "eight": 8, "twelve": 12, "twenty-four": 24,
+}
+
+// Preps finds the steps of a recipe that need starting in advance. Planned
+// meals that haven't been expanded have no steps yet, so ingredient lines
+// such as "dried beans, soaked overnight" are read too. Each kind of prep is
+// reported once, with the longest wait found.
+func Preps(r *recipe.Recipe) []Prep {
+	if r == nil {
+		return nil
+	}
+	lines := append([]string{}, r.Steps...)
+	for _, item := range r.Ingredients {
+		lines = append(lines, item.Raw)
+	}
+
+	var preps []Prep
+	byVerb := map[string]int{}
+	for _, line := range lines {
+		for _, sentence := range sentencePattern.Split(line, -1) {
+			verb, lead := prepIn(sentence)
+			if verb == "" || lead < minLead {
+				continue
+			}
+			task := strings.TrimRight(strings.TrimSpace(sentence), ".!?;")
+			if i, ok := byVerb[verb]; ok {
+				if lead > preps[i].Lead {
+					preps[i] = Prep{Task: task, Lead: lead}
+				}
+				continue
+			}
+			byVerb[verb] = len(preps)
+			preps = append(preps, Prep{Task: task, Lead: lead})
+		}
+	}
+	return preps
+}
+
+// prepIn returns the prep verb in a sentence and how long it takes: the first
+// duration after the verb, so "simmer for 2 hours, then rest for 10 minutes"
+// is a 10 minute rest.
+func prepIn(sentence string) (string, time.Duration) {
+	s := strings.ToLower(sentence)
+	verb, at := "", -1
+	for _, v := range prepVerbs {
+		if loc := v.pattern.FindStringIndex(s); loc != nil && (at < 0 || loc[0] < at) {
+			verb, at = v.verb, loc[0]
+		}
+	}
+	if verb == "" {
+		return "", 0
+	}
+
+	if overnightPattern.MatchString(s) {
+		return verb, overnight
+	}
+	m := durationPattern.FindStringSubmatch(s[at:])
+	if m == nil {
+		return verb, 0
+	}
+	amount, ok := durationWords[m[1]]
+	if !ok {
+		amount, _ = strconv.ParseFloat(m[1], 64)
+	}
+	// Use the top of a range so there's time for the longer wait.
+	if m[2] != "" {
+		if max, err := strconv.ParseFloat(m[2], 64); err == nil {
+			amount = max
+		}
+	}
+	unit := time.Minute
+	if strings.HasPrefix(m[3], "h") {
+		unit = time.Hour
+	}
+	return verb, time.Duration(amount * float64(unit))
+}
@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"errors"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("calendar feed not found")
+
+// Store keeps the secret token each user's calendar feed is served under.
+// Calendar apps can't send headers, so the token in the URL is all that
+// identifies the user.
+type Store interface {
+	// Token returns the user's feed token, creating one the first time.
+	Token(userID string) (string, error)
+	// Rotate gives the user a new token so the old feed URL stops working.
+	Rotate(userID string) (string, error)
+	// User returns who a feed token belongs to.
+	User(token string) (string, error)
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+var (
+	tokensPath = []string{"calendar", "tokens"}
+	usersPath  = []string{"calendar", "users"}
+)
+
+func (s *BoltStore) Token(userID string) (string, error) {
+	var token string
+	err := s.db.Get(usersPath, userID, &token)
+	if errors.Is(err, storage.ErrNotFound) {
+		return s.Rotate(userID)
+	}
+	return token, err
+}
+
+func (s *BoltStore) Rotate(userID string) (string, error) {
+	var old string
+	err := s.db.Get(usersPath, userID, &old)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return "", err
+	}
+	if old != "" {
+		if err := s.db.Delete(tokensPath, old); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return "", err
+		}
+	}
+
+	token, err := storage.NewID()
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.Put(tokensPath, token, userID); err != nil {
+		return "", err
+	}
+	return token, s.db.Put(usersPath, userID, token)
+}
+
+func (s *BoltStore) User(token string) (string, error) {
+	var userID string
+	err := s.db.Get(tokensPath, token, &userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return userID, err
+}
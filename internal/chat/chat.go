@@ -0,0 +1,213 @@
+package chat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+var ErrNotFound = errors.New("chat session not found or expired")
+
+// MaxImageBytes is the largest photo a session will hold.
+const MaxImageBytes = 5 << 20
+
+const (
+	RoleUser  = "user"
+	RoleModel = "model"
+)
+
+type Message struct {
+	Role string    `json:"role"`
+	Text string    `json:"text"`
+	Time time.Time `json:"time"`
+}
+
+// Session holds what a follow-up conversation is grounded in: the recipe the
+// user got (structured, or as the markdown we showed them) and the photo it
+// came from, so neither has to be sent again. Only Owner, whoever opened
+// it, can use it.
+type Session struct {
+	ID         string         `json:"id"`
+	Owner      string         `json:"-"`
+	Recipe     *recipe.Recipe `json:"recipe,omitempty"`
+	RecipeText string         `json:"recipe_text,omitempty"`
+	Image      []byte         `json:"-"`
+	History    []Message      `json:"history"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+// Context returns the recipe the session is about as text for the model.
+func (s *Session) Context() string {
+	if s.Recipe != nil {
+		return s.Recipe.Markdown()
+	}
+	return s.RecipeText
+}
+
+// size is roughly how much memory a session takes.
+func (s *Session) size() int {
+	n := len(s.Image) + len(s.RecipeText)
+	for _, m := range s.History {
+		n += len(m.Text)
+	}
+	return n
+}
+
+// Store keeps sessions in memory. Every read or write pushes a session's
+// expiry back by the TTL, so only idle conversations are dropped. Once it
+// holds max sessions, or maxBytes of photos and text, the sessions idle the
+// longest are dropped to make room.
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	bytes    int
+	ttl      time.Duration
+	max      int
+	maxBytes int
+	now      func() time.Time
+}
+
+func NewStore(ttl time.Duration, max, maxBytes int) *Store {
+	return &Store{
+		sessions: map[string]*Session{},
+		ttl:      ttl,
+		max:      max,
+		maxBytes: maxBytes,
+		now:      time.Now,
+	}
+}
+
+// Create stores a new session and returns a copy with its ID and expiry set.
+func (s *Store) Create(session Session) (Session, error) {
+	id, err := newID()
+	if err != nil {
+		return Session{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	for s.max > 0 && len(s.sessions) >= s.max {
+		s.evict("")
+	}
+
+	now := s.now()
+	session.ID = id
+	session.History = append([]Message(nil), session.History...)
+	session.CreatedAt = now
+	session.ExpiresAt = now.Add(s.ttl)
+	s.sessions[id] = &session
+	s.bytes += session.size()
+	s.fit(id)
+	return copySession(&session), nil
+}
+
+// Get returns the owner's session; anyone else's is reported as not found.
+func (s *Store) Get(id, owner string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.live(id, owner)
+	if err != nil {
+		return Session{}, err
+	}
+	return copySession(session), nil
+}
+
+// Append adds messages to the owner's session's history.
+func (s *Store) Append(id, owner string, messages ...Message) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.live(id, owner)
+	if err != nil {
+		return Session{}, err
+	}
+	before := session.size()
+	session.History = append(session.History, messages...)
+	s.bytes += session.size() - before
+	s.fit(id)
+	return copySession(session), nil
+}
+
+// Delete drops the owner's session.
+func (s *Store) Delete(id, owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; ok && session.Owner == owner {
+		s.remove(session)
+	}
+}
+
+// live returns an unexpired session of the owner's and extends its expiry.
+// Callers must hold the lock.
+func (s *Store) live(id, owner string) (*Session, error) {
+	session, ok := s.sessions[id]
+	if !ok || session.Owner != owner {
+		return nil, ErrNotFound
+	}
+	now := s.now()
+	if now.After(session.ExpiresAt) {
+		s.remove(session)
+		return nil, ErrNotFound
+	}
+	session.ExpiresAt = now.Add(s.ttl)
+	return session, nil
+}
+
+// sweep drops expired sessions. Callers must hold the lock.
+func (s *Store) sweep() {
+	now := s.now()
+	for _, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			s.remove(session)
+		}
+	}
+}
+
+// fit drops the idlest sessions other than keep until the rest are within
+// maxBytes. Callers must hold the lock.
+func (s *Store) fit(keep string) {
+	for s.maxBytes > 0 && s.bytes > s.maxBytes && len(s.sessions) > 1 {
+		s.evict(keep)
+	}
+}
+
+// evict drops the session other than keep that has been idle the longest.
+// Callers must hold the lock.
+func (s *Store) evict(keep string) {
+	var oldest *Session
+	for _, session := range s.sessions {
+		if session.ID != keep && (oldest == nil || session.ExpiresAt.Before(oldest.ExpiresAt)) {
+			oldest = session
+		}
+	}
+	if oldest != nil {
+		s.remove(oldest)
+	}
+}
+
+// remove drops a session. Callers must hold the lock.
+func (s *Store) remove(session *Session) {
+	delete(s.sessions, session.ID)
+	s.bytes -= session.size()
+}
+
+func copySession(s *Session) Session {
+	c := *s
+	c.History = append([]Message(nil), s.History...)
+	return c
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
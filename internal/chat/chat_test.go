@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(30*time.Minute, 0, 0)
+	store.now = func() time.Time { return now }
+
+	s, err := store.Create(Session{Owner: "user:ada", RecipeText: "# Jollof Rice", Image: []byte{0xff, 0xd8}})
+	assert.NoError(t, err)
+	assert.Len(t, s.ID, 32)
+	assert.Equal(t, now.Add(30*time.Minute), s.ExpiresAt)
+
+	now = now.Add(20 * time.Minute)
+	_, err = store.Append(s.ID, "user:bola", Message{Role: RoleUser, Text: "Mine now"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	s, err = store.Append(s.ID, "user:ada",
+		Message{Role: RoleUser, Text: "Can I bake this?"},
+		Message{Role: RoleModel, Text: "Yes, at 180°C for 40 minutes."},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, s.History, 2)
+	assert.Equal(t, now.Add(30*time.Minute), s.ExpiresAt)
+
+	// Reads extend the expiry, so the session outlives its original TTL.
+	now = now.Add(25 * time.Minute)
+	got, err := store.Get(s.ID, "user:ada")
+	assert.NoError(t, err)
+	assert.Equal(t, "# Jollof Rice", got.Context())
+	assert.Len(t, got.History, 2)
+	assert.Equal(t, []byte{0xff, 0xd8}, got.Image)
+
+	_, err = store.Get(s.ID, "ip:192.0.2.1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	store.Delete(s.ID, "user:bola")
+
+	now = now.Add(31 * time.Minute)
+	_, err = store.Get(s.ID, "user:ada")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreSweepsExpiredSessions(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Minute, 0, 0)
+	store.now = func() time.Time { return now }
+
+	old, _ := store.Create(Session{RecipeText: "old"})
+	now = now.Add(2 * time.Minute)
+	_, _ = store.Create(Session{RecipeText: "new"})
+
+	assert.Len(t, store.sessions, 1)
+	_, err := store.Append(old.ID, "", Message{Role: RoleUser, Text: "hello"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	store.Delete(old.ID, "")
+}
+
+func TestStoreEvictsIdlestSession(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Hour, 2, 0)
+	store.now = func() time.Time { return now }
+
+	first, _ := store.Create(Session{RecipeText: "first"})
+	now = now.Add(time.Minute)
+	second, _ := store.Create(Session{RecipeText: "second"})
+	now = now.Add(time.Minute)
+	// Using the first session makes the second the idlest
+	_, err := store.Get(first.ID, "")
+	assert.NoError(t, err)
+
+	_, _ = store.Create(Session{RecipeText: "third"})
+	assert.Len(t, store.sessions, 2)
+	_, err = store.Get(second.ID, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get(first.ID, "")
+	assert.NoError(t, err)
+}
+
+func TestStoreBoundsBytes(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Hour, 0, 10)
+	store.now = func() time.Time { return now }
+
+	first, _ := store.Create(Session{Image: make([]byte, 6)})
+	now = now.Add(time.Minute)
+	second, _ := store.Create(Session{Image: make([]byte, 6)})
+	_, err := store.Get(first.ID, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 6, store.bytes)
+
+	// A long conversation makes room for itself the same way
+	now = now.Add(time.Minute)
+	third, _ := store.Create(Session{RecipeText: "stew"})
+	_, err = store.Append(third.ID, "", Message{Role: RoleUser, Text: "and rice?"})
+	assert.NoError(t, err)
+	_, err = store.Get(second.ID, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 13, store.bytes)
+
+	store.Delete(third.ID, "")
+	assert.Zero(t, store.bytes)
+}
@@ -0,0 +1,157 @@
+// Package corpus is a collection of recipes bundled with the server, for
+// suggesting what to cook without asking Gemini.
+package corpus
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+//go:embed recipes.json
+var bundled []byte
+
+// Corpus is a set of recipes to match ingredients against. Recipes are
+// added while the server starts; after that it's only read.
+type Corpus struct {
+	recipes []*recipe.Recipe
+}
+
+// Default returns the recipes that ship with the server.
+func Default() *Corpus {
+	recipes, err := Parse(bundled)
+	if err != nil {
+		panic("corpus: bundled recipes: " + err.Error())
+	}
+	return &Corpus{recipes: recipes}
+}
+
+// Parse reads a JSON array of recipes written in the recipe.Schema format,
+// the same shape Gemini is asked for, so a corpus can be extended with
+// recipes from anywhere that can write it.
+func Parse(data []byte) ([]*recipe.Recipe, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing recipes: %v", err)
+	}
+	recipes := make([]*recipe.Recipe, 0, len(raw))
+	for i, data := range raw {
+		r, err := recipe.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("recipe %d: %v", i+1, err)
+		}
+		recipes = append(recipes, r)
+	}
+	return recipes, nil
+}
+
+// Import adds the recipes in r, in the format Parse reads.
+func (c *Corpus) Import(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	recipes, err := Parse(data)
+	if err != nil {
+		return 0, err
+	}
+	c.recipes = append(c.recipes, recipes...)
+	return len(recipes), nil
+}
+
+func (c *Corpus) Len() int { return len(c.recipes) }
+
+// Titles lists the title of every recipe.
+func (c *Corpus) Titles() []string {
+	titles := make([]string, len(c.recipes))
+	for i, r := range c.recipes {
+		titles[i] = r.Title
+	}
+	return titles
+}
+
+// Match is a corpus recipe that uses some of the user's ingredients.
+// Coverage is the share of the user's ingredients it uses. Recipe is a copy,
+// free to change.
+type Match struct {
+	Recipe *recipe.Recipe `json:"recipe"`
+	recipe.Compliance
+	Coverage float64 `json:"coverage"`
+	Score    float64 `json:"score"`
+}
+
+// Match ranks the recipes that use at least one of the ingredients the
+// user has, best first, returning at most limit. A recipe scores by how
+// much of it the user can make with what they have, pantry staples aside,
+// and to a lesser degree by how many of their ingredients it uses up.
+func (c *Corpus) Match(have []string, limit int) []Match {
+	have = uniqueNames(have)
+	matches := []Match{}
+	if len(have) == 0 {
+		return matches
+	}
+
+	for _, r := range c.recipes {
+		check := recipe.Check(r, have)
+		if len(check.Used) == 0 {
+			continue
+		}
+		names := r.IngredientNames()
+		usedHave := 0
+		for _, h := range have {
+			for _, name := range names {
+				if ingredient.Same(h, name) {
+					usedHave++
+					break
+				}
+			}
+		}
+
+		complete := float64(len(check.Used)) / float64(len(check.Used)+len(check.Missing))
+		coverage := float64(usedHave) / float64(len(have))
+		matches = append(matches, Match{
+			Recipe:     r.Copy(),
+			Compliance: check,
+			Coverage:   round(coverage),
+			Score:      round(0.6*complete + 0.4*coverage),
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if len(matches[i].Missing) != len(matches[j].Missing) {
+			return len(matches[i].Missing) < len(matches[j].Missing)
+		}
+		return matches[i].Recipe.Title < matches[j].Recipe.Title
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// uniqueNames drops names that normalize to one already seen, and blanks.
+func uniqueNames(names []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		n := ingredient.Normalize(name)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,78 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/units"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	assert.Greater(t, c.Len(), 30)
+	assert.Contains(t, c.Titles(), "Jollof Rice")
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte(`[{"title": "Toast", "ingredients": ["1 slice bread"], "steps": ["Toast it."]}, {"title": "Nothing"}]`))
+	assert.EqualError(t, err, "recipe 2: incomplete recipe: missing title, ingredients or steps")
+
+	_, err = Parse([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	c := &Corpus{}
+	n, err := c.Import(strings.NewReader(`[{"title": "Buttered Toast", "ingredients": ["2 slices bread", "1 tbsp butter"], "steps": ["Toast the bread and butter it."]}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"Buttered Toast"}, c.Titles())
+}
+
+func titles(matches []Match) []string {
+	out := []string{}
+	for _, m := range matches {
+		out = append(out, m.Recipe.Title)
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	c := &Corpus{}
+	_, err := c.Import(strings.NewReader(`[
+		{"title": "Tomato Rice", "ingredients": ["2 cups rice", "3 tomatoes", "1 onion", "salt"], "steps": ["Cook."]},
+		{"title": "Tomato Soup", "ingredients": ["6 tomatoes", "1 onion", "2 cups stock", "1/2 cup cream"], "steps": ["Simmer and blend."]},
+		{"title": "Pancakes", "ingredients": ["1 cup flour", "1 egg", "1 cup milk"], "steps": ["Fry."]}
+	]`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Tomato rice needs nothing more, tomato soup needs stock and cream,
+	// and pancakes use none of it
+	matches := c.Match([]string{"rice", "tomatoes", "red onion", "Tomato"}, 0)
+	assert.Equal(t, []string{"Tomato Rice", "Tomato Soup"}, titles(matches))
+	assert.True(t, matches[0].Compliant)
+	assert.Equal(t, []string{"salt"}, matches[0].Staples)
+	assert.Equal(t, 1.0, matches[0].Coverage)
+	assert.Equal(t, 1.0, matches[0].Score)
+	assert.Equal(t, []string{"stock", "cream"}, matches[1].Missing)
+	assert.Equal(t, 0.667, matches[1].Coverage)
+
+	assert.Len(t, c.Match([]string{"rice", "tomato"}, 1), 1)
+	assert.Empty(t, c.Match(nil, 5))
+	assert.Empty(t, c.Match([]string{"chocolate"}, 5))
+}
+
+func TestMatchCopies(t *testing.T) {
+	c := Default()
+	m := c.Match([]string{"rice", "tomato"}, 1)
+	if !assert.Len(t, m, 1) {
+		return
+	}
+	m[0].Recipe.Localize(units.Locale{System: units.Metric})
+	m[0].Recipe.Steps[0] = "changed"
+	again := c.Match([]string{"rice", "tomato"}, 1)
+	assert.NotEqual(t, "changed", again[0].Recipe.Steps[0])
+}
@@ -0,0 +1,207 @@
+package diary
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/nutrition"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+// Meals a diary entry can be filed under.
+var Meals = []string{"breakfast", "lunch", "dinner", "snack"}
+
+var ErrInvalidMeal = errors.New("meal must be one of breakfast, lunch, dinner or snack")
+
+// Entry is one thing the user ate. Components are the per-food estimates
+// from a photo, when the entry came from one.
+type Entry struct {
+	ID         string              `json:"id"`
+	Time       time.Time           `json:"time"`
+	Meal       string              `json:"meal"`
+	Name       string              `json:"name"`
+	Grams      float64             `json:"grams,omitempty"`
+	Servings   float64             `json:"servings,omitempty"`
+	Nutrition  recipe.Nutrition    `json:"nutrition"`
+	Components []nutrition.Portion `json:"components,omitempty"`
+	Source     string              `json:"source"`
+	Note       string              `json:"note,omitempty"`
+}
+
+// Targets are the user's daily goals. A zero target is not tracked.
+type Targets recipe.Nutrition
+
+// MealFor guesses the meal from the time of day the food was eaten.
+func MealFor(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 4 && h < 11:
+		return "breakfast"
+	case h >= 11 && h < 16:
+		return "lunch"
+	case h >= 16 && h < 22:
+		return "dinner"
+	}
+	return "snack"
+}
+
+// ValidMeal normalizes a meal name, returning ErrInvalidMeal for anything
+// that isn't one of Meals.
+func ValidMeal(meal string) (string, error) {
+	meal = strings.ToLower(strings.TrimSpace(meal))
+	for _, m := range Meals {
+		if m == meal {
+			return m, nil
+		}
+	}
+	return "", ErrInvalidMeal
+}
+
+// Day is what was eaten on one calendar day and how it compares to targets.
+type Day struct {
+	Date      string            `json:"date"`
+	Entries   int               `json:"entries"`
+	Nutrition recipe.Nutrition  `json:"nutrition"`
+	Remaining *recipe.Nutrition `json:"remaining,omitempty"`
+	Progress  *recipe.Nutrition `json:"progress_percent,omitempty"`
+}
+
+// Summary is a run of days with the totals over all of them.
+type Summary struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Days    []Day            `json:"days"`
+	Total   recipe.Nutrition `json:"total"`
+	Average recipe.Nutrition `json:"daily_average"`
+}
+
+// Summarize totals the entries per calendar day in loc, for every day from
+// the day of from to the day of to inclusive, so days with nothing logged
+// show up as zero.
+func Summarize(entries []Entry, from, to time.Time, loc *time.Location, targets Targets) Summary {
+	start := dayStart(from, loc)
+	end := dayStart(to, loc)
+
+	byDate := map[string]*Day{}
+	var days []Day
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, Day{Date: d.Format(time.DateOnly)})
+	}
+	for i := range days {
+		byDate[days[i].Date] = &days[i]
+	}
+
+	s := Summary{From: start.Format(time.DateOnly), To: end.Format(time.DateOnly)}
+	for _, e := range entries {
+		day, ok := byDate[e.Time.In(loc).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		day.Entries++
+		day.Nutrition = add(day.Nutrition, e.Nutrition)
+	}
+
+	for i := range days {
+		day := &days[i]
+		s.Total = add(s.Total, day.Nutrition)
+		if targets != (Targets{}) {
+			remaining, progress := compare(day.Nutrition, recipe.Nutrition(targets))
+			day.Remaining, day.Progress = &remaining, &progress
+		}
+	}
+	if n := float64(len(days)); n > 0 {
+		s.Average = recipe.Nutrition{
+			Calories: round(s.Total.Calories / n),
+			Protein:  round(s.Total.Protein / n),
+			Carbs:    round(s.Total.Carbs / n),
+			Fat:      round(s.Total.Fat / n),
+		}
+	}
+	s.Days = days
+	return s
+}
+
+// dayStart returns midnight at the start of t's day in loc.
+func dayStart(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// DayBounds returns the start of t's day in loc and the start of the day n
+// days later, for listing entries in a range of whole days.
+func DayBounds(t time.Time, n int, loc *time.Location) (time.Time, time.Time) {
+	start := dayStart(t, loc)
+	return start, start.AddDate(0, 0, n)
+}
+
+func add(a, b recipe.Nutrition) recipe.Nutrition {
+	return recipe.Nutrition{
+		Calories: round(a.Calories + b.Calories),
+		Protein:  round(a.Protein + b.Protein),
+		Carbs:    round(a.Carbs + b.Carbs),
+		Fat:      round(a.Fat + b.Fat),
+	}
+}
+
+// compare returns how much of each target is left and what percentage of it
+// has been eaten. Untracked (zero) targets stay zero in both.
+func compare(eaten, target recipe.Nutrition) (remaining, progress recipe.Nutrition) {
+	pair := func(e, t float64) (float64, float64) {
+		if t == 0 {
+			return 0, 0
+		}
+		return round(t - e), math.Round(e / t * 100)
+	}
+	remaining.Calories, progress.Calories = pair(eaten.Calories, target.Calories)
+	remaining.Protein, progress.Protein = pair(eaten.Protein, target.Protein)
+	remaining.Carbs, progress.Carbs = pair(eaten.Carbs, target.Carbs)
+	remaining.Fat, progress.Fat = pair(eaten.Fat, target.Fat)
+	return remaining, progress
+}
+
+// WriteCSV writes entries as CSV, one row per entry, with times in loc.
+func WriteCSV(w io.Writer, entries []Entry, loc *time.Location) error {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "time", "meal", "name", "grams", "servings", "calories", "protein_g", "carbs_g", "fat_g", "source", "note"}); err != nil {
+		return err
+	}
+	num := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, e := range sorted {
+		t := e.Time.In(loc)
+		row := []string{
+			t.Format(time.DateOnly), t.Format("15:04"), e.Meal, e.Name,
+			num(e.Grams), num(e.Servings),
+			strconv.FormatFloat(e.Nutrition.Calories, 'f', -1, 64),
+			strconv.FormatFloat(e.Nutrition.Protein, 'f', -1, 64),
+			strconv.FormatFloat(e.Nutrition.Carbs, 'f', -1, 64),
+			strconv.FormatFloat(e.Nutrition.Fat, 'f', -1, 64),
+			e.Source, e.Note,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %v", err)
+	}
+	return nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
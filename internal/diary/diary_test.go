@@ -0,0 +1,105 @@
+package diary
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var lagos = time.FixedZone("WAT", 3600)
+
+func at(day, hour int) time.Time {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, lagos)
+}
+
+func TestMeal(t *testing.T) {
+	assert.Equal(t, "breakfast", MealFor(at(19, 8)))
+	assert.Equal(t, "lunch", MealFor(at(19, 13)))
+	assert.Equal(t, "dinner", MealFor(at(19, 19)))
+	assert.Equal(t, "snack", MealFor(at(19, 23)))
+
+	meal, err := ValidMeal(" Dinner ")
+	assert.NoError(t, err)
+	assert.Equal(t, "dinner", meal)
+	_, err = ValidMeal("brunch")
+	assert.ErrorIs(t, err, ErrInvalidMeal)
+}
+
+func TestSummarize(t *testing.T) {
+	entries := []Entry{
+		{Time: at(19, 8), Nutrition: recipe.Nutrition{Calories: 400, Protein: 20}},
+		{Time: at(19, 13), Nutrition: recipe.Nutrition{Calories: 700, Protein: 35, Fat: 20}},
+		// 00:30 on the 21st in Lagos is still the 20th in UTC.
+		{Time: at(21, 0).Add(30 * time.Minute), Nutrition: recipe.Nutrition{Calories: 300}},
+	}
+
+	s := Summarize(entries, at(19, 0), at(21, 0), lagos, Targets{Calories: 2000, Protein: 100})
+	if !assert.Len(t, s.Days, 3) {
+		return
+	}
+	assert.Equal(t, "2026-10-19", s.From)
+	assert.Equal(t, "2026-10-21", s.To)
+	assert.Equal(t, 2, s.Days[0].Entries)
+	assert.Equal(t, recipe.Nutrition{Calories: 1100, Protein: 55, Fat: 20}, s.Days[0].Nutrition)
+	assert.Equal(t, recipe.Nutrition{Calories: 900, Protein: 45}, *s.Days[0].Remaining)
+	assert.Equal(t, recipe.Nutrition{Calories: 55, Protein: 55}, *s.Days[0].Progress)
+	assert.Equal(t, 0, s.Days[1].Entries)
+	assert.Equal(t, 300.0, s.Days[2].Nutrition.Calories)
+	assert.Equal(t, 1400.0, s.Total.Calories)
+	assert.Equal(t, 466.7, s.Average.Calories)
+
+	s = Summarize(entries, at(20, 12), at(20, 12), time.UTC, Targets{})
+	if assert.Len(t, s.Days, 1) {
+		assert.Equal(t, 1, s.Days[0].Entries)
+		assert.Nil(t, s.Days[0].Remaining)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []Entry{
+		{Time: at(19, 13), Meal: "lunch", Name: "Jollof rice, chicken", Grams: 350, Nutrition: recipe.Nutrition{Calories: 612.5, Protein: 40}, Source: "photo"},
+		{Time: at(19, 8), Meal: "breakfast", Name: "Oats", Servings: 1, Nutrition: recipe.Nutrition{Calories: 150}, Source: "manual"},
+	}, lagos)
+	assert.NoError(t, err)
+	assert.Equal(t, "date,time,meal,name,grams,servings,calories,protein_g,carbs_g,fat_g,source,note\n"+
+		"2026-10-19,08:00,breakfast,Oats,,1,150,0,0,0,manual,\n"+
+		"2026-10-19,13:00,lunch,\"Jollof rice, chicken\",350,,612.5,40,0,0,photo,\n", buf.String())
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	lunch, err := s.Add("ada", Entry{Time: at(19, 13), Name: "lunch"})
+	assert.NoError(t, err)
+	_, err = s.Add("ada", Entry{Time: at(19, 8), Name: "breakfast"})
+	assert.NoError(t, err)
+	_, err = s.Add("ada", Entry{Time: at(20, 8), Name: "tomorrow"})
+	assert.NoError(t, err)
+	_, err = s.Add("bola", Entry{Time: at(19, 9), Name: "someone else"})
+	assert.NoError(t, err)
+
+	from, to := DayBounds(at(19, 12), 1, lagos)
+	entries, err := s.List("ada", from, to)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "breakfast", entries[0].Name)
+		assert.Equal(t, "lunch", entries[1].Name)
+	}
+
+	assert.NoError(t, s.Delete("ada", lunch.ID))
+	assert.ErrorIs(t, s.Delete("ada", lunch.ID), ErrNotFound)
+
+	targets, err := s.Targets("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, Targets{}, targets)
+	assert.NoError(t, s.SetTargets("ada", Targets{Calories: 2000}))
+	targets, err = s.Targets("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, 2000.0, targets.Calories)
+}
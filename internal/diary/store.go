@@ -0,0 +1,102 @@
+package diary
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("diary entry not found")
+
+// Store keeps each user's diary.
+type Store interface {
+	Add(userID string, e Entry) (Entry, error)
+	// List returns the entries eaten in [from, to), oldest first.
+	List(userID string, from, to time.Time) ([]Entry, error)
+	Delete(userID, id string) error
+	Targets(userID string) (Targets, error)
+	SetTargets(userID string, t Targets) error
+}
+
+// BoltStore keeps diaries in the embedded database. Entry keys start with
+// the time they were eaten so a user's bucket iterates in order.
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+func entriesPath(userID string) []string { return []string{"diary", "entries", userID} }
+func targetsPath() []string              { return []string{"diary", "targets"} }
+
+func entryKey(e Entry) string {
+	return e.Time.UTC().Format(time.RFC3339Nano) + "/" + e.ID
+}
+
+func (s *BoltStore) Add(userID string, e Entry) (Entry, error) {
+	id, err := storage.NewID()
+	if err != nil {
+		return Entry{}, err
+	}
+	e.ID = id
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if err := s.db.Put(entriesPath(userID), entryKey(e), e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+func (s *BoltStore) List(userID string, from, to time.Time) ([]Entry, error) {
+	entries := []Entry{}
+	err := s.db.Each(entriesPath(userID), func(key string, data []byte) error {
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (s *BoltStore) Delete(userID, id string) error {
+	var key string
+	err := s.db.Each(entriesPath(userID), func(k string, data []byte) error {
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		if e.ID == id {
+			key = k
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return ErrNotFound
+	}
+	return s.db.Delete(entriesPath(userID), key)
+}
+
+func (s *BoltStore) Targets(userID string) (Targets, error) {
+	var t Targets
+	err := s.db.Get(targetsPath(), userID, &t)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Targets{}, nil
+	}
+	return t, err
+}
+
+func (s *BoltStore) SetTargets(userID string, t Targets) error {
+	return s.db.Put(targetsPath(), userID, t)
+}
@@ -0,0 +1,51 @@
+// Package embed turns text into vectors whose closeness says how alike the
+// texts are, and finds the closest ones in an in-memory index.
+package embed
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder embeds texts, returning one vector per text in the same order.
+// Vectors from different models can't be compared, so Model names the one
+// in use.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Model() string
+}
+
+// Cosine returns the cosine similarity of two vectors: 1 for the same
+// direction, 0 for unrelated. Vectors of different lengths are unrelated.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// Mean averages vectors into one, such as the embeddings of several
+// dishes on one plate.
+func Mean(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	mean := make([]float32, len(vectors[0]))
+	for _, v := range vectors {
+		for i := range mean {
+			if i < len(v) {
+				mean[i] += v[i] / float32(len(vectors))
+			}
+		}
+	}
+	return mean
+}
@@ -0,0 +1,101 @@
+package embed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1, Cosine([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0, Cosine([]float32{1, 0}, []float32{0, 3}), 1e-9)
+	assert.Equal(t, 0.0, Cosine([]float32{1}, []float32{1, 2}))
+	assert.Equal(t, 0.0, Cosine([]float32{0, 0}, []float32{1, 2}))
+}
+
+func TestMean(t *testing.T) {
+	assert.Equal(t, []float32{2, 3}, Mean([][]float32{{1, 2}, {3, 4}}))
+	assert.Nil(t, Mean(nil))
+}
+
+func TestFake(t *testing.T) {
+	f := Fake{Dims: 128}
+	v, err := f.Embed(context.Background(), []string{"Egusi soup", "egusi soup", "Ogbono soup", "chocolate cake"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, v[0], 128)
+	assert.Equal(t, v[0], v[1])
+	assert.Greater(t, Cosine(v[0], v[2]), Cosine(v[0], v[3]))
+}
+
+// counting records what it was asked to embed.
+type counting struct {
+	Fake
+	asked [][]string
+}
+
+func (c *counting) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.asked = append(c.asked, texts)
+	return c.Fake.Embed(ctx, texts)
+}
+
+func TestCached(t *testing.T) {
+	inner := &counting{}
+	e := Cached(inner, 3)
+	assert.Equal(t, "fake", e.Model())
+
+	first, err := e.Embed(context.Background(), []string{"jollof rice", "fried rice"})
+	assert.NoError(t, err)
+	again, err := e.Embed(context.Background(), []string{"fried rice", "moi moi", "jollof rice"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"jollof rice", "fried rice"}, {"moi moi"}}, inner.asked)
+	assert.Equal(t, first[1], again[0])
+	assert.Equal(t, first[0], again[2])
+
+	// Full, so it starts over
+	_, err = e.Embed(context.Background(), []string{"suya"})
+	assert.NoError(t, err)
+	_, err = e.Embed(context.Background(), []string{"jollof rice"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jollof rice"}, inner.asked[3])
+}
+
+func TestBoltStore(t *testing.T) {
+	s := NewBoltStore(storagetest.Open(t))
+	assert.NoError(t, s.Put("dishes/fake", "suya", []float32{1, 0}))
+	assert.NoError(t, s.Put("cache/fake", "abc", []float32{0, 1}))
+
+	v, err := s.Get("dishes/fake", "suya")
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, 0}, v)
+	collections, err := s.Collections()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cache/fake", "dishes/fake"}, collections)
+
+	assert.NoError(t, s.Drop("cache/fake"))
+	assert.NoError(t, s.Drop("cache/fake"))
+	_, err = s.Get("cache/fake", "abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestIndex(t *testing.T) {
+	ix := NewIndex()
+	ix.Set("a", []float32{1, 0})
+	ix.Set("b", []float32{0.9, 0.1})
+	ix.Set("c", []float32{0, 1})
+	assert.Equal(t, 3, ix.Len())
+	assert.True(t, ix.Has("b"))
+
+	matches := ix.Nearest([]float32{1, 0}, 2, nil)
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, "a", matches[0].Key)
+		assert.Equal(t, "b", matches[1].Key)
+	}
+
+	matches = ix.Nearest([]float32{1, 0}, 5, func(key string) bool { return key == "a" })
+	assert.Equal(t, []string{"b", "c"}, []string{matches[0].Key, matches[1].Key})
+}
@@ -0,0 +1,68 @@
+package embed
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Fake embeds text without calling out, by hashing its words and their
+// three-letter pieces into a vector. The same text always gets the same
+// vector and texts sharing words come out close, which is enough for tests
+// and for running without an API key.
+type Fake struct {
+	Dims int
+}
+
+func (f Fake) Model() string { return "fake" }
+
+func (f Fake) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	dims := f.Dims
+	if dims <= 0 {
+		dims = 256
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, dims)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			add(v, word, 1)
+			padded := "^" + word + "$"
+			for j := 0; j+3 <= len(padded); j++ {
+				add(v, padded[j:j+3], 0.5)
+			}
+		}
+		normalize(v)
+		out[i] = v
+	}
+	return out, nil
+}
+
+// add hashes a feature to one dimension, with a sign from the hash too so
+// unrelated features tend to cancel out rather than pile up.
+func add(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(len(v))] += weight
+}
+
+func normalize(v []float32) {
+	var n float64
+	for _, x := range v {
+		n += float64(x) * float64(x)
+	}
+	if n == 0 {
+		return
+	}
+	n = math.Sqrt(n)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / n)
+	}
+}
@@ -0,0 +1,50 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Oluwaseun241/mura/internal/usage"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// maxBatch is the most texts the API embeds in one request.
+const maxBatch = 100
+
+// Gemini embeds text with one of Gemini's embedding models.
+type Gemini struct {
+	model *genai.EmbeddingModel
+}
+
+func NewGemini(client *genai.Client, model string) *Gemini {
+	em := client.EmbeddingModel(model)
+	em.TaskType = genai.TaskTypeSemanticSimilarity
+	return &Gemini{model: em}
+}
+
+func (g *Gemini) Model() string { return g.model.Name() }
+
+func (g *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatch {
+		end := min(start+maxBatch, len(texts))
+		if err := usage.Use(ctx, usage.Gemini); err != nil {
+			return nil, err
+		}
+		batch := g.model.NewBatch()
+		for _, text := range texts[start:end] {
+			batch.AddContent(genai.Text(text))
+		}
+		resp, err := g.model.BatchEmbedContents(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error embedding text: %w", err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(resp.Embeddings), end-start)
+		}
+		for _, e := range resp.Embeddings {
+			out = append(out, e.Values)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package embed
+
+import (
+	"sort"
+	"sync"
+)
+
+// Index finds the vectors closest to another by comparing against every
+// one. That's fast enough for the thousands of vectors it's meant for and
+// needs nothing set up.
+type Index struct {
+	mu      sync.RWMutex
+	vectors map[string][]float32
+}
+
+func NewIndex() *Index {
+	return &Index{vectors: map[string][]float32{}}
+}
+
+func (ix *Index) Set(key string, v []float32) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.vectors[key] = v
+}
+
+func (ix *Index) Has(key string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	_, ok := ix.vectors[key]
+	return ok
+}
+
+func (ix *Index) Get(key string) ([]float32, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	v, ok := ix.vectors[key]
+	return v, ok
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.vectors)
+}
+
+type Match struct {
+	Key   string  `json:"key"`
+	Score float64 `json:"score"`
+}
+
+// Nearest returns the k keys most similar to v, best first, skipping any
+// that skip reports true for. skip may be nil.
+func (ix *Index) Nearest(v []float32, k int, skip func(key string) bool) []Match {
+	ix.mu.RLock()
+	matches := make([]Match, 0, len(ix.vectors))
+	for key, u := range ix.vectors {
+		if skip != nil && skip(key) {
+			continue
+		}
+		matches = append(matches, Match{Key: key, Score: Cosine(v, u)})
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Key < matches[j].Key
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
@@ -0,0 +1,121 @@
+package embed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("vector not found")
+
+// Store keeps vectors in named collections, so embeddings that cost an API
+// call are only paid for once.
+type Store interface {
+	Get(collection, key string) ([]float32, error)
+	Put(collection, key string, v []float32) error
+	Each(collection string, fn func(key string, v []float32) error) error
+	// Collections lists the collections there are, and Drop deletes one.
+	Collections() ([]string, error)
+	Drop(collection string) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+func vectorsPath(collection string) []string { return []string{"embeddings", collection} }
+
+func (s *BoltStore) Get(collection, key string) ([]float32, error) {
+	var v []float32
+	err := s.db.Get(vectorsPath(collection), key, &v)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return v, err
+}
+
+func (s *BoltStore) Put(collection, key string, v []float32) error {
+	return s.db.Put(vectorsPath(collection), key, v)
+}
+
+func (s *BoltStore) Each(collection string, fn func(key string, v []float32) error) error {
+	return s.db.Each(vectorsPath(collection), func(key string, data []byte) error {
+		var v []float32
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		return fn(key, v)
+	})
+}
+
+func (s *BoltStore) Collections() ([]string, error) {
+	return s.db.Buckets([]string{"embeddings"})
+}
+
+func (s *BoltStore) Drop(collection string) error {
+	return s.db.DeleteBucket(vectorsPath(collection))
+}
+
+// cached remembers the vectors its embedder returns, by text, in memory.
+// The texts include users' own recipes, so they're never written to disk.
+type cached struct {
+	Embedder
+	max int
+
+	mu      sync.Mutex
+	vectors map[string][]float32
+}
+
+// Cached wraps e so that a text is only embedded once while up to max
+// vectors are kept; past that the cache starts over.
+func Cached(e Embedder, max int) Embedder {
+	return &cached{Embedder: e, max: max, vectors: map[string][]float32{}}
+}
+
+func (c *cached) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	var missing []string
+	var at []int
+	c.mu.Lock()
+	for i, text := range texts {
+		if v, ok := c.vectors[textKey(text)]; ok {
+			out[i] = v
+			continue
+		}
+		missing = append(missing, text)
+		at = append(at, i)
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	vectors, err := c.Embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for j, v := range vectors {
+		out[at[j]] = v
+		if len(c.vectors) >= c.max {
+			c.vectors = map[string][]float32{}
+		}
+		c.vectors[textKey(missing[j])] = v
+	}
+	return out, nil
+}
+
+func textKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
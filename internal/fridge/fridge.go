@@ -0,0 +1,98 @@
+package fridge
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("no fridge snapshot yet")
+
+// Snapshot is what was detected in the user's fridge at one point in time.
+type Snapshot struct {
+	Items   []string  `json:"items"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// Diff is how the fridge changed between two snapshots.
+type Diff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// Compare matches items across snapshots by ingredient, so "tomatoes" in one
+// photo and "Tomato" in the next are the same thing. Unchanged items are
+// named as they appear in the new snapshot.
+func Compare(before, after []string) Diff {
+	d := Diff{Added: []string{}, Removed: []string{}, Unchanged: []string{}}
+
+	before = dedupe(before)
+	matched := make([]bool, len(before))
+	for _, name := range dedupe(after) {
+		found := false
+		for i, prev := range before {
+			if !matched[i] && ingredient.Same(prev, name) {
+				matched[i], found = true, true
+			}
+		}
+		if found {
+			d.Unchanged = append(d.Unchanged, name)
+		} else {
+			d.Added = append(d.Added, name)
+		}
+	}
+	for i, prev := range before {
+		if !matched[i] {
+			d.Removed = append(d.Removed, prev)
+		}
+	}
+	return d
+}
+
+// dedupe drops repeated ingredients, keeping the first name used.
+func dedupe(names []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		key := ingredient.Normalize(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, strings.TrimSpace(name))
+	}
+	return out
+}
+
+// Store keeps each user's most recent snapshot.
+type Store interface {
+	Latest(userID string) (Snapshot, error)
+	Save(userID string, s Snapshot) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+var snapshotsPath = []string{"fridge"}
+
+func (s *BoltStore) Latest(userID string) (Snapshot, error) {
+	var snap Snapshot
+	err := s.db.Get(snapshotsPath, userID, &snap)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Snapshot{}, ErrNotFound
+	}
+	return snap, err
+}
+
+func (s *BoltStore) Save(userID string, snap Snapshot) error {
+	return s.db.Put(snapshotsPath, userID, snap)
+}
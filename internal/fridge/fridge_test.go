@@ -0,0 +1,41 @@
+package fridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	d := Compare(
+		[]string{"Tomatoes", "milk", "eggs", "spinach", "eggs"},
+		[]string{"tomato", "Eggs", "carrots", "scallions", "carrot"},
+	)
+	assert.Equal(t, []string{"carrots", "scallions"}, d.Added)
+	assert.Equal(t, []string{"milk", "spinach"}, d.Removed)
+	assert.Equal(t, []string{"tomato", "Eggs"}, d.Unchanged)
+
+	d = Compare([]string{"egg", "eggs", "milk"}, []string{"milk"})
+	assert.Equal(t, []string{"egg"}, d.Removed)
+
+	d = Compare(nil, []string{"butter"})
+	assert.Equal(t, []string{"butter"}, d.Added)
+	assert.Empty(t, d.Removed)
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	_, err := s.Latest("ada")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	taken := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.Save("ada", Snapshot{Items: []string{"milk"}, TakenAt: taken}))
+	snap, err := s.Latest("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"milk"}, snap.Items)
+	assert.True(t, snap.TakenAt.Equal(taken))
+}
@@ -0,0 +1,94 @@
+// Package history records the recipes a user has generated so they can be
+// looked at again without another call to Gemini, or generated again from
+// the same inputs to compare.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The pipelines a history entry can come from.
+const (
+	DetectFood = "detect-food"
+	Recipe     = "recipe"
+)
+
+// Input is what a pipeline was run with, enough to run it again. Photos are
+// kept apart from the entry, see Store.Images.
+type Input struct {
+	// /detect-food
+	Images     int    `json:"images,omitempty"`
+	ImageBytes int    `json:"image_bytes,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	Expand     string `json:"expand,omitempty"`
+	MaxDishes  int    `json:"max_dishes,omitempty"`
+	Portions   bool   `json:"portions,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+
+	// /recipe; Ingredients includes anything taken from the pantry
+	Ingredients []string `json:"ingredients,omitempty"`
+	Dish        string   `json:"dish,omitempty"`
+	Strict      bool     `json:"strict,omitempty"`
+
+	Units string `json:"units,omitempty"`
+}
+
+// Summary describes the input in a line for listing.
+func (in Input) Summary(kind string) string {
+	switch kind {
+	case DetectFood:
+		photos := "1 photo"
+		if in.Images != 1 {
+			photos = fmt.Sprintf("%d photos", in.Images)
+		}
+		return fmt.Sprintf("%s (%s)", photos, size(in.ImageBytes))
+	case Recipe:
+		s := "Recipe"
+		if in.Dish != "" {
+			s = in.Dish
+		}
+		if len(in.Ingredients) == 0 {
+			return s
+		}
+		list := in.Ingredients
+		more := ""
+		if len(list) > 5 {
+			list, more = list[:5], fmt.Sprintf(" and %d more", len(in.Ingredients)-5)
+		}
+		return fmt.Sprintf("%s from %s%s", s, strings.Join(list, ", "), more)
+	}
+	return kind
+}
+
+func size(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// Entry is one run of a pipeline. Result is the response the user got, kept
+// as it was sent.
+type Entry struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	Input   Input  `json:"input"`
+	// Type is how the photo was classified, for /detect-food
+	Type   string          `json:"type,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// LatencyMS is how long the pipeline took, in milliseconds
+	LatencyMS     int64  `json:"latency_ms"`
+	Model         string `json:"model"`
+	PromptVersion string `json:"prompt_version"`
+	// RegeneratedFrom is the entry whose inputs this one was run again with
+	RegeneratedFrom string    `json:"regenerated_from,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
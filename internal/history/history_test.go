@@ -0,0 +1,110 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, "1 photo (340 KB)", Input{Images: 1, ImageBytes: 340 << 10}.Summary(DetectFood))
+	assert.Equal(t, "3 photos (2.5 MB)", Input{Images: 3, ImageBytes: 5 << 19}.Summary(DetectFood))
+	assert.Equal(t, "jollof from rice, tomato", Input{Dish: "jollof", Ingredients: []string{"rice", "tomato"}}.Summary(Recipe))
+	assert.Equal(t, "Recipe from a, b, c, d, e and 2 more", Input{Ingredients: []string{"a", "b", "c", "d", "e", "f", "g"}}.Summary(Recipe))
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	photo := &Entry{Kind: DetectFood, Type: "cooked food", Result: json.RawMessage(`{"data":"# Jollof"}`), CreatedAt: now}
+	text := &Entry{Kind: Recipe, Input: Input{Ingredients: []string{"rice"}}, CreatedAt: now.Add(time.Minute)}
+	assert.NoError(t, s.Add("ada", photo, [][]byte{[]byte("jpeg"), []byte("side")}))
+	assert.NoError(t, s.Add("ada", text, nil))
+	assert.NotEmpty(t, photo.ID)
+
+	page, next, err := s.List("ada", "", 1)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, text.ID, page[0].ID)
+	}
+	assert.NotEmpty(t, next)
+	page, next, err = s.List("ada", next, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, photo.ID, page[0].ID)
+		assert.Nil(t, page[0].Result)
+	}
+	assert.Empty(t, next)
+	total, err := s.Count("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	got, err := s.Get("ada", photo.ID)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data":"# Jollof"}`, string(got.Result))
+
+	images, err := s.Images("ada", photo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("jpeg"), []byte("side")}, images)
+
+	_, err = s.Images("ada", text.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get("bola", photo.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestImagesExpire(t *testing.T) {
+	s := NewBoltStore(storagetest.Open(t))
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	var entries []*Entry
+	for i := 0; i <= KeepImages; i++ {
+		e := &Entry{Kind: DetectFood, CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		assert.NoError(t, s.Add("ada", e, [][]byte{[]byte("jpeg")}))
+		entries = append(entries, e)
+	}
+	_, err := s.Images("ada", entries[0].ID)
+	assert.ErrorIs(t, err, ErrNoImages)
+	_, err = s.Images("ada", entries[1].ID)
+	assert.NoError(t, err)
+
+	big := &Entry{Kind: DetectFood, CreatedAt: now.Add(time.Hour)}
+	assert.NoError(t, s.Add("ada", big, [][]byte{make([]byte, MaxImageBytes+1)}))
+	_, err = s.Images("ada", big.ID)
+	assert.ErrorIs(t, err, ErrNoImages)
+}
+
+func TestUpgrade(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// Stored before entries were listed by time
+	old := []*Entry{{ID: "b", CreatedAt: now}, {ID: "a", CreatedAt: now.Add(time.Minute)}}
+	for _, e := range old {
+		assert.NoError(t, db.Put(entriesPath("ada"), e.ID, e))
+	}
+	assert.NoError(t, db.Put(legacyImagesPath("ada"), "b", [][]byte{[]byte("jpeg")}))
+
+	images, err := s.Images("ada", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("jpeg")}, images)
+
+	page, _, err := s.List("ada", "", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, "a", page[0].ID)
+		assert.Equal(t, "b", page[1].ID)
+	}
+	images, err = s.Images("ada", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("jpeg")}, images)
+	n, err := db.Count(legacyImagesPath("ada"))
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
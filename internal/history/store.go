@@ -0,0 +1,265 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("history entry not found")
+
+// ErrNoImages is returned for an entry whose photos weren't kept, either
+// because they were too big or because newer entries pushed them out.
+var ErrNoImages = fmt.Errorf("%w: its photos are no longer kept", ErrNotFound)
+
+const (
+	// MaxImageBytes is the most photo data kept for one entry. Bigger
+	// uploads are recorded without their photos.
+	MaxImageBytes = 10 << 20
+	// KeepImages is how many of a user's entries keep their photos. Older
+	// entries stay in the history but can't be regenerated.
+	KeepImages = 20
+)
+
+// Store keeps each user's history. Photos are stored apart from entries so
+// listing doesn't read them.
+type Store interface {
+	// Add stores a new entry, giving it an ID, along with the photos it was
+	// made from, if any.
+	Add(userID string, e *Entry, images [][]byte) error
+	// List returns up to limit of the user's entries, newest first, without
+	// their results. It starts after cursor, which is empty for the first
+	// page, and returns the cursor for the next page, empty if there isn't
+	// one.
+	List(userID, cursor string, limit int) ([]Entry, string, error)
+	// Count returns how many entries the user has.
+	Count(userID string) (int, error)
+	// Each calls fn with every entry the user has, results included, in no
+	// particular order.
+	Each(userID string, fn func(e *Entry) error) error
+	Get(userID, id string) (*Entry, error)
+	Images(userID, id string) ([][]byte, error)
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+// Entries are kept whole by ID, and again without their results under a key
+// that sorts by time for listing. Photos are kept as they are under the
+// entry's list key, one per key.
+func entriesPath(userID string) []string { return []string{"history", "entries", userID} }
+func listPath(userID string) []string    { return []string{"history", "list", userID} }
+func photosPath(userID string) []string  { return []string{"history", "photos", userID} }
+
+// legacyImagesPath held each entry's photos as a JSON list. They're moved
+// under photosPath the next time the user's history is listed.
+func legacyImagesPath(userID string) []string { return []string{"history", "images", userID} }
+
+func listKey(e *Entry) string {
+	return e.CreatedAt.UTC().Format("20060102150405.000000000") + "-" + e.ID
+}
+
+func (s *BoltStore) Add(userID string, e *Entry, images [][]byte) error {
+	id, err := storage.NewID()
+	if err != nil {
+		return err
+	}
+	e.ID = id
+	if err := s.db.Put(entriesPath(userID), id, e); err != nil {
+		return err
+	}
+	if err := s.db.Put(listPath(userID), listKey(e), listed(*e)); err != nil {
+		return err
+	}
+	size := 0
+	for _, img := range images {
+		size += len(img)
+	}
+	if len(images) == 0 || size > MaxImageBytes {
+		return nil
+	}
+	if err := s.putImages(userID, e, images); err != nil {
+		return err
+	}
+	return s.expireImages(userID)
+}
+
+// listed is an entry as it's listed, without its result.
+func listed(e Entry) Entry {
+	e.Result = nil
+	return e
+}
+
+func (s *BoltStore) putImages(userID string, e *Entry, images [][]byte) error {
+	for i, img := range images {
+		if err := s.db.PutRaw(photosPath(userID), listKey(e)+fmt.Sprintf("/%03d", i), img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireImages drops the photos of all but the newest KeepImages entries
+// that have them.
+func (s *BoltStore) expireImages(userID string) error {
+	var kept, expired []string
+	err := s.db.Reverse(photosPath(userID), "", func(key string, _ []byte) (bool, error) {
+		entry := key[:strings.LastIndex(key, "/")+1]
+		switch {
+		case len(kept) > 0 && kept[len(kept)-1] == entry,
+			len(expired) > 0 && expired[len(expired)-1] == entry:
+		case len(kept) < KeepImages:
+			kept = append(kept, entry)
+		default:
+			expired = append(expired, entry)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range expired {
+		if err := s.db.DeletePrefix(photosPath(userID), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) List(userID, cursor string, limit int) ([]Entry, string, error) {
+	if err := s.upgrade(userID); err != nil {
+		return nil, "", err
+	}
+	entries := []Entry{}
+	if limit < 1 {
+		return entries, "", nil
+	}
+	next := ""
+	err := s.db.Reverse(listPath(userID), cursor, func(key string, data []byte) (bool, error) {
+		if len(entries) == limit {
+			next = listKey(&entries[len(entries)-1])
+			return false, nil
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return false, err
+		}
+		entries = append(entries, e)
+		return true, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, next, nil
+}
+
+// upgrade lists entries stored before the list was kept and moves their
+// photos out of JSON.
+func (s *BoltStore) upgrade(userID string) error {
+	stored, err := s.db.Count(entriesPath(userID))
+	if err != nil {
+		return err
+	}
+	listedN, err := s.db.Count(listPath(userID))
+	if err != nil || listedN >= stored {
+		return err
+	}
+
+	// Writes wait until each read is done; bolt can't write inside a read
+	entries := map[string]*Entry{}
+	err = s.Each(userID, func(e *Entry) error {
+		entries[e.ID] = e
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := s.db.Put(listPath(userID), listKey(e), listed(*e)); err != nil {
+			return err
+		}
+	}
+
+	var legacy []string
+	err = s.db.Each(legacyImagesPath(userID), func(id string, _ []byte) error {
+		legacy = append(legacy, id)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range legacy {
+		var images [][]byte
+		if err := s.db.Get(legacyImagesPath(userID), id, &images); err != nil {
+			return err
+		}
+		if e, ok := entries[id]; ok {
+			if err := s.putImages(userID, e, images); err != nil {
+				return err
+			}
+		}
+		if err := s.db.Delete(legacyImagesPath(userID), id); err != nil {
+			return err
+		}
+	}
+	return s.expireImages(userID)
+}
+
+func (s *BoltStore) Count(userID string) (int, error) {
+	if err := s.upgrade(userID); err != nil {
+		return 0, err
+	}
+	return s.db.Count(listPath(userID))
+}
+
+func (s *BoltStore) Each(userID string, fn func(e *Entry) error) error {
+	return s.db.Each(entriesPath(userID), func(key string, data []byte) error {
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		return fn(&e)
+	})
+}
+
+func (s *BoltStore) Get(userID, id string) (*Entry, error) {
+	var e Entry
+	err := s.db.Get(entriesPath(userID), id, &e)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *BoltStore) Images(userID, id string) ([][]byte, error) {
+	e, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	var images [][]byte
+	err = s.db.Prefix(photosPath(userID), listKey(e)+"/", func(key string, data []byte) error {
+		images = append(images, append([]byte(nil), data...))
+		return nil
+	})
+	if err != nil || len(images) > 0 {
+		return images, err
+	}
+
+	// Stored as JSON and not moved yet
+	err = s.db.Get(legacyImagesPath(userID), id, &images)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNoImages
+	}
+	return images, err
+}
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// MaxPageSize caps how much of a page we read; recipe pages are big but
+// not this big.
+const MaxPageSize = 5 << 20
+
+var (
+	ErrBadURL         = errors.New("url must be an absolute http or https address")
+	ErrPrivateAddress = errors.New("url points at a private address")
+	ErrNotHTML        = errors.New("url is not an html page")
+	ErrTooLarge       = errors.New("page is too large")
+)
+
+// Fetcher downloads recipe pages. It refuses to connect to any address
+// outside the public internet, checked after DNS and on every redirect, so
+// users can't point it at our own network.
+type Fetcher struct {
+	client *http.Client
+}
+
+// NewFetcher returns a Fetcher with the given timeout. allowPrivate turns
+// off the address check, for tests against a local server.
+func NewFetcher(timeout time.Duration, allowPrivate bool) *Fetcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = publicOnly
+	}
+	transport := &http.Transport{
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Fetcher{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrBadURL
+			}
+			return nil
+		},
+	}}
+}
+
+// reservedNets are every range that isn't the public internet: private,
+// loopback, link-local, shared (carrier-grade NAT), documentation,
+// benchmarking, multicast and reserved space, and the IPv6 ranges that wrap
+// an IPv4 address (NAT64, 6to4, Teredo, IPv4-compatible), any of which
+// could lead back inside. IPv4-mapped addresses are unwrapped before
+// they're checked.
+var reservedNets = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"), // includes 255.255.255.255
+
+	netip.MustParsePrefix("::/96"), // unspecified, loopback, IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return ErrPrivateAddress
+	}
+	ip = ip.Unmap()
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// ParseURL checks a page URL is one we'd fetch.
+func ParseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrBadURL
+	}
+	return u, nil
+}
+
+// Fetch downloads a page. The returned URL is where any redirects ended,
+// for resolving relative links.
+func (f *Fetcher) Fetch(ctx context.Context, raw string) ([]byte, *url.URL, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; mura-recipe-import/1.0)")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrPrivateAddress) {
+			return nil, nil, ErrPrivateAddress
+		}
+		return nil, nil, fmt.Errorf("error fetching page: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("error fetching page: %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return nil, nil, ErrNotHTML
+		}
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, MaxPageSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading page: %v", err)
+	}
+	if len(page) > MaxPageSize {
+		return nil, nil, ErrTooLarge
+	}
+	return page, resp.Request.URL, nil
+}
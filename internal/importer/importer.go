@@ -0,0 +1,425 @@
+// Package importer reads recipes out of web pages marked up with the
+// schema.org Recipe type, as JSON-LD or as microdata, which is how most
+// recipe sites describe their recipes to search engines.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	nethtml "golang.org/x/net/html"
+)
+
+var (
+	ErrNoRecipe   = errors.New("no schema.org recipe found on the page")
+	ErrIncomplete = errors.New("recipe on the page is missing a title, ingredients or instructions")
+)
+
+// Imported is a recipe read from a page, with what our Recipe type has no
+// place for.
+type Imported struct {
+	Recipe     *recipe.Recipe
+	ImageURL   string
+	Categories []string
+}
+
+// Extract finds the recipe in a page. JSON-LD is preferred, as it's usually
+// more complete than the microdata on the same page. base resolves relative
+// image links and may be nil.
+func Extract(page []byte, base *url.URL) (*Imported, error) {
+	doc, err := nethtml.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing page: %v", err)
+	}
+
+	node := jsonLDRecipe(doc)
+	if node == nil {
+		node = microdataRecipe(doc)
+	}
+	if node == nil {
+		return nil, ErrNoRecipe
+	}
+	return convert(node, base)
+}
+
+// jsonLDRecipe returns the first Recipe in the page's JSON-LD scripts.
+func jsonLDRecipe(doc *nethtml.Node) map[string]interface{} {
+	var found map[string]interface{}
+	walk(doc, func(n *nethtml.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.Type == nethtml.ElementNode && n.Data == "script" && strings.EqualFold(attr(n, "type"), "application/ld+json") {
+			var v interface{}
+			if json.Unmarshal([]byte(textOf(n, false)), &v) == nil {
+				found = findRecipe(v)
+			}
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// findRecipe looks for a Recipe node in parsed JSON-LD, which may be one
+// node, a list of them or a @graph.
+func findRecipe(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if r := findRecipe(item); r != nil {
+				return r
+			}
+		}
+	case map[string]interface{}:
+		if isRecipe(v["@type"]) {
+			return v
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findRecipe(graph)
+		}
+		// Some sites wrap the recipe in the WebPage it's on
+		if main, ok := v["mainEntity"]; ok {
+			return findRecipe(main)
+		}
+	}
+	return nil
+}
+
+func isRecipe(t interface{}) bool {
+	for _, s := range texts(t) {
+		if s == "Recipe" || strings.HasSuffix(s, "schema.org/Recipe") {
+			return true
+		}
+	}
+	return false
+}
+
+// microdataRecipe reads the first element with itemtype schema.org/Recipe
+// into the same shape as JSON-LD.
+func microdataRecipe(doc *nethtml.Node) map[string]interface{} {
+	var found map[string]interface{}
+	walk(doc, func(n *nethtml.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.Type == nethtml.ElementNode && hasAttr(n, "itemscope") && isRecipe(strings.Fields(attr(n, "itemtype"))) {
+			found = microdataItem(n)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// microdataItem collects the itemprops of an itemscope element. Nested
+// items become nested maps and keep their own properties.
+func microdataItem(item *nethtml.Node) map[string]interface{} {
+	props := map[string][]interface{}{}
+	for c := item.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, func(n *nethtml.Node) bool {
+			if n.Type != nethtml.ElementNode {
+				return true
+			}
+			name := attr(n, "itemprop")
+			nested := hasAttr(n, "itemscope")
+			if name != "" {
+				var v interface{}
+				if nested {
+					v = microdataItem(n)
+				} else {
+					v = microdataValue(n)
+				}
+				for _, prop := range strings.Fields(name) {
+					props[prop] = append(props[prop], v)
+				}
+			}
+			return !nested
+		})
+	}
+
+	out := map[string]interface{}{"@type": strings.Fields(attr(item, "itemtype"))}
+	for name, values := range props {
+		if len(values) == 1 {
+			out[name] = values[0]
+		} else {
+			out[name] = values
+		}
+	}
+	return out
+}
+
+func microdataValue(n *nethtml.Node) string {
+	if hasAttr(n, "content") {
+		return attr(n, "content")
+	}
+	switch n.Data {
+	case "img", "audio", "video", "source":
+		return attr(n, "src")
+	case "a", "link":
+		return attr(n, "href")
+	case "time":
+		if hasAttr(n, "datetime") {
+			return attr(n, "datetime")
+		}
+	case "meta":
+		return attr(n, "content")
+	}
+	return textOf(n, true)
+}
+
+// convert turns a schema.org Recipe node into our Recipe.
+func convert(node map[string]interface{}, base *url.URL) (*Imported, error) {
+	r := &recipe.Recipe{
+		Title:       clean(first(node["name"])),
+		Description: clean(first(node["description"])),
+		Cuisine:     strings.Join(cleanAll(texts(node["recipeCuisine"])), ", "),
+		Servings:    leadingInt(texts(node["recipeYield"])),
+		Equipment:   cleanAll(texts(node["tool"])),
+		Ingredients: []ingredient.Item{},
+	}
+
+	prep, cook, total := minutes(node["prepTime"]), minutes(node["cookTime"]), minutes(node["totalTime"])
+	if cook == 0 && total > prep {
+		cook = total - prep
+	}
+	r.PrepTime, r.CookTime = prep, cook
+
+	lines := texts(node["recipeIngredient"])
+	if len(lines) == 0 {
+		// The older name for recipeIngredient
+		lines = texts(node["ingredients"])
+	}
+	for _, line := range cleanAll(lines) {
+		r.Ingredients = append(r.Ingredients, ingredient.Parse(line))
+	}
+	r.Steps = instructions(node["recipeInstructions"])
+
+	if n, ok := node["nutrition"].(map[string]interface{}); ok {
+		r.Nutrition = recipe.Nutrition{
+			Calories: number(first(n["calories"])),
+			Protein:  number(first(n["proteinContent"])),
+			Carbs:    number(first(n["carbohydrateContent"])),
+			Fat:      number(first(n["fatContent"])),
+		}
+	}
+
+	if r.Title == "" || len(r.Ingredients) == 0 || len(r.Steps) == 0 {
+		return nil, ErrIncomplete
+	}
+	return &Imported{
+		Recipe:     r,
+		ImageURL:   imageURL(node["image"], base),
+		Categories: cleanAll(texts(node["recipeCategory"])),
+	}, nil
+}
+
+// instructions flattens recipeInstructions, which may be text, a list of
+// texts, HowToSteps, or HowToSections of HowToSteps.
+func instructions(v interface{}) []string {
+	var steps []string
+	switch v := v.(type) {
+	case string:
+		// One block of text, sometimes with markup in it
+		for _, line := range strings.Split(tags.ReplaceAllString(v, "\n"), "\n") {
+			if line = clean(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			steps = append(steps, instructions(item)...)
+		}
+	case map[string]interface{}:
+		if list, ok := v["itemListElement"]; ok {
+			return instructions(list)
+		}
+		if text := first(v["text"]); text != "" {
+			return instructions(text)
+		}
+		return instructions(first(v["name"]))
+	}
+	return steps
+}
+
+// imageURL picks the first image, which may be a URL, an ImageObject or a
+// list of either.
+func imageURL(v interface{}, base *url.URL) string {
+	var raw string
+	switch v := v.(type) {
+	case string:
+		raw = v
+	case []interface{}:
+		if len(v) > 0 {
+			return imageURL(v[0], base)
+		}
+	case map[string]interface{}:
+		raw = first(v["url"])
+		if raw == "" {
+			raw = first(v["contentUrl"])
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// texts flattens a JSON-LD value into strings. Nodes stand for their name
+// or text.
+func texts(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, texts(item)...)
+		}
+		return out
+	case []string:
+		return v
+	case map[string]interface{}:
+		if name := first(v["name"]); name != "" {
+			return []string{name}
+		}
+		if text := first(v["text"]); text != "" {
+			return []string{text}
+		}
+	}
+	return nil
+}
+
+func first(v interface{}) string {
+	if t := texts(v); len(t) > 0 {
+		return t[0]
+	}
+	return ""
+}
+
+var (
+	tags      = regexp.MustCompile(`<[^>]*>`)
+	spaces    = regexp.MustCompile(`\s+`)
+	firstNum  = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	isoPeriod = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:\d+(?:\.\d+)?S)?)?$`)
+)
+
+// clean strips markup and entities, which some sites leave in their
+// JSON-LD, and tidies the spacing.
+func clean(s string) string {
+	s = html.UnescapeString(tags.ReplaceAllString(s, " "))
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+func cleanAll(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s = clean(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// minutes reads an ISO 8601 duration such as PT1H30M.
+func minutes(v interface{}) int {
+	m := isoPeriod.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(first(v))))
+	if m == nil {
+		return 0
+	}
+	days, _ := strconv.Atoi(m[1])
+	hours, _ := strconv.Atoi(m[2])
+	mins, _ := strconv.Atoi(m[3])
+	return days*24*60 + hours*60 + mins
+}
+
+// leadingInt finds the first whole number in any of the texts, as in
+// recipeYield values like "4 servings".
+func leadingInt(list []string) int {
+	for _, s := range list {
+		if m := firstNum.FindString(s); m != "" {
+			n, _ := strconv.ParseFloat(m, 64)
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// number reads the amount from a value such as "240 kcal" or "12 g".
+func number(s string) float64 {
+	n, _ := strconv.ParseFloat(firstNum.FindString(strings.ReplaceAll(s, ",", "")), 64)
+	return n
+}
+
+// walk visits n and its descendants in document order, skipping the
+// children of any node fn returns false for.
+func walk(n *nethtml.Node, fn func(*nethtml.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func attr(n *nethtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *nethtml.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// blocks are elements that start a new line of text.
+var blocks = map[string]bool{
+	"p": true, "li": true, "br": true, "div": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// textOf returns the text inside n. With lines set, block elements are
+// put on lines of their own.
+func textOf(n *nethtml.Node, lines bool) string {
+	var b strings.Builder
+	walk(n, func(c *nethtml.Node) bool {
+		if c.Type == nethtml.TextNode {
+			b.WriteString(c.Data)
+		}
+		if lines && c.Type == nethtml.ElementNode && blocks[c.Data] {
+			b.WriteString("\n")
+		}
+		return true
+	})
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func names(imp *Imported) []string {
+	var out []string
+	for _, item := range imp.Recipe.Ingredients {
+		out = append(out, item.Name)
+	}
+	return out
+}
+
+func TestExtractJSONLDGraph(t *testing.T) {
+	base, _ := url.Parse("https://example.com/jollof/")
+	imp, err := Extract(fixture(t, "jsonld_graph.html"), base)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := imp.Recipe
+	assert.Equal(t, "Party Jollof Rice", r.Title)
+	assert.Equal(t, "Smoky & rich one-pot rice.", r.Description)
+	assert.Equal(t, "Nigerian", r.Cuisine)
+	assert.Equal(t, 6, r.Servings)
+	assert.Equal(t, 20, r.PrepTime)
+	assert.Equal(t, 70, r.CookTime)
+	assert.Equal(t, "https://example.com/images/jollof.jpg", imp.ImageURL)
+	assert.Equal(t, []string{"Main Course"}, imp.Categories)
+
+	assert.Len(t, r.Ingredients, 5)
+	assert.Equal(t, 3.0, r.Ingredients[0].Quantity)
+	assert.Equal(t, "4 tomatoes", r.Ingredients[1].Raw)
+	assert.Equal(t, 0.5, r.Ingredients[3].Quantity)
+	assert.Equal(t, []string{
+		"Blend the tomatoes.",
+		"Fry the paste in the oil.",
+		"Add the rice and stock, cover and steam.",
+	}, r.Steps)
+	assert.Equal(t, 540.0, r.Nutrition.Calories)
+	assert.Equal(t, 9.0, r.Nutrition.Protein)
+	assert.Equal(t, 18.0, r.Nutrition.Fat)
+}
+
+func TestExtractJSONLDList(t *testing.T) {
+	imp, err := Extract(fixture(t, "jsonld_list.html"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := imp.Recipe
+	assert.Equal(t, "Egg Fried Rice", r.Title)
+	assert.Equal(t, 2, r.Servings)
+	assert.Equal(t, 15, r.CookTime)
+	assert.Equal(t, []string{"Wok"}, r.Equipment)
+	assert.Equal(t, "https://cdn.example.com/rice.jpg", imp.ImageURL)
+	assert.Equal(t, []string{"Scramble the eggs.", "Add the rice and soy sauce."}, r.Steps)
+	assert.Equal(t, 1020.0, r.Nutrition.Calories)
+	assert.Contains(t, names(imp), "soy sauce")
+}
+
+func TestExtractMicrodata(t *testing.T) {
+	base, _ := url.Parse("https://example.com/recipes/pancakes")
+	imp, err := Extract(fixture(t, "microdata.html"), base)
+	if !assert.NoError(t, err) {
+		return
+	}
+	r := imp.Recipe
+	assert.Equal(t, "Pancakes", r.Title)
+	assert.Equal(t, "Fluffy weekend pancakes.", r.Description)
+	assert.Equal(t, 4, r.Servings)
+	assert.Equal(t, 10, r.PrepTime)
+	assert.Equal(t, 20, r.CookTime)
+	assert.Equal(t, 320.0, r.Nutrition.Calories)
+	assert.Equal(t, "https://example.com/recipes/pancakes.jpg", imp.ImageURL)
+	assert.Equal(t, []string{"flour", "egg", "milk"}, names(imp))
+	assert.Equal(t, []string{"Whisk everything together.", "Fry ladlefuls in a hot pan."}, r.Steps)
+}
+
+func TestExtractNoRecipe(t *testing.T) {
+	_, err := Extract(fixture(t, "none.html"), nil)
+	assert.ErrorIs(t, err, ErrNoRecipe)
+
+	_, err = Extract([]byte(`<script type="application/ld+json">{"@type": "Recipe", "name": "Toast"}</script>`), nil)
+	assert.ErrorIs(t, err, ErrIncomplete)
+}
+
+func TestMinutes(t *testing.T) {
+	for in, want := range map[string]int{
+		"PT45M":     45,
+		"PT1H":      60,
+		"PT1H30M":   90,
+		"P1DT2H":    1560,
+		"pt10m":     10,
+		"PT0H5M30S": 5,
+		"45 mins":   0,
+		"":          0,
+	} {
+		assert.Equal(t, want, minutes(in), in)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	page := fixture(t, "jsonld_graph.html")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jollof":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(page)
+		case "/old":
+			http.Redirect(w, r, "/jollof", http.StatusMovedPermanently)
+		case "/photo":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte{0xff, 0xd8})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(5*time.Second, true)
+	ctx := context.Background()
+
+	body, final, err := f.Fetch(ctx, srv.URL+"/old")
+	if assert.NoError(t, err) {
+		assert.Equal(t, page, body)
+		assert.Equal(t, "/jollof", final.Path)
+		imp, err := Extract(body, final)
+		if assert.NoError(t, err) {
+			assert.Equal(t, srv.URL+"/images/jollof.jpg", imp.ImageURL)
+		}
+	}
+
+	_, _, err = f.Fetch(ctx, srv.URL+"/photo")
+	assert.ErrorIs(t, err, ErrNotHTML)
+
+	_, _, err = f.Fetch(ctx, srv.URL+"/missing")
+	assert.ErrorContains(t, err, "404")
+
+	_, _, err = f.Fetch(ctx, "ftp://example.com/recipe")
+	assert.ErrorIs(t, err, ErrBadURL)
+
+	// Without allowPrivate the test server is off limits
+	_, _, err = NewFetcher(5*time.Second, false).Fetch(ctx, srv.URL+"/jollof")
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+}
+
+func TestPublicOnly(t *testing.T) {
+	for _, addr := range []string{
+		"0.0.0.0:80", "10.1.2.3:80", "100.64.0.1:80", "100.127.255.254:80",
+		"127.0.0.1:80", "169.254.169.254:80", "172.16.0.1:80", "192.0.0.8:80",
+		"192.0.2.1:80", "192.88.99.1:80", "192.168.0.1:443", "198.18.0.1:80",
+		"198.19.255.1:80", "198.51.100.1:80", "203.0.113.1:80", "224.0.0.1:80",
+		"240.0.0.1:80", "255.255.255.255:80",
+		"[::]:80", "[::1]:80", "[::7f00:1]:80", "[::ffff:127.0.0.1]:80",
+		"[::ffff:10.0.0.1]:80", "[::ffff:a9fe:a9fe]:80", "[64:ff9b::a9fe:a9fe]:80",
+		"[64:ff9b:1::1]:80", "[100::1]:80", "[2001::1]:80", "[2001:db8::1]:80",
+		"[2002:7f00:1::]:80", "[2002:a00:1::]:80", "[fd00::1]:80", "[fe80::1]:80",
+		"[ff02::1]:80",
+	} {
+		assert.ErrorIs(t, publicOnly("tcp", addr, nil), ErrPrivateAddress, addr)
+	}
+	for _, addr := range []string{
+		"93.184.216.34:443", "100.128.0.1:80", "198.20.0.1:80", "[::ffff:93.184.216.34]:443",
+		"[2606:2800:220:1::]:443",
+	} {
+		assert.NoError(t, publicOnly("tcp", addr, nil), addr)
+	}
+}
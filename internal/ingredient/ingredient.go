@@ -0,0 +1,364 @@
+package ingredient
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// Item is one structured ingredient line. Quantity is zero when the text gave
+// no amount ("some flour", "salt to taste").
+type Item struct {
+	Raw         string  `json:"raw"`
+	Quantity    float64 `json:"quantity,omitempty"`
+	MaxQuantity float64 `json:"max_quantity,omitempty"`
+	Unit        string  `json:"unit,omitempty"`
+	Size        string  `json:"size,omitempty"`
+	Name        string  `json:"name"`
+	Canonical   string  `json:"canonical"`
+	Preparation string  `json:"preparation,omitempty"`
+	Note        string  `json:"note,omitempty"`
+}
+
+// countUnits are units that measure by piece or by feel rather than volume or
+// weight, so the units package doesn't know them.
+var countUnits = map[string]string{
+	"clove": "clove", "cloves": "clove",
+	"pinch": "pinch", "pinches": "pinch",
+	"dash": "dash", "dashes": "dash",
+	"splash": "splash", "drizzle": "drizzle",
+	"drop": "drop", "drops": "drop",
+	"handful": "handful", "handfuls": "handful",
+	"can": "can", "cans": "can", "tin": "tin", "tins": "tin",
+	"slice": "slice", "slices": "slice",
+	"piece": "piece", "pieces": "piece",
+	"bunch": "bunch", "bunches": "bunch",
+	"sprig": "sprig", "sprigs": "sprig",
+	"stick": "stick", "sticks": "stick",
+	"stalk": "stalk", "stalks": "stalk",
+	"head": "head", "heads": "head",
+	"knob": "knob", "knobs": "knob",
+	"cube": "cube", "cubes": "cube",
+	"fillet": "fillet", "fillets": "fillet",
+	"leaf": "leaf", "leaves": "leaf",
+	"packet": "packet", "packets": "packet", "pack": "pack", "packs": "pack",
+	"sachet": "sachet", "sachets": "sachet",
+	"bag": "bag", "bags": "bag", "jar": "jar", "jars": "jar",
+	"bottle": "bottle", "bottles": "bottle",
+	"derica": "derica", "dericas": "derica", "mudu": "mudu", "mudus": "mudu",
+}
+
+var numberWords = map[string]float64{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11,
+	"twelve": 12, "dozen": 12, "half": 0.5, "quarter": 0.25, "couple": 2,
+}
+
+var sizes = map[string]bool{
+	"small": true, "medium": true, "large": true, "extra-large": true, "jumbo": true, "big": true,
+}
+
+// vagueWords stand in for a quantity without giving one.
+var vagueWords = map[string]bool{
+	"some": true, "few": true, "little": true, "bit": true, "several": true,
+}
+
+var preparations = map[string]bool{
+	"minced": true, "chopped": true, "diced": true, "sliced": true, "grated": true,
+	"peeled": true, "crushed": true, "beaten": true, "melted": true, "softened": true,
+	"shredded": true, "cubed": true, "julienned": true, "halved": true, "quartered": true,
+	"mashed": true, "pureed": true, "puréed": true, "blended": true, "drained": true,
+	"rinsed": true, "toasted": true, "deseeded": true, "seeded": true, "trimmed": true,
+	"zested": true, "juiced": true, "squeezed": true, "sifted": true, "cooked": true,
+	"boiled": true, "parboiled": true, "washed": true, "soaked": true, "thawed": true,
+	"pitted": true, "cored": true, "skinned": true, "deboned": true, "cut": true,
+}
+
+var adverbs = map[string]bool{
+	"finely": true, "roughly": true, "thinly": true, "coarsely": true, "freshly": true,
+	"lightly": true, "well": true, "very": true, "thickly": true, "and": true,
+}
+
+// notePhrases are trailing phrases that qualify an ingredient rather than
+// name it.
+var notePhrases = []string{
+	"to taste", "at room temperature", "room temperature", "for garnish", "to garnish",
+	"for serving", "to serve", "for frying", "for greasing", "divided", "optional",
+	"or more", "or to taste", "as needed", "if needed", "plus extra",
+}
+
+var (
+	bulletPattern      = regexp.MustCompile(`^\s*(?:[-*•·]+|\d+[.)])\s+`)
+	parenthesisPattern = regexp.MustCompile(`\s*\(([^)]*)\)`)
+)
+
+// Parse turns one free-text ingredient such as "3 cloves garlic, minced" or
+// "a pinch of salt" into an Item.
+func Parse(text string) Item {
+	item := Item{Raw: strings.TrimSpace(text)}
+
+	s := bulletPattern.ReplaceAllString(item.Raw, "")
+	var notes []string
+	for _, m := range parenthesisPattern.FindAllStringSubmatch(s, -1) {
+		notes = append(notes, strings.TrimSpace(m[1]))
+	}
+	s = parenthesisPattern.ReplaceAllString(s, "")
+
+	// Anything after a comma qualifies the ingredient: "garlic, minced".
+	var trailing string
+	for i := strings.IndexByte(s, ','); i >= 0; {
+		if !decimalComma(s, i) {
+			s, trailing = s[:i], s[i+1:]
+			break
+		}
+		next := strings.IndexByte(s[i+1:], ',')
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, phrase := range notePhrases {
+		if strings.HasSuffix(s, " "+phrase) {
+			notes = append(notes, phrase)
+			s = strings.TrimSpace(strings.TrimSuffix(s, phrase))
+		}
+	}
+
+	if q, rest, ok := units.SplitAmount(s); ok {
+		item.Quantity, item.MaxQuantity = q.Amount, q.Max
+		s = rest
+	}
+	words := strings.Fields(s)
+
+	// Number words and vague amounts: "a pinch", "half a dozen", "some".
+	for len(words) > 0 {
+		w := words[0]
+		if v, ok := numberWords[w]; ok {
+			if item.Quantity == 0 {
+				item.Quantity = v
+			} else {
+				item.Quantity *= v
+			}
+			words = words[1:]
+			continue
+		}
+		if vagueWords[w] {
+			// "a little" and "a few" are not one of anything.
+			item.Quantity, item.MaxQuantity = 0, 0
+			words = words[1:]
+			continue
+		}
+		if w == "of" && len(words) > 1 {
+			words = words[1:]
+			continue
+		}
+		break
+	}
+
+	// Units, which may take two words ("fl oz") and may be attached to the
+	// number ("200g", which SplitAmount leaves as "g ...").
+	if len(words) > 1 {
+		if u, ok := units.LookupUnit(words[0] + " " + words[1]); ok && u.Dimension != units.Temperature {
+			item.Unit = u.Name
+			words = words[2:]
+		}
+	}
+	if item.Unit == "" && len(words) > 1 {
+		if u, ok := units.LookupUnit(words[0]); ok && u.Dimension != units.Temperature {
+			item.Unit = u.Name
+			words = words[1:]
+		} else if u, ok := countUnits[words[0]]; ok {
+			item.Unit = u
+			words = words[1:]
+		}
+	}
+	if len(words) > 1 && words[0] == "of" {
+		words = words[1:]
+	}
+
+	if len(words) > 1 && sizes[words[0]] {
+		item.Size = words[0]
+		words = words[1:]
+	}
+
+	// Preparation words can lead ("chopped onions") or trail ("garlic
+	// minced"). Adverbs only count when they modify one.
+	var prep []string
+	for len(words) > 1 && (preparations[words[0]] || (adverbs[words[0]] && preparations[words[1]])) {
+		prep = append(prep, words[0])
+		words = words[1:]
+	}
+	end := len(words)
+	for end > 1 && (preparations[words[end-1]] || (adverbs[words[end-1]] && end < len(words))) {
+		end--
+	}
+	prep = append(prep, words[end:]...)
+	words = words[:end]
+
+	if trailing = strings.TrimSpace(strings.ToLower(trailing)); trailing != "" {
+		if isNote(trailing) {
+			notes = append(notes, trailing)
+		} else if isPreparation(trailing) {
+			prep = append(prep, trailing)
+		} else {
+			notes = append(notes, trailing)
+		}
+	}
+
+	item.Name = strings.Join(words, " ")
+	item.Canonical = Normalize(item.Name)
+	item.Preparation = strings.Join(prep, " ")
+	item.Note = strings.Join(notes, "; ")
+	return item
+}
+
+// isPreparation reports whether the text only describes how to prepare an
+// ingredient, like "finely chopped" or "peeled and diced".
+func isPreparation(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if isNote(text) {
+		return true
+	}
+	words := strings.Fields(text)
+	hasPrep := false
+	for _, w := range words {
+		switch {
+		case preparations[w]:
+			hasPrep = true
+		case adverbs[w], w == "into", w == "in", w == "small", w == "large",
+			w == "pieces", w == "chunks", w == "cubes", w == "rings", w == "strips", w == "wedges":
+		default:
+			return false
+		}
+	}
+	return hasPrep
+}
+
+func isNote(text string) bool {
+	for _, phrase := range notePhrases {
+		if text == phrase {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseList splits free text on newlines, commas and semicolons and parses
+// each ingredient. A segment that only describes preparation ("minced") is
+// attached to the ingredient before it rather than treated as its own item.
+func ParseList(text string) []Item {
+	var segments []string
+	for _, seg := range splitOutsideParens(text) {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+		if len(segments) > 0 && isPreparation(seg) {
+			segments[len(segments)-1] += ", " + seg
+			continue
+		}
+		segments = append(segments, seg)
+	}
+
+	items := make([]Item, 0, len(segments))
+	for _, seg := range segments {
+		if item := Parse(seg); item.Name != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func splitOutsideParens(text string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case '\n', ';', ',':
+			if r == ',' && decimalComma(text, i) {
+				continue
+			}
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// decimalComma reports whether the comma at i sits between digits, as in
+// "1,5 kg", so it's part of a number rather than a separator.
+func decimalComma(text string, i int) bool {
+	return i > 0 && i+1 < len(text) && isDigit(text[i-1]) && isDigit(text[i+1])
+}
+
+func isDigit(b byte) bool { return '0' <= b && b <= '9' }
+
+// Names returns the canonical names of the items, without duplicates.
+func Names(items []Item) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, item := range items {
+		if item.Canonical != "" && !seen[item.Canonical] {
+			seen[item.Canonical] = true
+			names = append(names, item.Canonical)
+		}
+	}
+	return names
+}
+
+// String writes the item back out as a single line, e.g. "3 clove garlic,
+// minced".
+func (i Item) String() string {
+	var parts []string
+	if i.Quantity != 0 {
+		q := units.Quantity{Amount: i.Quantity, Max: i.MaxQuantity, Unit: i.Unit}
+		if _, ok := units.LookupUnit(i.Unit); ok {
+			parts = append(parts, units.Locale{System: units.US}.Format(q))
+		} else {
+			amount := units.Locale{System: units.US}.FormatAmount(i.Quantity, true)
+			if i.MaxQuantity != 0 {
+				amount += "–" + units.Locale{System: units.US}.FormatAmount(i.MaxQuantity, true)
+			}
+			parts = append(parts, amount)
+			if i.Unit != "" {
+				parts = append(parts, pluralUnit(i.Unit, i.Quantity, i.MaxQuantity))
+			}
+		}
+	}
+	if i.Size != "" {
+		parts = append(parts, i.Size)
+	}
+	parts = append(parts, i.Name)
+	s := strings.Join(parts, " ")
+	if i.Preparation != "" {
+		s += ", " + i.Preparation
+	}
+	if i.Note != "" {
+		s += " (" + i.Note + ")"
+	}
+	return s
+}
+
+func pluralUnit(unit string, quantity, max float64) string {
+	if quantity <= 1 && max <= 1 {
+		return unit
+	}
+	switch {
+	case unit == "leaf":
+		return "leaves"
+	case strings.HasSuffix(unit, "ch"), strings.HasSuffix(unit, "sh"):
+		return unit + "es"
+	}
+	return unit + "s"
+}
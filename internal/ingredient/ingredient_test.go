@@ -0,0 +1,59 @@
+package ingredient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Item
+	}{
+		{"2 large eggs", Item{Quantity: 2, Size: "large", Name: "eggs", Canonical: "egg"}},
+		{"1/2 cup sugar", Item{Quantity: 0.5, Unit: "cup", Name: "sugar", Canonical: "sugar"}},
+		{"a pinch of salt", Item{Quantity: 1, Unit: "pinch", Name: "salt", Canonical: "salt"}},
+		{"3 cloves garlic minced", Item{Quantity: 3, Unit: "clove", Name: "garlic", Canonical: "garlic", Preparation: "minced"}},
+		{"200g basmati rice", Item{Quantity: 200, Unit: "g", Name: "basmati rice", Canonical: "basmati rice"}},
+		{"some flour", Item{Name: "flour", Canonical: "all-purpose flour"}},
+		{"- 1 1/2 tbsp finely chopped scallions", Item{Quantity: 1.5, Unit: "tbsp", Name: "scallions", Canonical: "spring onion", Preparation: "finely chopped"}},
+		{"2-3 tomatoes, peeled and diced", Item{Quantity: 2, MaxQuantity: 3, Name: "tomatoes", Canonical: "tomato", Preparation: "peeled and diced"}},
+		{"1 (400g) can chopped tomatoes", Item{Quantity: 1, Unit: "can", Name: "tomatoes", Canonical: "tomato", Preparation: "chopped", Note: "400g"}},
+		{"salt to taste", Item{Name: "salt", Canonical: "salt", Note: "to taste"}},
+		{"half a dozen eggs", Item{Quantity: 6, Name: "eggs", Canonical: "egg"}},
+		{"a few sprigs of thyme", Item{Unit: "sprig", Name: "thyme", Canonical: "thyme"}},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.in)
+		tt.want.Raw = tt.in
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	items := ParseList("2 large eggs, 1/2 cup sugar, a pinch of salt, 3 cloves garlic, minced\nsome flour")
+	if assert.Len(t, items, 5) {
+		assert.Equal(t, []string{"egg", "sugar", "salt", "garlic", "all-purpose flour"}, Names(items))
+		assert.Equal(t, "minced", items[3].Preparation)
+		assert.Equal(t, "3 cloves garlic, minced", items[3].String())
+	}
+
+	items = ParseList("1,5 kg chicken, diced, 2 onions")
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, 1.5, items[0].Quantity)
+		assert.Equal(t, "kg", items[0].Unit)
+		assert.Equal(t, "chicken", items[0].Name)
+		assert.Equal(t, "diced", items[0].Preparation)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "spring onion", Normalize("Scallions"))
+	assert.Equal(t, "coriander", Normalize("fresh cilantro"))
+	assert.Equal(t, "tomato", Normalize("Ripe Tomatoes"))
+	assert.Equal(t, "bay leaf", Normalize("bay leaves"))
+	assert.Equal(t, "scotch bonnet pepper", Normalize("atarodo"))
+	assert.True(t, Same("red onions", "onion"))
+	assert.False(t, Same("bell pepper", "black pepper"))
+}
@@ -0,0 +1,206 @@
+package ingredient
+
+import (
+	"strings"
+	"unicode"
+)
+
+// synonyms folds regional and alternative names onto one canonical name so
+// that "scallions" from a photo and "spring onion" typed by a user compare
+// equal.
+var synonyms = map[string]string{
+	"scallion":            "spring onion",
+	"green onion":         "spring onion",
+	"cilantro":            "coriander",
+	"coriander leaf":      "coriander",
+	"aubergine":           "eggplant",
+	"courgette":           "zucchini",
+	"capsicum":            "bell pepper",
+	"sweet pepper":        "bell pepper",
+	"garbanzo bean":       "chickpea",
+	"garbanzo":            "chickpea",
+	"maize":               "corn",
+	"sweetcorn":           "corn",
+	"prawn":               "shrimp",
+	"minced meat":         "ground beef",
+	"minced beef":         "ground beef",
+	"beef mince":          "ground beef",
+	"mince":               "ground beef",
+	"plain flour":         "all-purpose flour",
+	"flour":               "all-purpose flour",
+	"caster sugar":        "sugar",
+	"granulated sugar":    "sugar",
+	"white sugar":         "sugar",
+	"icing sugar":         "powdered sugar",
+	"confectioners sugar": "powdered sugar",
+	"vegetable oil":       "oil",
+	"cooking oil":         "oil",
+	"groundnut oil":       "peanut oil",
+	"groundnut":           "peanut",
+	"rocket":              "arugula",
+	"chilli":              "chili pepper",
+	"chili":               "chili pepper",
+	"chile":               "chili pepper",
+	"scotch bonnet":       "scotch bonnet pepper",
+	"atarodo":             "scotch bonnet pepper",
+	"tatashe":             "red bell pepper",
+	"red pepper":          "red bell pepper",
+	"tomato puree":        "tomato paste",
+	"tomato purée":        "tomato paste",
+	"stock cube":          "bouillon cube",
+	"maggi":               "bouillon cube",
+	"knorr":               "bouillon cube",
+	"beef broth":          "beef stock",
+	"chicken broth":       "chicken stock",
+	"double cream":        "heavy cream",
+	"whipping cream":      "heavy cream",
+	"bicarbonate of soda": "baking soda",
+	"bicarb":              "baking soda",
+	"cornflour":           "cornstarch",
+	"yoghurt":             "yogurt",
+	"irish potato":        "potato",
+	"kosher salt":         "salt",
+	"sea salt":            "salt",
+	"table salt":          "salt",
+	"ground black pepper": "black pepper",
+}
+
+// irregular plurals that the suffix rules in singular get wrong.
+var irregular = map[string]string{
+	"leaves":    "leaf",
+	"loaves":    "loaf",
+	"halves":    "half",
+	"knives":    "knife",
+	"potatoes":  "potato",
+	"tomatoes":  "tomato",
+	"mangoes":   "mango",
+	"chillies":  "chilli",
+	"chilies":   "chili",
+	"molasses":  "molasses",
+	"couscous":  "couscous",
+	"hummus":    "hummus",
+	"asparagus": "asparagus",
+	"citrus":    "citrus",
+	"swiss":     "swiss",
+	"grass":     "grass",
+	"oats":      "oats",
+	"greens":    "greens",
+	"noodles":   "noodle",
+	"lentils":   "lentil",
+	"peas":      "pea",
+	"grapes":    "grape",
+	"olives":    "olive",
+	"cloves":    "clove",
+	"chives":    "chives",
+	"anchovies": "anchovy",
+}
+
+// descriptors say nothing about which ingredient it is, so Normalize drops
+// them.
+var descriptors = map[string]bool{
+	"fresh": true, "ripe": true, "organic": true, "raw": true, "whole": true,
+	"large": true, "medium": true, "small": true, "extra-large": true, "jumbo": true,
+	"free-range": true, "good": true, "quality": true, "some": true,
+}
+
+func singular(word string) string {
+	if s, ok := irregular[word]; ok {
+		return s
+	}
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// Normalize reduces an ingredient name to its canonical form: lower case,
+// singular, without punctuation and with synonyms folded together. Two names
+// that refer to the same thing should normalize to the same string.
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || unicode.IsSpace(r) {
+			return r
+		}
+		return ' '
+	}, name)
+
+	var words []string
+	for _, w := range strings.Fields(name) {
+		if !descriptors[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	// Only the head noun (the last word) is pluralised in English.
+	words[len(words)-1] = singular(words[len(words)-1])
+	name = strings.Join(words, " ")
+
+	if s, ok := synonyms[name]; ok {
+		return s
+	}
+	// Fold a synonym at the end of a longer name, e.g. "fresh cilantro".
+	for i := 1; i < len(words); i++ {
+		tail := strings.Join(words[i:], " ")
+		if s, ok := synonyms[tail]; ok {
+			return strings.Join(append(words[:i:i], s), " ")
+		}
+	}
+	return name
+}
+
+// cuts are words naming part of an ingredient, so "chicken thigh" is still
+// chicken but "beef stock" is not beef.
+var cuts = map[string]bool{
+	"thigh": true, "breast": true, "wing": true, "drumstick": true, "leg": true,
+	"fillet": true, "steak": true, "chop": true, "yolk": true, "white": true,
+	"juice": true, "zest": true, "clove": true, "leaf": true, "floret": true,
+}
+
+// Same reports whether two ingredient names refer to the same ingredient
+// once normalized, treating a more specific name as the same as a general one
+// ("red onion" matches "onion", "chicken thigh" matches "chicken").
+func Same(a, b string) bool {
+	a, b = Normalize(a), Normalize(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b || strings.HasSuffix(a, " "+b) || strings.HasSuffix(b, " "+a) {
+		return true
+	}
+	return isCutOf(a, b) || isCutOf(b, a)
+}
+
+func isCutOf(specific, general string) bool {
+	rest, ok := strings.CutPrefix(specific, general+" ")
+	return ok && cuts[rest]
+}
+
+// staples are assumed to be in every kitchen, so a recipe that needs them is
+// not asking the user for anything they lack.
+var staples = map[string]bool{
+	"salt": true, "black pepper": true, "pepper": true, "water": true, "ice": true,
+	"oil": true, "olive oil": true, "peanut oil": true, "palm oil": true,
+	"cooking spray": true, "sugar": true,
+}
+
+// IsStaple reports whether the ingredient is a pantry staple such as salt,
+// oil or water.
+func IsStaple(name string) bool {
+	return staples[Normalize(name)]
+}
@@ -0,0 +1,236 @@
+package mealplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/shopping"
+)
+
+// Slots are the meals planned for each day, in order.
+var Slots = []string{"breakfast", "lunch", "dinner"}
+
+// Days is how long a plan runs.
+const Days = 7
+
+// Request is what a plan has to fit. CaloriesPerDay is per person, Budget
+// is for the whole week.
+type Request struct {
+	HouseholdSize  int      `json:"household_size"`
+	Diet           []string `json:"diet,omitempty"`
+	CaloriesPerDay float64  `json:"calories_per_day,omitempty"`
+	Budget         float64  `json:"budget,omitempty"`
+	Currency       string   `json:"currency,omitempty"`
+	Cuisine        string   `json:"cuisine,omitempty"`
+	StartDate      string   `json:"start_date,omitempty"`
+}
+
+// Validate checks the request and fills in defaults: one person, starting
+// today.
+func (r *Request) Validate(now time.Time) (time.Time, error) {
+	if r.HouseholdSize == 0 {
+		r.HouseholdSize = 1
+	}
+	if r.HouseholdSize < 0 || r.HouseholdSize > 20 {
+		return time.Time{}, fmt.Errorf("household_size must be between 1 and 20")
+	}
+	if r.CaloriesPerDay < 0 || r.Budget < 0 {
+		return time.Time{}, fmt.Errorf("calories_per_day and budget cannot be negative")
+	}
+	if r.StartDate == "" {
+		r.StartDate = now.Format(time.DateOnly)
+	}
+	start, err := time.Parse(time.DateOnly, r.StartDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start_date %q, expected YYYY-MM-DD", r.StartDate)
+	}
+	return start, nil
+}
+
+// Meal is one slot of the plan. Its recipe starts out as ingredients and
+// nutrition only; Expanded is set once the full method has been written.
+type Meal struct {
+	Slot          string         `json:"slot"`
+	Recipe        *recipe.Recipe `json:"recipe"`
+	EstimatedCost float64        `json:"estimated_cost,omitempty"`
+	Expanded      bool           `json:"expanded"`
+}
+
+type Day struct {
+	Date  string `json:"date"`
+	Meals []Meal `json:"meals"`
+}
+
+// Shared is an ingredient bought once and used on several days.
+type Shared struct {
+	Ingredient string   `json:"ingredient"`
+	Days       []string `json:"days"`
+}
+
+type Plan struct {
+	ID            string    `json:"id"`
+	Request       Request   `json:"request"`
+	Days          []Day     `json:"days"`
+	Shared        []Shared  `json:"shared_ingredients"`
+	EstimatedCost float64   `json:"estimated_cost,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MealSchema is the JSON shape we ask the model to answer in for one meal of
+// a plan: a recipe without its method.
+const MealSchema = `{"slot": "breakfast" | "lunch" | "dinner", "title": string, "description": string, "cuisine": string, "servings": number, "prep_time_minutes": number, "cook_time_minutes": number, "ingredients": ["quantity unit ingredient"], "nutrition": {"calories": number, "protein_g": number, "carbs_g": number, "fat_g": number}, "estimated_cost": number} where nutrition is per serving`
+
+// Schema is the JSON shape of a whole plan.
+const Schema = `{"days": [{"meals": [` + MealSchema + `]}]}`
+
+type rawMeal struct {
+	recipe.Recipe
+	Slot          string   `json:"slot"`
+	Ingredients   []string `json:"ingredients"`
+	EstimatedCost float64  `json:"estimated_cost"`
+}
+
+func (m rawMeal) meal() (Meal, error) {
+	r := m.Recipe
+	r.Ingredients = make([]ingredient.Item, 0, len(m.Ingredients))
+	for _, line := range m.Ingredients {
+		if strings.TrimSpace(line) != "" {
+			r.Ingredients = append(r.Ingredients, ingredient.Parse(line))
+		}
+	}
+	r.Steps = []string{}
+	if r.Title == "" || len(r.Ingredients) == 0 {
+		return Meal{}, fmt.Errorf("incomplete meal: missing title or ingredients")
+	}
+	return Meal{Slot: strings.ToLower(m.Slot), Recipe: &r, EstimatedCost: m.EstimatedCost}, nil
+}
+
+// ParseMeal decodes one meal in the MealSchema format.
+func ParseMeal(data []byte) (Meal, error) {
+	var raw rawMeal
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Meal{}, fmt.Errorf("error parsing meal: %v", err)
+	}
+	return raw.meal()
+}
+
+// Parse decodes a plan in the Schema format into days starting at start.
+// Each day must have every slot; meals are put in slot order.
+func Parse(data []byte, start time.Time, req Request) (*Plan, error) {
+	var raw struct {
+		Days []struct {
+			Meals []rawMeal `json:"meals"`
+		} `json:"days"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing plan: %v", err)
+	}
+	if len(raw.Days) < Days {
+		return nil, fmt.Errorf("incomplete plan: %d of %d days", len(raw.Days), Days)
+	}
+
+	p := &Plan{Request: req}
+	for i, d := range raw.Days[:Days] {
+		day := Day{Date: start.AddDate(0, 0, i).Format(time.DateOnly)}
+		bySlot := map[string]Meal{}
+		for _, rm := range d.Meals {
+			m, err := rm.meal()
+			if err != nil {
+				return nil, fmt.Errorf("day %d: %v", i+1, err)
+			}
+			bySlot[m.Slot] = m
+		}
+		for _, slot := range Slots {
+			m, ok := bySlot[slot]
+			if !ok {
+				return nil, fmt.Errorf("day %d: missing %s", i+1, slot)
+			}
+			day.Meals = append(day.Meals, m)
+		}
+		p.Days = append(p.Days, day)
+	}
+	p.Refresh()
+	return p, nil
+}
+
+// Meal finds the meal in a slot on a day, given as 1-7 or a date.
+func (p *Plan) Meal(day, slot string) (*Meal, error) {
+	for i := range p.Days {
+		if day != p.Days[i].Date && day != fmt.Sprint(i+1) {
+			continue
+		}
+		for j := range p.Days[i].Meals {
+			if p.Days[i].Meals[j].Slot == slot {
+				return &p.Days[i].Meals[j], nil
+			}
+		}
+		return nil, fmt.Errorf("no %q meal on day %s", slot, day)
+	}
+	return nil, fmt.Errorf("no day %q in this plan", day)
+}
+
+// Refresh recomputes what's derived from the meals: the total cost and which
+// ingredients are shared across days.
+func (p *Plan) Refresh() {
+	p.EstimatedCost = 0
+	days := map[string][]string{}
+	var order []string
+	for _, d := range p.Days {
+		for _, m := range d.Meals {
+			p.EstimatedCost += m.EstimatedCost
+			for _, item := range m.Recipe.Ingredients {
+				name := item.Canonical
+				if name == "" || ingredient.IsStaple(name) {
+					continue
+				}
+				if _, ok := days[name]; !ok {
+					order = append(order, name)
+				}
+				if n := len(days[name]); n == 0 || days[name][n-1] != d.Date {
+					days[name] = append(days[name], d.Date)
+				}
+			}
+		}
+	}
+	p.EstimatedCost = math.Round(p.EstimatedCost*100) / 100
+
+	p.Shared = []Shared{}
+	for _, name := range order {
+		if len(days[name]) > 1 {
+			p.Shared = append(p.Shared, Shared{Ingredient: name, Days: days[name]})
+		}
+	}
+	sort.SliceStable(p.Shared, func(i, j int) bool { return len(p.Shared[i].Days) > len(p.Shared[j].Days) })
+}
+
+// Needs lists everything the plan's meals call for, for a shopping list.
+func (p *Plan) Needs() []shopping.Need {
+	var needs []shopping.Need
+	for _, d := range p.Days {
+		for _, m := range d.Meals {
+			needs = append(needs, shopping.FromRecipe(m.Recipe, 0)...)
+		}
+	}
+	return needs
+}
+
+// Summary describes the rest of the plan for a prompt, so a replacement meal
+// can reuse what's already being bought.
+func (p *Plan) Summary() string {
+	var lines []string
+	for i, d := range p.Days {
+		var meals []string
+		for _, m := range d.Meals {
+			meals = append(meals, fmt.Sprintf("%s: %s", m.Slot, m.Recipe.Title))
+		}
+		lines = append(lines, fmt.Sprintf("Day %d (%s) %s", i+1, d.Date, strings.Join(meals, ", ")))
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,129 @@
+package mealplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+// week builds a plan response where every dinner uses the same bag of rice
+// and the day 1 and 2 lunches share spinach.
+func week(days int) []byte {
+	type meal map[string]interface{}
+	var out struct {
+		Days []struct {
+			Meals []meal `json:"meals"`
+		} `json:"days"`
+	}
+	for d := 1; d <= days; d++ {
+		lunch := []string{"200g chicken breast", "1 tbsp olive oil"}
+		if d <= 2 {
+			lunch = append(lunch, "100g spinach")
+		}
+		out.Days = append(out.Days, struct {
+			Meals []meal `json:"meals"`
+		}{Meals: []meal{
+			{"slot": "Dinner", "title": fmt.Sprintf("Dinner %d", d), "ingredients": []string{"150g rice", "2 tomatoes", "salt"}, "estimated_cost": 3.5},
+			{"slot": "breakfast", "title": fmt.Sprintf("Oats %d", d), "ingredients": []string{"50g oats", "200ml milk"}, "estimated_cost": 1},
+			{"slot": "lunch", "title": fmt.Sprintf("Lunch %d", d), "ingredients": lunch, "estimated_cost": 4.25},
+		}})
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+func TestValidate(t *testing.T) {
+	r := Request{}
+	got, err := r.Validate(start.Add(9 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, start, got)
+	assert.Equal(t, 1, r.HouseholdSize)
+	assert.Equal(t, "2026-10-19", r.StartDate)
+
+	r = Request{HouseholdSize: 30}
+	_, err = r.Validate(start)
+	assert.Error(t, err)
+
+	r = Request{StartDate: "next monday"}
+	_, err = r.Validate(start)
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(week(7), start, Request{HouseholdSize: 2})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, p.Days, 7)
+	assert.Equal(t, "2026-10-25", p.Days[6].Date)
+	assert.Equal(t, "breakfast", p.Days[0].Meals[0].Slot)
+	assert.Equal(t, "Lunch 1", p.Days[0].Meals[1].Recipe.Title)
+	assert.Equal(t, "Dinner 1", p.Days[0].Meals[2].Recipe.Title)
+	assert.False(t, p.Days[0].Meals[2].Expanded)
+	assert.Equal(t, 61.25, p.EstimatedCost)
+
+	shared := map[string]int{}
+	for _, s := range p.Shared {
+		shared[s.Ingredient] = len(s.Days)
+	}
+	assert.Equal(t, map[string]int{"rice": 7, "tomato": 7, "oats": 7, "milk": 7, "chicken breast": 7, "spinach": 2}, shared)
+
+	_, err = Parse(week(6), start, Request{})
+	assert.Error(t, err)
+}
+
+func TestMeal(t *testing.T) {
+	p, _ := Parse(week(7), start, Request{})
+
+	m, err := p.Meal("3", "dinner")
+	assert.NoError(t, err)
+	assert.Equal(t, "Dinner 3", m.Recipe.Title)
+
+	m, err = p.Meal("2026-10-20", "lunch")
+	assert.NoError(t, err)
+	assert.Equal(t, "Lunch 2", m.Recipe.Title)
+
+	_, err = p.Meal("8", "lunch")
+	assert.Error(t, err)
+	_, err = p.Meal("1", "supper")
+	assert.Error(t, err)
+
+	meal, err := ParseMeal([]byte(`{"slot": "lunch", "title": "Egusi soup", "ingredients": ["2 cups ground egusi", "300g spinach"]}`))
+	assert.NoError(t, err)
+	*m = meal
+	p.Refresh()
+	// 14 breakfast, 21 dinner and 15 lunch ingredients.
+	assert.Len(t, p.Needs(), 50)
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	older, _ := Parse(week(7), start, Request{})
+	older.CreatedAt = start
+	newer, _ := Parse(week(7), start, Request{})
+	newer.CreatedAt = start.Add(time.Hour)
+	assert.NoError(t, s.Save("ada", older))
+	assert.NoError(t, s.Save("ada", newer))
+	assert.NotEmpty(t, older.ID)
+
+	plans, err := s.List("ada")
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 2) {
+		assert.Equal(t, newer.ID, plans[0].ID)
+	}
+
+	got, err := s.Get("ada", older.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dinner 7", got.Days[6].Meals[2].Recipe.Title)
+
+	_, err = s.Get("bola", older.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,80 @@
+package mealplan
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("meal plan not found")
+
+// Store keeps each user's meal plans.
+type Store interface {
+	// List returns the user's plans, newest first.
+	List(userID string) ([]Plan, error)
+	Get(userID, id string) (*Plan, error)
+	// Save stores a plan, giving it an ID if it doesn't have one yet.
+	Save(userID string, p *Plan) error
+	// Update calls fn with the stored plan and stores what it leaves, with
+	// no other change to the plan coming between. If fn returns an error
+	// the plan is left as it was.
+	Update(userID, id string, fn func(p *Plan) error) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+func plansPath(userID string) []string { return []string{"mealplans", userID} }
+
+func (s *BoltStore) List(userID string) ([]Plan, error) {
+	plans := []Plan{}
+	err := s.db.Each(plansPath(userID), func(key string, data []byte) error {
+		var p Plan
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		plans = append(plans, p)
+		return nil
+	})
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].CreatedAt.After(plans[j].CreatedAt) })
+	return plans, err
+}
+
+func (s *BoltStore) Get(userID, id string) (*Plan, error) {
+	var p Plan
+	err := s.db.Get(plansPath(userID), id, &p)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *BoltStore) Save(userID string, p *Plan) error {
+	if p.ID == "" {
+		id, err := storage.NewID()
+		if err != nil {
+			return err
+		}
+		p.ID = id
+	}
+	return s.db.Put(plansPath(userID), p.ID, p)
+}
+
+func (s *BoltStore) Update(userID, id string, fn func(p *Plan) error) error {
+	var p Plan
+	err := s.db.Update(plansPath(userID), id, &p, func() error { return fn(&p) })
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
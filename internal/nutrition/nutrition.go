@@ -0,0 +1,121 @@
+package nutrition
+
+import (
+	"math"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+// index is per100g keyed by normalized name.
+var index = func() map[string]recipe.Nutrition {
+	m := make(map[string]recipe.Nutrition, len(per100g))
+	for name, n := range per100g {
+		m[ingredient.Normalize(name)] = n
+	}
+	return m
+}()
+
+// Lookup returns the nutrition of 100 g of the food. A name we don't know
+// falls back to the longest known name it ends with, so "grilled chicken
+// breast" uses chicken breast and "smoky party jollof rice" uses jollof rice.
+func Lookup(name string) (recipe.Nutrition, bool) {
+	name = ingredient.Normalize(name)
+	if n, ok := index[name]; ok {
+		return n, true
+	}
+	words := strings.Fields(name)
+	for i := 1; i < len(words); i++ {
+		if n, ok := index[strings.Join(words[i:], " ")]; ok {
+			return n, true
+		}
+	}
+	return recipe.Nutrition{}, false
+}
+
+// Range is an estimate with the lowest and highest values we think likely.
+type Range struct {
+	Low      float64 `json:"low"`
+	Estimate float64 `json:"estimate"`
+	High     float64 `json:"high"`
+}
+
+func (r Range) scale(f float64) Range {
+	return Range{Low: round(r.Low * f), Estimate: round(r.Estimate * f), High: round(r.High * f)}
+}
+
+func (r Range) add(o Range) Range {
+	return Range{Low: r.Low + o.Low, Estimate: r.Estimate + o.Estimate, High: r.High + o.High}
+}
+
+// Portion is how much of one food is on a plate and what it adds up to.
+type Portion struct {
+	Name     string `json:"name"`
+	Grams    Range  `json:"grams"`
+	Calories Range  `json:"calories"`
+	Protein  Range  `json:"protein_g"`
+	Carbs    Range  `json:"carbs_g"`
+	Fat      Range  `json:"fat_g"`
+	Source   string `json:"source"`
+}
+
+// unreferencedSpread is how much wider a weight range gets when nothing of
+// known size is in the photo to judge scale by.
+const unreferencedSpread = 0.25
+
+// Weight makes a weight range consistent (low <= estimate <= high) and widens
+// it when the photo had no reference object.
+func Weight(low, estimate, high float64, referenced bool) Range {
+	if estimate <= 0 {
+		estimate = (low + high) / 2
+	}
+	if low <= 0 || low > estimate {
+		low = estimate
+	}
+	if high < estimate {
+		high = estimate
+	}
+	if !referenced {
+		low *= 1 - unreferencedSpread
+		high *= 1 + unreferencedSpread
+	}
+	return Range{Low: round(low), Estimate: round(estimate), High: round(high)}
+}
+
+// Estimate works out a portion's nutrition from its weight. Our table is
+// used when it knows the food; otherwise fallback, the model's own per 100 g
+// figures, is.
+func Estimate(name string, grams Range, fallback recipe.Nutrition) Portion {
+	per, ok := Lookup(name)
+	source := "table"
+	if !ok {
+		per, source = fallback, "model"
+	}
+	return Portion{
+		Name:     name,
+		Grams:    grams,
+		Calories: grams.scale(per.Calories / 100),
+		Protein:  grams.scale(per.Protein / 100),
+		Carbs:    grams.scale(per.Carbs / 100),
+		Fat:      grams.scale(per.Fat / 100),
+		Source:   source,
+	}
+}
+
+// Total adds portions up into one for the whole plate.
+func Total(portions []Portion) Portion {
+	total := Portion{Name: "total"}
+	for _, p := range portions {
+		total.Grams = total.Grams.add(p.Grams)
+		total.Calories = total.Calories.add(p.Calories)
+		total.Protein = total.Protein.add(p.Protein)
+		total.Carbs = total.Carbs.add(p.Carbs)
+		total.Fat = total.Fat.add(p.Fat)
+	}
+	return total
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,47 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	n, ok := Lookup("Jollof Rice")
+	assert.True(t, ok)
+	assert.Equal(t, 150.0, n.Calories)
+
+	n, ok = Lookup("grilled chicken breasts")
+	assert.True(t, ok)
+	assert.Equal(t, 31.0, n.Protein)
+
+	n, ok = Lookup("French fries")
+	assert.True(t, ok)
+	assert.Equal(t, 312.0, n.Calories)
+
+	_, ok = Lookup("ofada sauce")
+	assert.False(t, ok)
+}
+
+func TestWeight(t *testing.T) {
+	assert.Equal(t, Range{Low: 150, Estimate: 200, High: 250}, Weight(150, 200, 250, true))
+	assert.Equal(t, Range{Low: 112.5, Estimate: 200, High: 312.5}, Weight(150, 200, 250, false))
+	assert.Equal(t, Range{Low: 200, Estimate: 200, High: 200}, Weight(300, 200, 0, true))
+	assert.Equal(t, Range{Low: 100, Estimate: 150, High: 200}, Weight(100, 0, 200, true))
+}
+
+func TestEstimate(t *testing.T) {
+	rice := Estimate("jollof rice", Range{Low: 200, Estimate: 250, High: 300}, recipe.Nutrition{})
+	assert.Equal(t, "table", rice.Source)
+	assert.Equal(t, Range{Low: 300, Estimate: 375, High: 450}, rice.Calories)
+	assert.Equal(t, Range{Low: 8, Estimate: 10, High: 12}, rice.Fat)
+
+	sauce := Estimate("ofada sauce", Range{Low: 50, Estimate: 50, High: 50}, recipe.Nutrition{Calories: 200, Fat: 18})
+	assert.Equal(t, "model", sauce.Source)
+	assert.Equal(t, 100.0, sauce.Calories.Estimate)
+
+	total := Total([]Portion{rice, sauce})
+	assert.Equal(t, Range{Low: 250, Estimate: 300, High: 350}, total.Grams)
+	assert.Equal(t, Range{Low: 400, Estimate: 475, High: 550}, total.Calories)
+}
@@ -0,0 +1,93 @@
+package nutrition
+
+import "github.com/Oluwaseun241/mura/internal/recipe"
+
+// per100g holds calories and macros for 100 g of food as it is served, so
+// "rice" is cooked rice and "chicken" is cooked chicken. Figures are rounded
+// from USDA FoodData Central, with common West African dishes estimated from
+// typical home recipes.
+var per100g = map[string]recipe.Nutrition{
+	// Grains and starches
+	"rice":           {Calories: 130, Protein: 2.7, Carbs: 28, Fat: 0.3},
+	"white rice":     {Calories: 130, Protein: 2.7, Carbs: 28, Fat: 0.3},
+	"brown rice":     {Calories: 112, Protein: 2.3, Carbs: 23.5, Fat: 0.8},
+	"jollof rice":    {Calories: 150, Protein: 3, Carbs: 25, Fat: 4},
+	"fried rice":     {Calories: 163, Protein: 4, Carbs: 24, Fat: 5.5},
+	"pasta":          {Calories: 158, Protein: 5.8, Carbs: 31, Fat: 0.9},
+	"spaghetti":      {Calories: 158, Protein: 5.8, Carbs: 31, Fat: 0.9},
+	"noodle":         {Calories: 138, Protein: 4.5, Carbs: 25, Fat: 2},
+	"couscous":       {Calories: 112, Protein: 3.8, Carbs: 23, Fat: 0.2},
+	"bread":          {Calories: 265, Protein: 9, Carbs: 49, Fat: 3.2},
+	"oats":           {Calories: 71, Protein: 2.5, Carbs: 12, Fat: 1.5},
+	"porridge":       {Calories: 71, Protein: 2.5, Carbs: 12, Fat: 1.5},
+	"pancake":        {Calories: 227, Protein: 6.4, Carbs: 28, Fat: 9.7},
+	"potato":         {Calories: 87, Protein: 1.9, Carbs: 20, Fat: 0.1},
+	"mashed potato":  {Calories: 113, Protein: 2, Carbs: 17, Fat: 4.2},
+	"french fry":     {Calories: 312, Protein: 3.4, Carbs: 41, Fat: 15},
+	"chip":           {Calories: 312, Protein: 3.4, Carbs: 41, Fat: 15},
+	"sweet potato":   {Calories: 90, Protein: 2, Carbs: 21, Fat: 0.2},
+	"yam":            {Calories: 116, Protein: 1.5, Carbs: 27.5, Fat: 0.1},
+	"pounded yam":    {Calories: 118, Protein: 1.5, Carbs: 28, Fat: 0.2},
+	"fufu":           {Calories: 160, Protein: 1.4, Carbs: 38, Fat: 0.3},
+	"eba":            {Calories: 160, Protein: 1, Carbs: 38, Fat: 0.5},
+	"plantain":       {Calories: 116, Protein: 0.8, Carbs: 31, Fat: 0.2},
+	"fried plantain": {Calories: 236, Protein: 1.5, Carbs: 38, Fat: 9},
+	"dodo":           {Calories: 236, Protein: 1.5, Carbs: 38, Fat: 9},
+	"corn":           {Calories: 96, Protein: 3.4, Carbs: 21, Fat: 1.5},
+
+	// Meat, fish and eggs
+	"chicken":        {Calories: 239, Protein: 27, Carbs: 0, Fat: 14},
+	"chicken breast": {Calories: 165, Protein: 31, Carbs: 0, Fat: 3.6},
+	"chicken thigh":  {Calories: 209, Protein: 26, Carbs: 0, Fat: 10.9},
+	"fried chicken":  {Calories: 246, Protein: 24, Carbs: 8, Fat: 13},
+	"beef":           {Calories: 250, Protein: 26, Carbs: 0, Fat: 15},
+	"ground beef":    {Calories: 254, Protein: 26, Carbs: 0, Fat: 17},
+	"suya":           {Calories: 250, Protein: 26, Carbs: 4, Fat: 14},
+	"pork":           {Calories: 242, Protein: 27, Carbs: 0, Fat: 14},
+	"bacon":          {Calories: 541, Protein: 37, Carbs: 1.4, Fat: 42},
+	"sausage":        {Calories: 300, Protein: 12, Carbs: 2, Fat: 27},
+	"lamb":           {Calories: 294, Protein: 25, Carbs: 0, Fat: 21},
+	"goat meat":      {Calories: 143, Protein: 27, Carbs: 0, Fat: 3},
+	"fish":           {Calories: 128, Protein: 26, Carbs: 0, Fat: 2.7},
+	"tilapia":        {Calories: 128, Protein: 26, Carbs: 0, Fat: 2.7},
+	"salmon":         {Calories: 206, Protein: 22, Carbs: 0, Fat: 12},
+	"shrimp":         {Calories: 99, Protein: 24, Carbs: 0.2, Fat: 0.3},
+	"egg":            {Calories: 155, Protein: 13, Carbs: 1.1, Fat: 11},
+	"fried egg":      {Calories: 196, Protein: 14, Carbs: 0.8, Fat: 15},
+	"burger":         {Calories: 295, Protein: 17, Carbs: 24, Fat: 14},
+	"pizza":          {Calories: 266, Protein: 11, Carbs: 33, Fat: 10},
+
+	// Legumes
+	"bean":       {Calories: 116, Protein: 7.7, Carbs: 21, Fat: 0.5},
+	"moi moi":    {Calories: 140, Protein: 8, Carbs: 13, Fat: 6},
+	"akara":      {Calories: 260, Protein: 10, Carbs: 20, Fat: 16},
+	"lentil":     {Calories: 116, Protein: 9, Carbs: 20, Fat: 0.4},
+	"chickpea":   {Calories: 164, Protein: 8.9, Carbs: 27, Fat: 2.6},
+	"tofu":       {Calories: 76, Protein: 8, Carbs: 1.9, Fat: 4.8},
+	"egusi soup": {Calories: 170, Protein: 8, Carbs: 5, Fat: 13},
+	"stew":       {Calories: 100, Protein: 1.5, Carbs: 8, Fat: 7},
+
+	// Vegetables and fruit
+	"salad":      {Calories: 20, Protein: 1.2, Carbs: 3.6, Fat: 0.2},
+	"lettuce":    {Calories: 15, Protein: 1.4, Carbs: 2.9, Fat: 0.2},
+	"coleslaw":   {Calories: 69, Protein: 1.3, Carbs: 12.4, Fat: 2.6},
+	"tomato":     {Calories: 18, Protein: 0.9, Carbs: 3.9, Fat: 0.2},
+	"onion":      {Calories: 40, Protein: 1.1, Carbs: 9.3, Fat: 0.1},
+	"avocado":    {Calories: 160, Protein: 2, Carbs: 8.5, Fat: 14.7},
+	"broccoli":   {Calories: 35, Protein: 2.4, Carbs: 7.2, Fat: 0.4},
+	"carrot":     {Calories: 35, Protein: 0.8, Carbs: 8.2, Fat: 0.2},
+	"spinach":    {Calories: 23, Protein: 3, Carbs: 3.8, Fat: 0.3},
+	"green bean": {Calories: 35, Protein: 1.9, Carbs: 7.9, Fat: 0.3},
+	"pea":        {Calories: 84, Protein: 5.4, Carbs: 15.6, Fat: 0.2},
+	"apple":      {Calories: 52, Protein: 0.3, Carbs: 14, Fat: 0.2},
+	"banana":     {Calories: 89, Protein: 1.1, Carbs: 23, Fat: 0.3},
+	"orange":     {Calories: 47, Protein: 0.9, Carbs: 12, Fat: 0.1},
+
+	// Dairy and fats
+	"cheese":    {Calories: 403, Protein: 25, Carbs: 1.3, Fat: 33},
+	"yogurt":    {Calories: 61, Protein: 3.5, Carbs: 4.7, Fat: 3.3},
+	"milk":      {Calories: 61, Protein: 3.2, Carbs: 4.8, Fat: 3.3},
+	"butter":    {Calories: 717, Protein: 0.9, Carbs: 0.1, Fat: 81},
+	"oil":       {Calories: 884, Protein: 0, Carbs: 0, Fat: 100},
+	"olive oil": {Calories: 884, Protein: 0, Carbs: 0, Fat: 100},
+}
@@ -0,0 +1,159 @@
+package pantry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// Item is something the user has at home. Quantity is zero when we only know
+// they have some, e.g. items added straight from a photo.
+type Item struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Canonical       string     `json:"canonical"`
+	Quantity        float64    `json:"quantity,omitempty"`
+	Unit            string     `json:"unit,omitempty"`
+	PurchasedAt     *time.Time `json:"purchased_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	ExpiryEstimated bool       `json:"expiry_estimated,omitempty"`
+	Source          string     `json:"source"`
+	AddedAt         time.Time  `json:"added_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// FromIngredient makes a pantry item from a parsed ingredient line.
+func FromIngredient(it ingredient.Item, source string) Item {
+	return Item{
+		Name:      it.Name,
+		Canonical: it.Canonical,
+		Quantity:  it.Quantity,
+		Unit:      it.Unit,
+		Source:    source,
+	}
+}
+
+// Ingredient turns the item back into an ingredient line for recipe prompts.
+func (i Item) Ingredient() ingredient.Item {
+	it := ingredient.Item{Quantity: i.Quantity, Unit: i.Unit, Name: i.Name, Canonical: i.Canonical}
+	it.Raw = it.String()
+	return it
+}
+
+// Expired reports whether the item is past its expiry date at now.
+func (i Item) Expired(now time.Time) bool {
+	return i.ExpiresAt != nil && now.After(*i.ExpiresAt)
+}
+
+// DaysLeft is the number of whole days until the item expires, negative once
+// it has. ok is false when the expiry is unknown.
+func (i Item) DaysLeft(now time.Time) (days int, ok bool) {
+	if i.ExpiresAt == nil {
+		return 0, false
+	}
+	return int(i.ExpiresAt.Sub(now).Hours() / 24), true
+}
+
+// Prepare fills in what the user didn't give: the canonical name, when it was
+// added, and an expiry estimated from typical shelf life.
+func (i *Item) Prepare(now time.Time) {
+	i.Name = strings.TrimSpace(i.Name)
+	if i.Canonical == "" {
+		i.Canonical = ingredient.Normalize(i.Name)
+	}
+	if i.AddedAt.IsZero() {
+		i.AddedAt = now
+	}
+	i.UpdatedAt = now
+	if i.ExpiresAt == nil || i.ExpiryEstimated {
+		i.estimateExpiry()
+	}
+}
+
+func (i *Item) estimateExpiry() {
+	days, ok := ShelfLife(i.Canonical)
+	if !ok {
+		return
+	}
+	from := i.AddedAt
+	if i.PurchasedAt != nil {
+		from = *i.PurchasedAt
+	}
+	expires := from.AddDate(0, 0, days)
+	i.ExpiresAt, i.ExpiryEstimated = &expires, true
+}
+
+// Merge folds a newly added item into an existing one of the same
+// ingredient, returning the item to store. Quantities add up when their
+// units agree or convert; otherwise the new item is kept separate. The
+// earlier expiry wins, since the older stock should be used first.
+func Merge(items []Item, in Item) Item {
+	for _, it := range items {
+		if it.Canonical != in.Canonical {
+			continue
+		}
+		amount, ok := convertTo(in, it)
+		if !ok {
+			continue
+		}
+		merged := it
+		merged.Quantity += amount
+		if merged.Unit == "" {
+			merged.Unit = in.Unit
+		}
+		if in.ExpiresAt != nil && (merged.ExpiresAt == nil || in.ExpiresAt.Before(*merged.ExpiresAt)) {
+			merged.ExpiresAt, merged.ExpiryEstimated = in.ExpiresAt, in.ExpiryEstimated
+		}
+		return merged
+	}
+	return in
+}
+
+// convertTo expresses in's quantity in existing's unit.
+func convertTo(in, existing Item) (float64, bool) {
+	switch {
+	case in.Quantity == 0:
+		return 0, true
+	case existing.Quantity == 0 && existing.Unit == "":
+		return in.Quantity, true
+	case in.Unit == existing.Unit:
+		return in.Quantity, true
+	}
+	q, err := units.Convert(units.Quantity{Amount: in.Quantity, Unit: in.Unit}, existing.Unit, in.Canonical)
+	if err != nil {
+		return 0, false
+	}
+	return q.Amount, true
+}
+
+// shelfLife is roughly how many days an ingredient keeps once bought, stored
+// the usual way (meat, dairy and fresh produce refrigerated).
+var shelfLife = map[string]int{
+	"chicken": 2, "ground beef": 2, "beef": 4, "pork": 4, "goat meat": 3,
+	"fish": 2, "shrimp": 2, "sausage": 7, "bacon": 7,
+	"milk": 7, "heavy cream": 7, "yogurt": 14, "cheese": 21, "butter": 30, "egg": 28, "tofu": 5,
+	"spinach": 5, "lettuce": 7, "tomato": 7, "bell pepper": 10, "red bell pepper": 10,
+	"scotch bonnet pepper": 14, "chili pepper": 14, "onion": 30, "spring onion": 7,
+	"garlic": 90, "ginger": 21, "potato": 30, "sweet potato": 21, "yam": 21,
+	"carrot": 21, "cabbage": 14, "cucumber": 7, "zucchini": 7, "eggplant": 7,
+	"broccoli": 5, "mushroom": 5, "okra": 4, "coriander": 7, "parsley": 7, "basil": 5,
+	"plantain": 7, "banana": 5, "apple": 30, "avocado": 4, "lemon": 21, "lime": 21,
+	"orange": 21, "strawberry": 4, "bread": 5,
+	"rice": 365, "pasta": 365, "all-purpose flour": 240, "bean": 365, "oats": 365, "sugar": 730,
+}
+
+// ShelfLife returns how many days the ingredient typically keeps. Names we
+// don't know fall back to the longest known name they end with, so "red
+// onion" keeps like onion.
+func ShelfLife(name string) (int, bool) {
+	name = ingredient.Normalize(name)
+	words := strings.Fields(name)
+	for i := range words {
+		if days, ok := shelfLife[strings.Join(words[i:], " ")]; ok {
+			return days, true
+		}
+	}
+	return 0, false
+}
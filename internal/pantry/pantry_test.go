@@ -0,0 +1,111 @@
+package pantry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func date(day int) *time.Time {
+	t := time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestPrepare(t *testing.T) {
+	item := FromIngredient(ingredient.Parse("2 red onions"), "manual")
+	item.Prepare(now)
+	assert.Equal(t, "red onion", item.Canonical)
+	assert.Equal(t, 2.0, item.Quantity)
+	assert.True(t, item.ExpiryEstimated)
+	assert.Equal(t, now.AddDate(0, 0, 30), *item.ExpiresAt)
+
+	milk := Item{Name: "milk", PurchasedAt: date(15)}
+	milk.Prepare(now)
+	assert.Equal(t, *date(22), *milk.ExpiresAt)
+	days, ok := milk.DaysLeft(now)
+	assert.True(t, ok)
+	assert.Equal(t, 2, days)
+	assert.False(t, milk.Expired(now))
+	assert.True(t, milk.Expired(date(23).Add(time.Hour)))
+
+	// An expiry the user gave is kept.
+	chicken := Item{Name: "chicken thighs", ExpiresAt: date(25)}
+	chicken.Prepare(now)
+	assert.Equal(t, *date(25), *chicken.ExpiresAt)
+	assert.False(t, chicken.ExpiryEstimated)
+
+	mystery := Item{Name: "ofada sauce"}
+	mystery.Prepare(now)
+	assert.Nil(t, mystery.ExpiresAt)
+}
+
+func TestMerge(t *testing.T) {
+	rice := Item{ID: "1", Name: "rice", Canonical: "rice", Quantity: 1, Unit: "kg", ExpiresAt: date(30)}
+	onions := Item{ID: "2", Name: "onions", Canonical: "onion"}
+	existing := []Item{rice, onions}
+
+	merged := Merge(existing, Item{Name: "rice", Canonical: "rice", Quantity: 500, Unit: "g", ExpiresAt: date(25)})
+	assert.Equal(t, "1", merged.ID)
+	assert.Equal(t, 1.5, merged.Quantity)
+	assert.Equal(t, *date(25), *merged.ExpiresAt)
+
+	// Detected items carry no quantity and just confirm what's there.
+	merged = Merge(existing, Item{Name: "rice", Canonical: "rice"})
+	assert.Equal(t, 1.0, merged.Quantity)
+
+	merged = Merge(existing, Item{Name: "onion", Canonical: "onion", Quantity: 3})
+	assert.Equal(t, "2", merged.ID)
+	assert.Equal(t, 3.0, merged.Quantity)
+
+	// Bags of rice can't be added to kilograms, so they're kept apart.
+	merged = Merge(existing, Item{Name: "rice", Canonical: "rice", Quantity: 1, Unit: "bag"})
+	assert.Empty(t, merged.ID)
+
+	merged = Merge(existing, Item{Name: "garlic", Canonical: "garlic"})
+	assert.Empty(t, merged.ID)
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	salt, err := s.Put("ada", Item{Name: "salt"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, salt.ID)
+	_, err = s.Put("ada", Item{Name: "milk", ExpiresAt: date(22)})
+	assert.NoError(t, err)
+	_, err = s.Put("ada", Item{Name: "chicken", ExpiresAt: date(20)})
+	assert.NoError(t, err)
+
+	items, err := s.List("ada")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, []string{"chicken", "milk", "salt"}, []string{items[0].Name, items[1].Name, items[2].Name})
+	}
+
+	salt.Quantity = 1
+	salt.Unit = "kg"
+	_, err = s.Put("ada", salt)
+	assert.NoError(t, err)
+	got, err := s.Get("ada", salt.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, got.Quantity)
+
+	assert.NoError(t, s.Delete("ada", salt.ID))
+	_, err = s.Get("ada", salt.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete("ada", salt.ID), ErrNotFound)
+
+	items, err = s.List("bola")
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	users, err := s.Users()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ada"}, users)
+}
@@ -0,0 +1,102 @@
+package pantry
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("pantry item not found")
+
+// Store keeps each user's pantry.
+type Store interface {
+	// List returns the pantry, soonest to expire first.
+	List(userID string) ([]Item, error)
+	Get(userID, id string) (Item, error)
+	// Put saves an item, giving it an ID if it doesn't have one yet.
+	Put(userID string, item Item) (Item, error)
+	Delete(userID, id string) error
+	// Users lists everyone who has a pantry.
+	Users() ([]string, error)
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+func itemsPath(userID string) []string { return []string{"pantry", userID} }
+
+func (s *BoltStore) List(userID string) ([]Item, error) {
+	items := []Item{}
+	err := s.db.Each(itemsPath(userID), func(key string, data []byte) error {
+		var item Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortByExpiry(items)
+	return items, nil
+}
+
+func (s *BoltStore) Get(userID, id string) (Item, error) {
+	var item Item
+	err := s.db.Get(itemsPath(userID), id, &item)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Item{}, ErrNotFound
+	}
+	return item, err
+}
+
+func (s *BoltStore) Put(userID string, item Item) (Item, error) {
+	if item.ID == "" {
+		id, err := storage.NewID()
+		if err != nil {
+			return Item{}, err
+		}
+		item.ID = id
+	}
+	if err := s.db.Put(itemsPath(userID), item.ID, item); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+func (s *BoltStore) Delete(userID, id string) error {
+	err := s.db.Delete(itemsPath(userID), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *BoltStore) Users() ([]string, error) {
+	return s.db.Buckets([]string{"pantry"})
+}
+
+// SortByExpiry orders items soonest to expire first, with items of unknown
+// expiry last and alphabetical among themselves.
+func SortByExpiry(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].ExpiresAt, items[j].ExpiresAt
+		switch {
+		case a != nil && b != nil && !a.Equal(*b):
+			return a.Before(*b)
+		case a != nil && b == nil:
+			return true
+		case a == nil && b != nil:
+			return false
+		}
+		return items[i].Name < items[j].Name
+	})
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate requests
+// per second. A limit with no burst doesn't limit anything.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every allows n requests per period, in bursts of up to burst.
+func Every(n int, per time.Duration, burst int) Limit {
+	return Limit{Rate: float64(n) / per.Seconds(), Burst: burst}
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Burst <= 0
+}
+
+var periods = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+}
+
+// ParseLimit reads a limit such as "10/m" or "100/h,20": a number of
+// requests per second, minute, hour or day, optionally followed by the
+// burst. The burst defaults to the number of requests, and "off" turns the
+// limit off.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return Limit{}, nil
+	}
+	spec, burstText, hasBurst := strings.Cut(s, ",")
+	countText, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 10/m or 100/h,20", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(countText))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: count must be a positive number", s)
+	}
+	per, ok := periods[strings.TrimSpace(period)]
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be s, m, h or d", s)
+	}
+	burst := n
+	if hasBurst {
+		if burst, err = strconv.Atoi(strings.TrimSpace(burstText)); err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive number", s)
+		}
+	}
+	return Every(n, per, burst), nil
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a request would be allowed; zero when
+	// this one was.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// State is one bucket. Stores keep a State per key and call Take on it, so a
+// shared store only needs to load and save it atomically.
+type State struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// Take refills the bucket for the time since it was last used and takes a
+// token from it if there is one.
+func (s *State) Take(l Limit, now time.Time) Result {
+	if s.Updated.IsZero() {
+		s.Tokens = float64(l.Burst)
+	} else if elapsed := now.Sub(s.Updated).Seconds(); elapsed > 0 {
+		s.Tokens = math.Min(float64(l.Burst), s.Tokens+elapsed*l.Rate)
+	}
+	s.Updated = now
+
+	r := Result{Limit: l.Burst}
+	if s.Tokens >= 1 {
+		s.Tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = seconds((1 - s.Tokens) / l.Rate)
+	}
+	r.Remaining = int(s.Tokens)
+	r.Reset = seconds((float64(l.Burst) - s.Tokens) / l.Rate)
+	return r
+}
+
+// Full reports whether the bucket will have refilled completely by now, at
+// which point it is the same as a new one and can be forgotten.
+func (s State) Full(l Limit, now time.Time) bool {
+	return s.Tokens+now.Sub(s.Updated).Seconds()*l.Rate >= float64(l.Burst)
+}
+
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+// Store holds the buckets. MemoryStore keeps them in the process; replicas
+// that should share limits need a Store backed by something they all reach,
+// such as Redis, that runs State.Take atomically per key.
+type Store interface {
+	Take(key string, l Limit, now time.Time) (Result, error)
+}
+
+// Rule limits a group of routes. Paths are route paths as registered,
+// optionally preceded by a method ("POST /meal-plans"), with a trailing *
+// matching any route that starts with the rest.
+type Rule struct {
+	Name  string
+	Paths []string
+	Limit Limit
+}
+
+func (r Rule) Matches(method, path string) bool {
+	for _, p := range r.Paths {
+		if m, rest, ok := strings.Cut(p, " "); ok {
+			if m != method {
+				continue
+			}
+			p = rest
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(path, prefix) {
+			return true
+		}
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Limiter picks the first rule that matches a route, falling back to
+// Default, and takes a token from the caller's bucket for that rule.
+type Limiter struct {
+	Store   Store
+	Rules   []Rule
+	Default Limit
+}
+
+// Rule returns the rule for a route.
+func (l *Limiter) Rule(method, path string) Rule {
+	for _, r := range l.Rules {
+		if r.Matches(method, path) {
+			return r
+		}
+	}
+	return Rule{Name: "default", Limit: l.Default}
+}
+
+// Allow takes a token for the caller on a route. Callers are identified by
+// whatever the caller passes as who, such as "ip:1.2.3.4" or "user:42".
+// ok is false when the route is not limited.
+func (l *Limiter) Allow(method, path, who string, now time.Time) (Result, bool, error) {
+	rule := l.Rule(method, path)
+	if rule.Limit.Unlimited() {
+		return Result{}, false, nil
+	}
+	r, err := l.Store.Take(rule.Name+"|"+who, rule.Limit, now)
+	if err != nil {
+		return Result{}, false, err
+	}
+	return r, true, nil
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("10/m")
+	assert.NoError(t, err)
+	assert.Equal(t, Every(10, time.Minute, 10), l)
+
+	l, err = ParseLimit(" 100 / h , 20 ")
+	assert.NoError(t, err)
+	assert.Equal(t, Every(100, time.Hour, 20), l)
+
+	l, err = ParseLimit("off")
+	assert.NoError(t, err)
+	assert.True(t, l.Unlimited())
+
+	for _, bad := range []string{"", "10", "0/m", "10/w", "10/m,0", "x/m"} {
+		_, err := ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTake(t *testing.T) {
+	l := Every(60, time.Minute, 3)
+	var s State
+
+	for i := 2; i >= 0; i-- {
+		r := s.Take(l, start)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, i, r.Remaining)
+		assert.Equal(t, 3, r.Limit)
+	}
+	r := s.Take(l, start)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, time.Second, r.RetryAfter)
+	assert.Equal(t, 3*time.Second, r.Reset)
+
+	// Half a token isn't enough.
+	r = s.Take(l, start.Add(500*time.Millisecond))
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 500*time.Millisecond, r.RetryAfter)
+
+	r = s.Take(l, start.Add(time.Second))
+	assert.True(t, r.Allowed)
+
+	// Refilling stops at the burst.
+	r = s.Take(l, start.Add(time.Hour))
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 2, r.Remaining)
+}
+
+func TestLimiter(t *testing.T) {
+	store := NewMemoryStore()
+	l := &Limiter{
+		Store: store,
+		Rules: []Rule{
+			{Name: "health", Paths: []string{"/health"}},
+			{Name: "image", Paths: []string{"/detect-food", "/detect"}, Limit: Every(1, time.Minute, 1)},
+			{Name: "chat", Paths: []string{"/chat*"}, Limit: Every(2, time.Minute, 2)},
+			{Name: "plan", Paths: []string{"POST /meal-plans"}, Limit: Every(2, time.Hour, 1)},
+		},
+		Default: Every(5, time.Minute, 5),
+	}
+
+	assert.Equal(t, "image", l.Rule("POST", "/detect").Name)
+	assert.Equal(t, "chat", l.Rule("GET", "/chat/:id/messages").Name)
+	assert.Equal(t, "plan", l.Rule("POST", "/meal-plans").Name)
+	assert.Equal(t, "default", l.Rule("GET", "/meal-plans").Name)
+	assert.Equal(t, "default", l.Rule("GET", "/pantry").Name)
+
+	_, limited, err := l.Allow("POST", "/health", "ip:1.2.3.4", start)
+	assert.NoError(t, err)
+	assert.False(t, limited)
+
+	r, limited, _ := l.Allow("POST", "/detect-food", "ip:1.2.3.4", start)
+	assert.True(t, limited)
+	assert.True(t, r.Allowed)
+	// Routes in one rule share a bucket.
+	r, _, _ = l.Allow("POST", "/detect", "ip:1.2.3.4", start)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, time.Minute, r.RetryAfter)
+	// Other callers and other rules have their own.
+	r, _, _ = l.Allow("POST", "/detect", "user:ada", start)
+	assert.True(t, r.Allowed)
+	r, _, _ = l.Allow("POST", "/pantry", "ip:1.2.3.4", start)
+	assert.True(t, r.Allowed)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := NewMemoryStore()
+	l := Every(60, time.Minute, 2)
+	s.Take("a", l, start)
+	s.Take("b", l, start)
+	assert.Equal(t, 2, s.Len())
+
+	// Both have refilled by the next sweep.
+	s.Take("c", l, start.Add(2*sweepEvery))
+	assert.Equal(t, 1, s.Len())
+}
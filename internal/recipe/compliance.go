@@ -0,0 +1,38 @@
+package recipe
+
+import "github.com/Oluwaseun241/mura/internal/ingredient"
+
+// Compliance compares a recipe's ingredients against what the user said they
+// have. Extras are split into pantry staples, which we assume the user has,
+// and missing ingredients they would need to buy.
+type Compliance struct {
+	Compliant bool     `json:"compliant"`
+	Used      []string `json:"used"`
+	Staples   []string `json:"pantry_staples"`
+	Missing   []string `json:"missing_ingredients"`
+}
+
+func Check(r *Recipe, have []string) Compliance {
+	c := Compliance{Used: []string{}, Staples: []string{}, Missing: []string{}}
+	for _, name := range r.IngredientNames() {
+		switch {
+		case contains(have, name):
+			c.Used = append(c.Used, name)
+		case ingredient.IsStaple(name):
+			c.Staples = append(c.Staples, name)
+		default:
+			c.Missing = append(c.Missing, name)
+		}
+	}
+	c.Compliant = len(c.Missing) == 0
+	return c
+}
+
+func contains(have []string, name string) bool {
+	for _, h := range have {
+		if ingredient.Same(h, name) {
+			return true
+		}
+	}
+	return false
+}
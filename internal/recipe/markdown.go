@@ -0,0 +1,70 @@
+package recipe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the recipe in the markdown layout the web client has
+// always displayed.
+func (r *Recipe) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	if r.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", r.Description)
+	}
+
+	var facts []string
+	if r.Servings > 0 {
+		facts = append(facts, fmt.Sprintf("**Serves:** %d", r.Servings))
+	}
+	if r.PrepTime > 0 {
+		facts = append(facts, fmt.Sprintf("**Prep time:** %d min", r.PrepTime))
+	}
+	if r.CookTime > 0 {
+		facts = append(facts, fmt.Sprintf("**Cook time:** %d min", r.CookTime))
+	}
+	if len(facts) > 0 {
+		fmt.Fprintf(&b, "%s\n\n", strings.Join(facts, " · "))
+	}
+
+	if len(r.Equipment) > 0 {
+		b.WriteString("## Equipment\n\n")
+		for _, e := range r.Equipment {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Ingredients\n\n")
+	for _, item := range r.Ingredients {
+		fmt.Fprintf(&b, "- %s\n", item)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Instructions\n\n")
+	for i, step := range r.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	b.WriteString("\n")
+
+	if len(r.Tips) > 0 {
+		b.WriteString("## Chef's Tips\n\n")
+		for _, tip := range r.Tips {
+			fmt.Fprintf(&b, "- %s\n", tip)
+		}
+		b.WriteString("\n")
+	}
+
+	n := r.Nutrition
+	if n != (Nutrition{}) {
+		b.WriteString("## Nutrition (per serving)\n\n")
+		fmt.Fprintf(&b, "- **Calories:** %.0f kcal\n", n.Calories)
+		fmt.Fprintf(&b, "- **Protein:** %.0f g\n", n.Protein)
+		fmt.Fprintf(&b, "- **Carbs:** %.0f g\n", n.Carbs)
+		fmt.Fprintf(&b, "- **Fat:** %.0f g\n", n.Fat)
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
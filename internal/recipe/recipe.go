@@ -0,0 +1,94 @@
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+type Nutrition struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein_g"`
+	Carbs    float64 `json:"carbs_g"`
+	Fat      float64 `json:"fat_g"`
+}
+
+type Recipe struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Cuisine     string            `json:"cuisine,omitempty"`
+	Servings    int               `json:"servings,omitempty"`
+	PrepTime    int               `json:"prep_time_minutes,omitempty"`
+	CookTime    int               `json:"cook_time_minutes,omitempty"`
+	Equipment   []string          `json:"equipment,omitempty"`
+	Ingredients []ingredient.Item `json:"ingredients"`
+	Steps       []string          `json:"steps"`
+	Tips        []string          `json:"tips,omitempty"`
+	Nutrition   Nutrition         `json:"nutrition"`
+}
+
+// Schema is the JSON shape we ask the model to answer in. Ingredients come
+// back as plain lines and are structured with ingredient.Parse.
+const Schema = `{"title": string, "description": string, "cuisine": string, "servings": number, "prep_time_minutes": number, "cook_time_minutes": number, "equipment": [string], "ingredients": ["quantity unit ingredient, preparation"], "steps": [string], "tips": [string], "nutrition": {"calories": number, "protein_g": number, "carbs_g": number, "fat_g": number}} where nutrition is per serving`
+
+// Parse decodes a recipe in the Schema format.
+func Parse(data []byte) (*Recipe, error) {
+	var raw struct {
+		Recipe
+		Ingredients []string `json:"ingredients"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing recipe: %v", err)
+	}
+
+	r := raw.Recipe
+	r.Ingredients = make([]ingredient.Item, 0, len(raw.Ingredients))
+	for _, line := range raw.Ingredients {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		r.Ingredients = append(r.Ingredients, ingredient.Parse(line))
+	}
+
+	if r.Title == "" || len(r.Ingredients) == 0 || len(r.Steps) == 0 {
+		return nil, fmt.Errorf("incomplete recipe: missing title, ingredients or steps")
+	}
+	return &r, nil
+}
+
+// IngredientNames returns the canonical names of the recipe's ingredients.
+func (r *Recipe) IngredientNames() []string {
+	return ingredient.Names(r.Ingredients)
+}
+
+// Copy returns a copy of the recipe that can be changed, such as by
+// Localize, without changing r.
+func (r *Recipe) Copy() *Recipe {
+	c := *r
+	c.Equipment = append([]string(nil), r.Equipment...)
+	c.Ingredients = append([]ingredient.Item(nil), r.Ingredients...)
+	c.Steps = append([]string(nil), r.Steps...)
+	c.Tips = append([]string(nil), r.Tips...)
+	return &c
+}
+
+// Localize converts ingredient quantities and any measurements or oven
+// temperatures mentioned in the steps and tips into the locale's units.
+func (r *Recipe) Localize(loc units.Locale) {
+	for i, item := range r.Ingredients {
+		if _, ok := units.LookupUnit(item.Unit); !ok || item.Quantity == 0 {
+			continue
+		}
+		q := units.Normalize(units.Quantity{Amount: item.Quantity, Max: item.MaxQuantity, Unit: item.Unit}, loc.System, item.Name)
+		r.Ingredients[i].Quantity, r.Ingredients[i].MaxQuantity, r.Ingredients[i].Unit = q.Amount, q.Max, q.Unit
+	}
+	for i, step := range r.Steps {
+		r.Steps[i] = loc.ConvertText(step)
+	}
+	for i, tip := range r.Tips {
+		r.Tips[i] = loc.ConvertText(tip)
+	}
+}
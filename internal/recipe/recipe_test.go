@@ -0,0 +1,106 @@
+package recipe
+
+import (
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/units"
+	"github.com/stretchr/testify/assert"
+)
+
+const jollof = `{
+	"title": "Jollof Rice",
+	"description": "Smoky party-style jollof.",
+	"servings": 4,
+	"prep_time_minutes": 15,
+	"cook_time_minutes": 45,
+	"ingredients": ["2 cups long grain rice", "4 large tomatoes, blended", "1 red bell pepper", "2 tbsp vegetable oil", "1 tsp salt", "1 bay leaf", "500g chicken thighs"],
+	"steps": ["Fry the tomato base in the oil for 20 minutes.", "Add the rice and 3 cups of water, then cover and cook on low heat."],
+	"nutrition": {"calories": 520, "protein_g": 28, "carbs_g": 70, "fat_g": 14}
+}`
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(jollof))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Jollof Rice", r.Title)
+	assert.Len(t, r.Ingredients, 7)
+	assert.Equal(t, "tomato", r.Ingredients[1].Canonical)
+	assert.Equal(t, "blended", r.Ingredients[1].Preparation)
+
+	_, err = Parse([]byte(`{"title": "Nothing"}`))
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	r, _ := Parse([]byte(jollof))
+
+	c := Check(r, []string{"rice", "tomatoes", "chicken"})
+	assert.False(t, c.Compliant)
+	assert.Equal(t, []string{"long grain rice", "tomato", "chicken thigh"}, c.Used)
+	assert.Equal(t, []string{"oil", "salt"}, c.Staples)
+	assert.Equal(t, []string{"red bell pepper", "bay leaf"}, c.Missing)
+
+	c = Check(r, []string{"rice", "tomatoes", "chicken", "tatashe", "bay leaves"})
+	assert.True(t, c.Compliant)
+}
+
+func TestLocalizeAndMarkdown(t *testing.T) {
+	r, _ := Parse([]byte(jollof))
+	r.Localize(units.Locale{System: units.Metric})
+
+	md := r.Markdown()
+	assert.Contains(t, md, "# Jollof Rice")
+	assert.Contains(t, md, "**Serves:** 4 · **Prep time:** 15 min · **Cook time:** 45 min")
+	assert.Contains(t, md, "- 370 g long grain rice")
+	assert.Contains(t, md, "- 2 tbsp vegetable oil")
+	assert.Contains(t, md, "2. Add the rice and 710 ml of water")
+	assert.Contains(t, md, "- **Calories:** 520 kcal")
+}
+
+func TestCompare(t *testing.T) {
+	before, _ := Parse([]byte(jollof))
+	after, _ := Parse([]byte(`{
+		"title": "Lighter Jollof Rice",
+		"prep_time_minutes": 15,
+		"cook_time_minutes": 40,
+		"ingredients": ["2 cups brown rice", "4 large tomatoes, blended", "1 red bell pepper", "1 tbsp vegetable oil", "1 tsp salt", "1 bay leaf", "500g chicken breast"],
+		"steps": ["Fry the tomato base in the oil for 15 minutes.", "Add the rice and 3 cups of water, then cover and cook on low heat.", "Serve with steamed vegetables."],
+		"nutrition": {"calories": 410, "protein_g": 34, "carbs_g": 62, "fat_g": 7}
+	}`))
+
+	d := Compare(before, after)
+	assert.Equal(t, []string{"brown rice", "chicken breast"}, names(d.Added))
+	assert.Equal(t, []string{"long grain rice", "chicken thigh"}, names(d.Removed))
+	if assert.Len(t, d.Changed, 1) {
+		assert.Equal(t, "oil", d.Changed[0].After.Canonical)
+		assert.Equal(t, 1.0, d.Changed[0].After.Quantity)
+	}
+	assert.Equal(t, []StepChange{
+		{Op: "changed", Before: "Fry the tomato base in the oil for 20 minutes.", After: "Fry the tomato base in the oil for 15 minutes."},
+		{Op: "added", After: "Serve with steamed vegetables."},
+	}, d.Steps)
+	assert.Equal(t, Nutrition{Calories: -110, Protein: 6, Carbs: -8, Fat: -7}, d.NutritionDelta)
+	assert.Equal(t, -5, d.TimeDelta)
+}
+
+func TestSchemaJSON(t *testing.T) {
+	r, _ := Parse([]byte(jollof))
+	data, err := r.SchemaJSON()
+	assert.NoError(t, err)
+
+	again, err := Parse(data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, r.IngredientNames(), again.IngredientNames())
+		assert.Equal(t, r.Steps, again.Steps)
+	}
+}
+
+func names(items []ingredient.Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Canonical)
+	}
+	return out
+}
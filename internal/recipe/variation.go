@@ -0,0 +1,179 @@
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+)
+
+// Variations maps each supported transformation to the instruction we give
+// the model when rewriting a recipe.
+var Variations = map[string]string{
+	"lighter":      "Make it lighter: cut calories and saturated fat by swapping cooking methods and ingredients, while keeping the character of the dish.",
+	"high-protein": "Make it high-protein: raise the protein per serving substantially with lean proteins, legumes, eggs or dairy, without making it heavier overall.",
+	"30-minute":    "Make it a 30-minute recipe: the total prep and cook time must be 30 minutes or less. Use shortcuts, quicker cuts and faster cooking methods.",
+	"budget":       "Make it budget-friendly: swap expensive ingredients for cheaper, widely available ones and stretch the protein, keeping the servings the same.",
+	"kid-friendly": "Make it kid-friendly: milder spice, familiar flavours and textures, bite-sized pieces, and note any steps children can help with.",
+	"spicier":      "Make it spicier: add more heat and depth with chili, pepper and spices suited to the cuisine, and say how to adjust the heat.",
+	"one-pot":      "Make it a one-pot recipe: everything must be cooked in a single pot or pan, reordering steps so ingredients go in at the right time.",
+}
+
+// VariationNames lists the supported transformations in a stable order.
+func VariationNames() []string {
+	names := make([]string, 0, len(Variations))
+	for name := range Variations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SchemaJSON encodes the recipe in the Schema format we ask the model to
+// answer in, so a rewrite can start from exactly what the user has.
+func (r *Recipe) SchemaJSON() ([]byte, error) {
+	lines := make([]string, 0, len(r.Ingredients))
+	for _, item := range r.Ingredients {
+		lines = append(lines, item.String())
+	}
+	out := struct {
+		*Recipe
+		Ingredients []string `json:"ingredients"`
+	}{r, lines}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding recipe: %v", err)
+	}
+	return data, nil
+}
+
+type IngredientChange struct {
+	Before ingredient.Item `json:"before"`
+	After  ingredient.Item `json:"after"`
+}
+
+type StepChange struct {
+	Op     string `json:"op"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Diff describes how a variation differs from the recipe it started from.
+type Diff struct {
+	Added          []ingredient.Item  `json:"ingredients_added"`
+	Removed        []ingredient.Item  `json:"ingredients_removed"`
+	Changed        []IngredientChange `json:"ingredients_changed"`
+	Steps          []StepChange       `json:"steps_changed"`
+	NutritionDelta Nutrition          `json:"nutrition_delta"`
+	TimeDelta      int                `json:"total_time_delta_minutes"`
+}
+
+// Compare diffs two versions of a recipe. Ingredients are matched by
+// canonical name, steps by their position in the longest common sequence of
+// unchanged steps.
+func Compare(before, after *Recipe) Diff {
+	d := Diff{
+		Added:   []ingredient.Item{},
+		Removed: []ingredient.Item{},
+		Changed: []IngredientChange{},
+		NutritionDelta: Nutrition{
+			Calories: after.Nutrition.Calories - before.Nutrition.Calories,
+			Protein:  after.Nutrition.Protein - before.Nutrition.Protein,
+			Carbs:    after.Nutrition.Carbs - before.Nutrition.Carbs,
+			Fat:      after.Nutrition.Fat - before.Nutrition.Fat,
+		},
+		TimeDelta: (after.PrepTime + after.CookTime) - (before.PrepTime + before.CookTime),
+	}
+
+	matched := make([]bool, len(after.Ingredients))
+	for _, b := range before.Ingredients {
+		found := false
+		for j, a := range after.Ingredients {
+			if matched[j] || canonical(a) != canonical(b) {
+				continue
+			}
+			matched[j], found = true, true
+			if a.Quantity != b.Quantity || a.MaxQuantity != b.MaxQuantity || a.Unit != b.Unit {
+				d.Changed = append(d.Changed, IngredientChange{Before: b, After: a})
+			}
+			break
+		}
+		if !found {
+			d.Removed = append(d.Removed, b)
+		}
+	}
+	for j, a := range after.Ingredients {
+		if !matched[j] {
+			d.Added = append(d.Added, a)
+		}
+	}
+
+	d.Steps = diffSteps(before.Steps, after.Steps)
+	return d
+}
+
+func canonical(item ingredient.Item) string {
+	if item.Canonical != "" {
+		return item.Canonical
+	}
+	return ingredient.Normalize(item.Name)
+}
+
+func diffSteps(before, after []string) []StepChange {
+	same := func(a, b string) bool {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:].
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if same(before[i], after[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := []StepChange{}
+	var removed, added []string
+	flush := func() {
+		// A removal next to an addition is an edited step.
+		n := min(len(removed), len(added))
+		for k := 0; k < n; k++ {
+			changes = append(changes, StepChange{Op: "changed", Before: removed[k], After: added[k]})
+		}
+		for _, s := range removed[n:] {
+			changes = append(changes, StepChange{Op: "removed", Before: s})
+		}
+		for _, s := range added[n:] {
+			changes = append(changes, StepChange{Op: "added", After: s})
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && same(before[i], after[j]):
+			flush()
+			i++
+			j++
+		case j == len(after) || (i < len(before) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, before[i])
+			i++
+		default:
+			added = append(added, after[j])
+			j++
+		}
+	}
+	flush()
+	return changes
+}
@@ -0,0 +1,162 @@
+package saved
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/service"
+)
+
+// Recipe is a recipe a user kept. Recipes from /recipe are structured;
+// recipes from /detect-food only exist as the markdown we showed, so one of
+// Recipe or Markdown is set.
+type Recipe struct {
+	ID        string                 `json:"id"`
+	Title     string                 `json:"title"`
+	Recipe    *recipe.Recipe         `json:"recipe,omitempty"`
+	Markdown  string                 `json:"markdown,omitempty"`
+	Dish      string                 `json:"dish,omitempty"`
+	ImageURL  string                 `json:"image_url,omitempty"`
+	Videos    []service.YouTubeVideo `json:"videos,omitempty"`
+	Source    string                 `json:"source,omitempty"`
+	Tags      []string               `json:"tags"`
+	Notes     string                 `json:"notes,omitempty"`
+	Favourite bool                   `json:"favourite"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// Text returns the recipe as markdown, however it was saved.
+func (r *Recipe) Text() string {
+	if r.Recipe != nil {
+		return r.Recipe.Markdown()
+	}
+	return r.Markdown
+}
+
+// Prepare fills in what a recipe being saved may be missing: a title taken
+// from the recipe, the dish or the first markdown heading, tidy tags and
+// timestamps.
+func (r *Recipe) Prepare(now time.Time) {
+	if r.Title == "" && r.Recipe != nil {
+		r.Title = r.Recipe.Title
+	}
+	if r.Title == "" {
+		r.Title = r.Dish
+	}
+	if r.Title == "" {
+		for _, line := range strings.Split(r.Markdown, "\n") {
+			if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "#"); ok {
+				if r.Title = strings.TrimSpace(strings.Trim(heading, "#* ")); r.Title != "" {
+					break
+				}
+			}
+		}
+	}
+	r.Title = strings.TrimSpace(r.Title)
+	r.Tags = NormalizeTags(r.Tags)
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	r.UpdatedAt = now
+}
+
+// NormalizeTags lower-cases tags and drops blanks and repeats, keeping the
+// order they were given in.
+func NormalizeTags(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(strings.ToLower(t)), " ")
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// Query filters a user's saved recipes. Text matches words in the title,
+// dish, notes, tags and ingredients; every word has to match somewhere.
+// Empty fields don't filter.
+type Query struct {
+	Text      string
+	Tag       string
+	Favourite bool
+}
+
+// Match reports whether a saved recipe passes the query. Stores that can't
+// filter themselves apply it to every recipe.
+func (q Query) Match(r *Recipe) bool {
+	if q.Favourite && !r.Favourite {
+		return false
+	}
+	if tag := strings.ToLower(strings.TrimSpace(q.Tag)); tag != "" {
+		found := false
+		for _, t := range r.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(q.Text))
+	if len(words) == 0 {
+		return true
+	}
+	haystack := strings.ToLower(strings.Join(append([]string{r.Title, r.Dish, r.Notes, r.Markdown}, r.Tags...), " "))
+	var ingredients []ingredient.Item
+	if r.Recipe != nil {
+		ingredients = r.Recipe.Ingredients
+	}
+	for _, w := range words {
+		if strings.Contains(haystack, w) {
+			continue
+		}
+		found := false
+		for _, item := range ingredients {
+			if strings.Contains(strings.ToLower(item.Name), w) || ingredient.Same(item.Name, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Tags counts how often each tag is used, most used first.
+func Tags(recipes []Recipe) []TagCount {
+	counts := map[string]int{}
+	for _, r := range recipes {
+		for _, t := range r.Tags {
+			counts[t]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		out = append(out, TagCount{Tag: t, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
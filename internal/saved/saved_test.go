@@ -0,0 +1,105 @@
+package saved
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"weeknight", "one pot"}, NormalizeTags([]string{" Weeknight", "", "one   POT", "weeknight"}))
+	assert.Equal(t, []string{}, NormalizeTags(nil))
+}
+
+func TestPrepare(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	r := &Recipe{Markdown: "Here you go!\n\n## **Egusi Soup**\n\n- 1 cup egusi", Tags: []string{"Soup"}}
+	r.Prepare(now)
+	assert.Equal(t, "Egusi Soup", r.Title)
+	assert.Equal(t, []string{"soup"}, r.Tags)
+	assert.Equal(t, now, r.CreatedAt)
+
+	r = &Recipe{Recipe: &recipe.Recipe{Title: "Suya"}, Dish: "beef suya"}
+	r.Prepare(now)
+	assert.Equal(t, "Suya", r.Title)
+
+	r = &Recipe{Dish: "fried plantain", CreatedAt: now}
+	r.Prepare(now.Add(time.Hour))
+	assert.Equal(t, "fried plantain", r.Title)
+	assert.Equal(t, now, r.CreatedAt)
+	assert.Equal(t, now.Add(time.Hour), r.UpdatedAt)
+}
+
+func TestQuery(t *testing.T) {
+	jollof := &Recipe{
+		Title: "Party jollof",
+		Recipe: &recipe.Recipe{
+			Title:       "Party jollof",
+			Ingredients: []ingredient.Item{ingredient.Parse("2 cups long grain rice"), ingredient.Parse("3 tomatoes")},
+		},
+		Tags:      []string{"nigerian", "party"},
+		Notes:     "Use more scotch bonnet",
+		Favourite: true,
+	}
+	pasta := &Recipe{Title: "Spaghetti", Markdown: "# Spaghetti\n\n- 200g spaghetti\n- 2 cloves garlic", Tags: []string{"quick"}}
+
+	assert.True(t, Query{}.Match(pasta))
+	assert.True(t, Query{Text: "JOLLOF"}.Match(jollof))
+	assert.True(t, Query{Text: "tomato rice"}.Match(jollof))
+	assert.True(t, Query{Text: "scotch"}.Match(jollof))
+	assert.False(t, Query{Text: "jollof garlic"}.Match(jollof))
+	assert.True(t, Query{Text: "garlic"}.Match(pasta))
+	assert.True(t, Query{Tag: "Party"}.Match(jollof))
+	assert.False(t, Query{Tag: "part"}.Match(jollof))
+	assert.True(t, Query{Favourite: true}.Match(jollof))
+	assert.False(t, Query{Favourite: true}.Match(pasta))
+}
+
+func TestTags(t *testing.T) {
+	counts := Tags([]Recipe{
+		{Tags: []string{"quick", "vegan"}},
+		{Tags: []string{"quick"}},
+		{Tags: []string{"baking"}},
+	})
+	assert.Equal(t, []TagCount{{"quick", 2}, {"baking", 1}, {"vegan", 1}}, counts)
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	first := &Recipe{Title: "Moi moi", Tags: []string{"steamed"}, CreatedAt: now}
+	second := &Recipe{Title: "Akara", Tags: []string{"fried"}, Favourite: true, CreatedAt: now.Add(time.Hour)}
+	assert.NoError(t, s.Save("ada", first))
+	assert.NoError(t, s.Save("ada", second))
+	assert.NotEmpty(t, first.ID)
+
+	all, err := s.List("ada", Query{})
+	assert.NoError(t, err)
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, "Akara", all[0].Title)
+	}
+	favs, err := s.List("ada", Query{Favourite: true})
+	assert.NoError(t, err)
+	assert.Len(t, favs, 1)
+	none, err := s.List("bob", Query{})
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+
+	first.Notes = "Add eggs"
+	assert.NoError(t, s.Save("ada", first))
+	got, err := s.Get("ada", first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Add eggs", got.Notes)
+
+	assert.NoError(t, s.Delete("ada", first.ID))
+	_, err = s.Get("ada", first.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete("ada", first.ID), ErrNotFound)
+}
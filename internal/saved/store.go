@@ -0,0 +1,80 @@
+package saved
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("saved recipe not found")
+
+// Store keeps each user's saved recipes. Query is part of List so that a
+// database-backed store can filter in the query rather than in Go.
+type Store interface {
+	// List returns the user's recipes that match q, most recently saved
+	// first.
+	List(userID string, q Query) ([]Recipe, error)
+	Get(userID, id string) (*Recipe, error)
+	// Save stores a recipe, giving it an ID if it doesn't have one yet.
+	Save(userID string, r *Recipe) error
+	Delete(userID, id string) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+func recipesPath(userID string) []string { return []string{"saved", userID} }
+
+func (s *BoltStore) List(userID string, q Query) ([]Recipe, error) {
+	recipes := []Recipe{}
+	err := s.db.Each(recipesPath(userID), func(key string, data []byte) error {
+		var r Recipe
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		if q.Match(&r) {
+			recipes = append(recipes, r)
+		}
+		return nil
+	})
+	sort.SliceStable(recipes, func(i, j int) bool { return recipes[i].CreatedAt.After(recipes[j].CreatedAt) })
+	return recipes, err
+}
+
+func (s *BoltStore) Get(userID, id string) (*Recipe, error) {
+	var r Recipe
+	err := s.db.Get(recipesPath(userID), id, &r)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *BoltStore) Save(userID string, r *Recipe) error {
+	if r.ID == "" {
+		id, err := storage.NewID()
+		if err != nil {
+			return err
+		}
+		r.ID = id
+	}
+	return s.db.Put(recipesPath(userID), r.ID, r)
+}
+
+func (s *BoltStore) Delete(userID, id string) error {
+	err := s.db.Delete(recipesPath(userID), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package search
+
+import "strings"
+
+// The dietary flags a recipe can have, named as in the substitutions table.
+const (
+	Vegetarian = "vegetarian"
+	Vegan      = "vegan"
+	GlutenFree = "gluten-free"
+	DairyFree  = "dairy-free"
+	EggFree    = "egg-free"
+	NutFree    = "nut-free"
+)
+
+// Flags are all the dietary flags, in the order Diets lists them.
+var Flags = []string{Vegetarian, Vegan, GlutenFree, DairyFree, EggFree, NutFree}
+
+// A dietRule lists the words that rule a flag out. A word doesn't count when
+// the ingredient also has one of the except words, so "coconut milk" is
+// still dairy-free.
+type dietRule struct {
+	flag   string
+	words  []string
+	except []string
+}
+
+var meat = []string{
+	"beef", "chicken", "pork", "lamb", "goat", "mutton", "turkey", "duck", "bacon",
+	"ham", "sausage", "chorizo", "veal", "venison", "salami", "pepperoni",
+	"prosciutto", "meat", "oxtail", "tripe", "liver", "kidney", "gizzard", "shaki",
+	"suya", "gelatin", "gelatine", "lard", "pancetta", "mince",
+	"fish", "salmon", "tuna", "cod", "tilapia", "mackerel", "catfish", "sardine",
+	"anchovy", "prawn", "shrimp", "crab", "lobster", "crayfish", "mussel", "clam",
+	"oyster", "squid", "octopus", "stockfish", "scallop", "snail",
+}
+
+var dietRules = []dietRule{
+	{flag: Vegetarian, words: meat},
+	{flag: Vegan, words: meat},
+	{
+		flag:   DairyFree,
+		words:  []string{"milk", "butter", "cheese", "cream", "yogurt", "yoghurt", "ghee", "whey", "buttermilk", "parmesan", "mozzarella", "cheddar", "feta", "ricotta", "mascarpone", "custard"},
+		except: []string{"coconut", "almond", "soy", "oat", "rice", "cashew", "peanut", "shea", "cocoa", "vegan", "plant", "tartar"},
+	},
+	{flag: EggFree, words: []string{"egg", "mayonnaise", "mayo", "meringue"}, except: []string{"eggplant", "vegan"}},
+	{
+		flag:   GlutenFree,
+		words:  []string{"flour", "wheat", "bread", "breadcrumb", "pasta", "spaghetti", "noodle", "couscous", "semolina", "barley", "rye", "soy sauce", "beer", "seitan", "bulgur", "macaroni", "cracker", "biscuit", "panko", "puff pastry"},
+		except: []string{"rice", "corn", "cassava", "almond", "coconut", "chickpea", "gluten-free", "buckwheat", "tapioca", "plantain", "yam"},
+	},
+	{
+		flag:  NutFree,
+		words: []string{"almond", "cashew", "walnut", "pecan", "hazelnut", "pistachio", "macadamia", "peanut", "groundnut", "nut"},
+		// Coconut and nutmeg aren't tree nuts
+		except: []string{"coconut", "nutmeg", "butternut", "tiger nut"},
+	},
+}
+
+// veganAlso are animal products a vegetarian eats but a vegan doesn't.
+var veganAlso = []string{"honey"}
+
+// Diets works out which dietary flags a recipe has from its ingredient
+// names. It goes by names alone, so it's a filter to narrow a search, not a
+// guarantee. A recipe with no ingredients has no flags, since nothing is
+// known about it.
+func Diets(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	ruled := map[string]bool{}
+	for _, name := range names {
+		words := " " + strings.Join(terms(name), " ") + " "
+		for _, rule := range dietRules {
+			if has(words, rule.words) && !has(words, rule.except) {
+				ruled[rule.flag] = true
+			}
+		}
+		if has(words, veganAlso) {
+			ruled[Vegan] = true
+		}
+		// Anything not vegetarian isn't vegan, and dairy and eggs aren't
+		// vegan either
+		if ruled[Vegetarian] || ruled[DairyFree] || ruled[EggFree] {
+			ruled[Vegan] = true
+		}
+	}
+
+	var flags []string
+	for _, flag := range Flags {
+		if !ruled[flag] {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// has reports whether any of the words, as whole stemmed words, are in the
+// space-padded list of terms.
+func has(padded string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(padded, " "+strings.Join(terms(w), " ")+" ") {
+			return true
+		}
+	}
+	return false
+}
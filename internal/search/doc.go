@@ -0,0 +1,124 @@
+package search
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+)
+
+// Doc is a recipe as the index sees it. Minutes and Calories are zero when
+// the recipe doesn't say.
+type Doc struct {
+	ID          string
+	Source      string
+	Title       string
+	Cuisine     string
+	Tags        []string
+	Ingredients []string
+	Steps       []string
+	// Minutes is prep and cook time together
+	Minutes int
+	// Calories are per serving
+	Calories  float64
+	Diet      []string
+	CreatedAt time.Time
+}
+
+// FromRecipe makes a doc from a structured recipe.
+func FromRecipe(r *recipe.Recipe) Doc {
+	d := Doc{
+		Title:    r.Title,
+		Cuisine:  r.Cuisine,
+		Steps:    append(append([]string{r.Description}, r.Steps...), r.Tips...),
+		Minutes:  r.PrepTime + r.CookTime,
+		Calories: r.Nutrition.Calories,
+	}
+	names := make([]string, 0, len(r.Ingredients))
+	for _, item := range r.Ingredients {
+		d.Ingredients = append(d.Ingredients, item.Raw)
+		names = append(names, item.Name)
+	}
+	d.Diet = Diets(names)
+	return d
+}
+
+var (
+	listItem     = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+`)
+	boldLine     = regexp.MustCompile(`^\*\*([^*]+)\*\*:?\s*$`)
+	totalTime    = regexp.MustCompile(`(?i)total\s+time\**:?\**\s*(?:about\s+)?(\d+)\s*(?:-\s*\d+\s*)?(min|minute|hour|hr)`)
+	partTime     = regexp.MustCompile(`(?i)(?:prep(?:aration)?|cook(?:ing)?)\s+time\**:?\**\s*(?:about\s+)?(\d+)\s*(?:-\s*\d+\s*)?(min|minute|hour|hr)`)
+	caloriesLine = regexp.MustCompile(`(?i)calories\**:?\**\s*(?:about\s+|approximately\s+|~\s*)?(\d+(?:\.\d+)?)`)
+)
+
+// FromMarkdown makes a doc from a recipe written out in markdown, as
+// /detect-food returns them. List items under an ingredients heading are
+// ingredients; every other line counts as a step.
+func FromMarkdown(text string) Doc {
+	var d Doc
+	section := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if heading, ok := headingText(line); ok {
+			if d.Title == "" && strings.HasPrefix(line, "#") {
+				d.Title = heading
+			}
+			section = strings.ToLower(heading)
+			continue
+		}
+		item := listItem.ReplaceAllString(line, "")
+		item = strings.TrimSpace(strings.ReplaceAll(item, "**", ""))
+		if item == "" {
+			continue
+		}
+		if strings.Contains(section, "ingredient") && listItem.MatchString(line) {
+			d.Ingredients = append(d.Ingredients, item)
+		} else {
+			d.Steps = append(d.Steps, item)
+		}
+	}
+
+	if m := totalTime.FindStringSubmatch(text); m != nil {
+		d.Minutes = minutes(m[1], m[2])
+	} else {
+		for _, m := range partTime.FindAllStringSubmatch(text, -1) {
+			d.Minutes += minutes(m[1], m[2])
+		}
+	}
+	if m := caloriesLine.FindStringSubmatch(text); m != nil {
+		d.Calories, _ = strconv.ParseFloat(m[1], 64)
+	}
+
+	names := make([]string, 0, len(d.Ingredients))
+	for _, line := range d.Ingredients {
+		names = append(names, ingredient.Parse(line).Name)
+	}
+	d.Diet = Diets(names)
+	return d
+}
+
+// headingText returns the text of a markdown heading, or of a line that is
+// all bold, which the model often uses as one.
+func headingText(line string) (string, bool) {
+	if strings.HasPrefix(line, "#") {
+		return strings.TrimSpace(strings.Trim(line, "#* :")), true
+	}
+	if m := boldLine.FindStringSubmatch(line); m != nil {
+		return strings.TrimSpace(strings.TrimSuffix(m[1], ":")), true
+	}
+	return "", false
+}
+
+func minutes(n, unit string) int {
+	v, _ := strconv.Atoi(n)
+	if strings.HasPrefix(strings.ToLower(unit), "h") {
+		return v * 60
+	}
+	return v
+}
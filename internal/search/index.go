@@ -0,0 +1,408 @@
+// Package search is a small full-text index over a user's recipes. It holds
+// everything in memory: a user has at most a few hundred recipes, so an
+// index is cheap to build whenever they change.
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type field int
+
+const (
+	titleField field = iota
+	ingredientsField
+	tagsField
+	stepsField
+	numFields
+)
+
+var fieldNames = [numFields]string{"title", "ingredients", "tags", "steps"}
+
+// boosts weigh a match by the field it's in: the title says what a recipe is
+// far more than a passing mention in a step does.
+var boosts = [numFields]float64{3, 2, 2, 1}
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// segment is a line of a field, such as one ingredient or one step. Phrases
+// only match within a segment.
+type segment struct {
+	text   string
+	tokens []token
+}
+
+type entry struct {
+	doc    Doc
+	fields [numFields][]segment
+	length [numFields]int
+}
+
+type Index struct {
+	entries []entry
+	// postings lists the entries each term appears in, in order
+	postings  map[string][]int
+	avgLength [numFields]float64
+}
+
+func NewIndex(docs []Doc) *Index {
+	ix := &Index{entries: make([]entry, len(docs)), postings: map[string][]int{}}
+	for i, d := range docs {
+		e := entry{doc: d}
+		texts := [numFields][]string{{d.Title}, d.Ingredients, d.Tags, d.Steps}
+		seen := map[string]bool{}
+		for f, lines := range texts {
+			for _, line := range lines {
+				seg := segment{text: line, tokens: tokenize(line)}
+				e.fields[f] = append(e.fields[f], seg)
+				for _, t := range seg.tokens {
+					if t.term == "" {
+						continue
+					}
+					e.length[f]++
+					if !seen[t.term] {
+						seen[t.term] = true
+						ix.postings[t.term] = append(ix.postings[t.term], i)
+					}
+				}
+			}
+			ix.avgLength[f] += float64(e.length[f])
+		}
+		ix.entries[i] = e
+	}
+	for f := range ix.avgLength {
+		if len(docs) > 0 {
+			ix.avgLength[f] /= float64(len(docs))
+		}
+	}
+	return ix
+}
+
+// Len returns how many recipes are indexed.
+func (ix *Index) Len() int { return len(ix.entries) }
+
+// Filter narrows a search. Zero values don't filter. A recipe that doesn't
+// say how long it takes or how many calories it has is left out by filters
+// on those.
+type Filter struct {
+	Cuisine     string
+	Diet        []string
+	MaxMinutes  int
+	MinCalories float64
+	MaxCalories float64
+}
+
+// Match reports whether d passes the filter. Cuisine matches the recipe's
+// cuisine or any of its tags.
+func (f Filter) Match(d *Doc) bool {
+	if f.Cuisine != "" && !strings.EqualFold(d.Cuisine, f.Cuisine) && !containsFold(d.Tags, f.Cuisine) {
+		return false
+	}
+	for _, flag := range f.Diet {
+		if !containsFold(d.Diet, flag) {
+			return false
+		}
+	}
+	if f.MaxMinutes > 0 && (d.Minutes == 0 || d.Minutes > f.MaxMinutes) {
+		return false
+	}
+	if (f.MinCalories > 0 || f.MaxCalories > 0) && d.Calories == 0 {
+		return false
+	}
+	if f.MinCalories > 0 && d.Calories < f.MinCalories {
+		return false
+	}
+	if f.MaxCalories > 0 && d.Calories > f.MaxCalories {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hit is a recipe that matched a search. Highlights holds the matching
+// parts of each field, HTML-escaped with the matched words in <mark>.
+type Hit struct {
+	ID         string              `json:"id"`
+	Source     string              `json:"source"`
+	Title      string              `json:"title"`
+	Cuisine    string              `json:"cuisine,omitempty"`
+	Tags       []string            `json:"tags,omitempty"`
+	Minutes    int                 `json:"minutes,omitempty"`
+	Calories   float64             `json:"calories,omitempty"`
+	Diet       []string            `json:"diet,omitempty"`
+	Score      float64             `json:"score"`
+	Matched    int                 `json:"matched_terms"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// Search finds the recipes matching q that pass the filter. Words in q are
+// matched separately and quoted phrases as a whole. Recipes matching more
+// of the query rank first, then by BM25 score; an empty query lists every
+// recipe that passes the filter, newest first.
+func (ix *Index) Search(q string, f Filter) []Hit {
+	clauses := parseQuery(q)
+	hits := []Hit{}
+	if len(clauses) == 0 {
+		for i := range ix.entries {
+			if d := &ix.entries[i].doc; f.Match(d) {
+				hits = append(hits, newHit(d))
+			}
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].CreatedAt.After(hits[j].CreatedAt) })
+		return hits
+	}
+
+	// Count each clause in each field of every candidate first, since the
+	// weight of a clause depends on how many recipes have it
+	counts := map[int][][numFields]int{}
+	df := make([]int, len(clauses))
+	for _, i := range ix.candidates(clauses) {
+		e := &ix.entries[i]
+		c := make([][numFields]int, len(clauses))
+		for ci, cl := range clauses {
+			found := false
+			for fi := range e.fields {
+				c[ci][fi] = occurrences(e.fields[fi], cl)
+				found = found || c[ci][fi] > 0
+			}
+			if found {
+				df[ci]++
+			}
+		}
+		counts[i] = c
+	}
+
+	n := float64(len(ix.entries))
+	for i, c := range counts {
+		e := &ix.entries[i]
+		if !f.Match(&e.doc) {
+			continue
+		}
+		h := newHit(&e.doc)
+		var matched []clause
+		for ci, cl := range clauses {
+			idf := math.Log(1 + (n-float64(df[ci])+0.5)/(float64(df[ci])+0.5))
+			score := 0.0
+			for fi, tf := range c[ci] {
+				if tf == 0 {
+					continue
+				}
+				norm := 1 - b
+				if ix.avgLength[fi] > 0 {
+					norm += b * float64(e.length[fi]) / ix.avgLength[fi]
+				}
+				score += boosts[fi] * float64(tf) * (k1 + 1) / (float64(tf) + k1*norm)
+			}
+			if score > 0 {
+				h.Score += idf * score
+				matched = append(matched, cl)
+			}
+		}
+		if len(matched) == 0 {
+			// Had a phrase's words, but not together
+			continue
+		}
+		h.Matched = len(matched)
+		h.Score = math.Round(h.Score*1000) / 1000
+		h.Highlights = highlights(e, matched)
+		hits = append(hits, h)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Matched != hits[j].Matched {
+			return hits[i].Matched > hits[j].Matched
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+	return hits
+}
+
+func newHit(d *Doc) Hit {
+	return Hit{
+		ID:        d.ID,
+		Source:    d.Source,
+		Title:     d.Title,
+		Cuisine:   d.Cuisine,
+		Tags:      d.Tags,
+		Minutes:   d.Minutes,
+		Calories:  d.Calories,
+		Diet:      d.Diet,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+// candidates returns the entries that have every word of at least one
+// clause.
+func (ix *Index) candidates(clauses []clause) []int {
+	set := map[int]bool{}
+	for _, cl := range clauses {
+		have := map[int]int{}
+		for _, term := range uniq(cl) {
+			for _, i := range ix.postings[term] {
+				have[i]++
+			}
+		}
+		for i, n := range have {
+			if n == len(uniq(cl)) {
+				set[i] = true
+			}
+		}
+	}
+	out := make([]int, 0, len(set))
+	for i := range set {
+		out = append(out, i)
+	}
+	sort.Ints(out)
+	return out
+}
+
+func uniq(words []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// occurrences counts where the clause's words appear in order in one of the
+// segments. Stop words between them in the text are skipped over, so
+// "coconut milk" matches "coconut and milk" but not "coconut, rice and
+// milk".
+func occurrences(segments []segment, cl clause) int {
+	n := 0
+	for _, seg := range segments {
+		for i := range seg.tokens {
+			if _, ok := matchAt(seg.tokens, i, cl); ok {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// matchAt reports whether the clause starts at token i, and the token after
+// its last word.
+func matchAt(tokens []token, i int, cl clause) (int, bool) {
+	for _, w := range cl {
+		for i < len(tokens) && tokens[i].term == "" {
+			i++
+		}
+		if i >= len(tokens) || tokens[i].term != w {
+			return 0, false
+		}
+		i++
+	}
+	return i, true
+}
+
+// Highlighting limits
+const (
+	maxHighlights = 3
+	snippetBefore = 6
+	snippetAfter  = 14
+)
+
+func highlights(e *entry, matched []clause) map[string][]string {
+	out := map[string][]string{}
+	for fi, segments := range e.fields {
+		for _, seg := range segments {
+			marks := markTokens(seg.tokens, matched)
+			if marks == nil {
+				continue
+			}
+			window := field(fi) == stepsField
+			out[fieldNames[fi]] = append(out[fieldNames[fi]], highlight(seg, marks, window))
+			if len(out[fieldNames[fi]]) == maxHighlights {
+				break
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// markTokens returns which tokens are part of a match of any clause, or nil
+// if none are.
+func markTokens(tokens []token, clauses []clause) []bool {
+	var marks []bool
+	for i := range tokens {
+		for _, cl := range clauses {
+			end, ok := matchAt(tokens, i, cl)
+			if !ok {
+				continue
+			}
+			if marks == nil {
+				marks = make([]bool, len(tokens))
+			}
+			for j := i; j < end; j++ {
+				marks[j] = tokens[j].term != ""
+			}
+		}
+	}
+	return marks
+}
+
+// highlight wraps the marked tokens of a segment in <mark>. Long text such
+// as a step is cut down to the words around the first match.
+func highlight(seg segment, marks []bool, window bool) string {
+	from, to := 0, len(seg.tokens)
+	if window {
+		first := 0
+		for first < len(marks) && !marks[first] {
+			first++
+		}
+		from = max(0, first-snippetBefore)
+		to = min(len(seg.tokens), first+snippetAfter)
+	}
+
+	var sb strings.Builder
+	start, end := 0, len(seg.text)
+	if from > 0 {
+		start = seg.tokens[from].start
+		sb.WriteString("…")
+	}
+	if to < len(seg.tokens) {
+		end = seg.tokens[to-1].end
+	}
+	pos := start
+	for i := from; i < to; i++ {
+		if !marks[i] {
+			continue
+		}
+		t := seg.tokens[i]
+		sb.WriteString(html.EscapeString(seg.text[pos:t.start]))
+		sb.WriteString("<mark>" + html.EscapeString(seg.text[t.start:t.end]) + "</mark>")
+		pos = t.end
+	}
+	sb.WriteString(html.EscapeString(seg.text[pos:end]))
+	if end < len(seg.text) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
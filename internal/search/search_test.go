@@ -0,0 +1,149 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	for word, want := range map[string]string{
+		"tomatoes": "tomato", "berries": "berry", "dishes": "dish", "sauces": "sauce",
+		"onions": "onion", "glass": "glass", "couscous": "couscous", "peas": "pea",
+		"egg": "egg", "hummus": "hummus",
+	} {
+		assert.Equal(t, want, stem(word), word)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	assert.Equal(t, []clause{{"soup"}, {"coconut", "milk"}}, parseQuery(`that soup with "coconut milk"`))
+	assert.Equal(t, []clause{{"rice"}}, parseQuery(`rice "the"`))
+	assert.Nil(t, parseQuery("  "))
+}
+
+const egusi = `Here is a recipe for you!
+
+## Egusi Soup
+
+**Prep time:** 15 minutes · **Cook time:** 45 minutes
+
+**Ingredients:**
+* 1 cup ground egusi
+* 500g beef, cubed
+* 2 tbsp palm oil
+
+**Instructions:**
+1. Season the **beef** and boil until tender.
+2. Stir in the egusi and simmer.
+
+**Nutritional Information (per serving):**
+* Calories: approximately 520 kcal`
+
+func TestFromMarkdown(t *testing.T) {
+	d := FromMarkdown(egusi)
+	assert.Equal(t, "Egusi Soup", d.Title)
+	assert.Equal(t, []string{"1 cup ground egusi", "500g beef, cubed", "2 tbsp palm oil"}, d.Ingredients)
+	assert.Contains(t, d.Steps, "Season the beef and boil until tender.")
+	assert.Equal(t, 60, d.Minutes)
+	assert.Equal(t, 520.0, d.Calories)
+	assert.NotContains(t, d.Diet, Vegetarian)
+	assert.Contains(t, d.Diet, GlutenFree)
+
+	assert.Equal(t, 90, FromMarkdown("**Total time:** 1.5 hours\n\nTotal time: 90 min").Minutes)
+}
+
+func TestDiets(t *testing.T) {
+	assert.Nil(t, Diets(nil))
+	assert.Equal(t, []string{Vegetarian, Vegan, GlutenFree, DairyFree, EggFree, NutFree}, Diets([]string{"rice", "coconut milk", "tomato", "nutmeg"}))
+	assert.Equal(t, []string{Vegetarian, GlutenFree, EggFree, NutFree}, Diets([]string{"paneer", "butter", "spinach"}))
+	assert.Equal(t, []string{Vegetarian, DairyFree, EggFree}, Diets([]string{"spaghetti", "peanut butter", "honey"}))
+	assert.Equal(t, []string{GlutenFree, DairyFree, EggFree, NutFree}, Diets([]string{"chicken stock", "rice flour"}))
+}
+
+func index() *Index {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	coconut := FromRecipe(&recipe.Recipe{
+		Title:       "Thai Green Curry",
+		Cuisine:     "Thai",
+		PrepTime:    10,
+		CookTime:    20,
+		Ingredients: []ingredient.Item{ingredient.Parse("400ml coconut milk"), ingredient.Parse("2 chicken breasts")},
+		Steps:       []string{"Fry the curry paste, then pour in the coconut milk and simmer for 10 minutes until it thickens slightly."},
+		Nutrition:   recipe.Nutrition{Calories: 610},
+	})
+	coconut.ID, coconut.Source, coconut.CreatedAt = "curry", "saved", now
+
+	soup := FromRecipe(&recipe.Recipe{
+		Title:       "Coconut Lentil Soup",
+		PrepTime:    5,
+		CookTime:    30,
+		Ingredients: []ingredient.Item{ingredient.Parse("1 cup red lentils"), ingredient.Parse("1 can coconut milk"), ingredient.Parse("2 carrots")},
+		Steps:       []string{"Simmer everything until soft, then blend."},
+		Nutrition:   recipe.Nutrition{Calories: 380},
+	})
+	soup.ID, soup.Source, soup.CreatedAt = "soup", "history", now.Add(-time.Hour)
+
+	egusi := FromMarkdown(egusi)
+	egusi.ID, egusi.Source, egusi.Tags, egusi.CreatedAt = "egusi", "saved", []string{"nigerian"}, now.Add(time.Hour)
+
+	return NewIndex([]Doc{coconut, soup, egusi})
+}
+
+func ids(hits []Hit) []string {
+	out := []string{}
+	for _, h := range hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	ix := index()
+	assert.Equal(t, 3, ix.Len())
+
+	// The soup has every word, so it ranks above the curry, which only has
+	// coconut milk
+	hits := ix.Search("that soup with coconut milk", Filter{})
+	assert.Equal(t, []string{"soup", "curry", "egusi"}, ids(hits))
+	assert.Equal(t, 3, hits[0].Matched)
+	assert.Equal(t, []string{"<mark>Coconut</mark> Lentil <mark>Soup</mark>"}, hits[0].Highlights["title"])
+	assert.Equal(t, []string{"1 can <mark>coconut</mark> <mark>milk</mark>"}, hits[0].Highlights["ingredients"])
+
+	// Steps are cut down around the match
+	hits = ix.Search("thickens", Filter{})
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, []string{"…simmer for 10 minutes until it <mark>thickens</mark> slightly."}, hits[0].Highlights["steps"])
+	}
+
+	// Phrases need their words together
+	assert.Equal(t, []string{"curry", "soup"}, ids(ix.Search(`"coconut milk"`, Filter{})))
+	assert.Empty(t, ix.Search(`"milk coconut"`, Filter{}))
+
+	// Plurals find singulars and the other way round
+	assert.Equal(t, []string{"soup"}, ids(ix.Search("lentil carrot", Filter{})))
+
+	// An empty query lists everything, newest first
+	assert.Equal(t, []string{"egusi", "curry", "soup"}, ids(ix.Search("", Filter{})))
+}
+
+func TestSearchFilters(t *testing.T) {
+	ix := index()
+	assert.Equal(t, []string{"curry"}, ids(ix.Search("coconut", Filter{Cuisine: "thai"})))
+	assert.Equal(t, []string{"egusi"}, ids(ix.Search("", Filter{Cuisine: "Nigerian"})))
+	assert.Equal(t, []string{"soup"}, ids(ix.Search("", Filter{Diet: []string{Vegan}})))
+	assert.Equal(t, []string{"curry"}, ids(ix.Search("", Filter{MaxMinutes: 30})))
+	assert.Equal(t, []string{"egusi", "soup"}, ids(ix.Search("", Filter{MaxCalories: 600})))
+	assert.Equal(t, []string{"egusi", "curry"}, ids(ix.Search("", Filter{MinCalories: 500})))
+}
+
+func TestHighlightEscapes(t *testing.T) {
+	ix := NewIndex([]Doc{{ID: "x", Title: "Mac & <cheese>"}})
+	hits := ix.Search("cheese", Filter{})
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, []string{"Mac &amp; &lt;<mark>cheese</mark>&gt;"}, hits[0].Highlights["title"])
+	}
+}
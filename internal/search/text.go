@@ -0,0 +1,104 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a word of a piece of text, with where it is in the text so it can
+// be highlighted.
+type token struct {
+	term       string
+	start, end int
+}
+
+// stopWords are too common to search by. They still take up a position so
+// a phrase doesn't match across one.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "with": true,
+	"in": true, "to": true, "for": true, "on": true, "or": true, "at": true,
+	"by": true, "into": true, "that": true, "this": true, "my": true,
+	"some": true, "is": true, "it": true,
+}
+
+// tokenize splits text into words. Stop words come back with an empty term.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+	return tokens
+}
+
+func newToken(text string, start, end int) token {
+	word := strings.ToLower(text[start:end])
+	if stopWords[word] {
+		word = ""
+	}
+	return token{term: stem(word), start: start, end: end}
+}
+
+// stem folds plurals together, so "tomatoes" finds "tomato" and "berries"
+// finds "berry". It's deliberately light; anything more makes the index
+// harder to reason about than it helps.
+func stem(word string) string {
+	if utf8.RuneCountInString(word) <= 3 {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// terms returns the searchable terms of text, stop words left out.
+func terms(text string) []string {
+	var out []string
+	for _, t := range tokenize(text) {
+		if t.term != "" {
+			out = append(out, t.term)
+		}
+	}
+	return out
+}
+
+// clause is a word, or a quoted phrase whose words must appear together.
+type clause []string
+
+// parseQuery splits a query into clauses: each quoted phrase is one clause
+// and every other word is its own.
+func parseQuery(q string) []clause {
+	var clauses []clause
+	parts := strings.Split(q, `"`)
+	for i, part := range parts {
+		words := terms(part)
+		if i%2 == 1 && len(words) > 0 {
+			clauses = append(clauses, clause(words))
+			continue
+		}
+		for _, w := range words {
+			clauses = append(clauses, clause{w})
+		}
+	}
+	return clauses
+}
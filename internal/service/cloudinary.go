@@ -12,16 +12,17 @@ import (
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
-func UploadImage(fileByte []byte) error {
+// UploadImage stores a photo and returns its URL.
+func UploadImage(fileByte []byte) (string, error) {
 	cloudinaryURL := os.Getenv("CLOUDINARY_URL")
 	cld, err := cloudinary.NewFromURL(cloudinaryURL)
 	if err != nil {
-		return fmt.Errorf("unable to create cloudinary client: %v", err)
+		return "", fmt.Errorf("unable to create cloudinary client: %v", err)
 	}
 
 	// Validate the file content
 	if len(fileByte) == 0 {
-		return fmt.Errorf("image file is empty")
+		return "", fmt.Errorf("image file is empty")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -37,8 +38,8 @@ func UploadImage(fileByte []byte) error {
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("upload failed: %v", err)
+		return "", fmt.Errorf("upload failed: %v", err)
 	}
 	log.Printf("Image uploaded successfully: URL: %s, PublicID: %s\n", upload.SecureURL, upload.PublicID)
-	return nil
+	return upload.SecureURL, nil
 }
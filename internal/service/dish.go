@@ -0,0 +1,88 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// minDishConfidence drops detections the model is mostly guessing at.
+const minDishConfidence = 0.2
+
+// ParseDishes decodes the model's dish detections, converting Gemini's
+// [ymin, xmin, ymax, xmax] boxes on a 0-1000 grid into fractions of the image
+// size, and orders them most confident first.
+func ParseDishes(content []byte) ([]Dish, error) {
+	var raw struct {
+		Dishes []struct {
+			Name       string     `json:"name"`
+			Confidence float64    `json:"confidence"`
+			Box        [4]float64 `json:"box_2d"`
+		} `json:"dishes"`
+	}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	dishes := []Dish{}
+	for _, d := range raw.Dishes {
+		if strings.TrimSpace(d.Name) == "" || d.Confidence < minDishConfidence {
+			continue
+		}
+		ymin, xmin, ymax, xmax := d.Box[0]/1000, d.Box[1]/1000, d.Box[2]/1000, d.Box[3]/1000
+		dishes = append(dishes, Dish{
+			Name:       d.Name,
+			Confidence: d.Confidence,
+			Box:        BoundingBox{X: xmin, Y: ymin, Width: xmax - xmin, Height: ymax - ymin},
+		})
+	}
+
+	sort.SliceStable(dishes, func(i, j int) bool { return dishes[i].Confidence > dishes[j].Confidence })
+	return dishes, nil
+}
+
+// SelectDishes picks which detected dishes to write recipes for. expand is
+// "all" (the default), "none", or a comma separated list of dish indexes or
+// names. At most limit dishes are returned.
+func SelectDishes(dishes []Dish, expand string, limit int) ([]int, error) {
+	expand = strings.ToLower(strings.TrimSpace(expand))
+
+	var selected []int
+	switch expand {
+	case "", "all":
+		for i := range dishes {
+			selected = append(selected, i)
+		}
+	case "none":
+		return nil, nil
+	default:
+		for _, part := range strings.Split(expand, ",") {
+			part = strings.TrimSpace(part)
+			if i, err := strconv.Atoi(part); err == nil {
+				if i < 0 || i >= len(dishes) {
+					return nil, fmt.Errorf("no dish at index %d", i)
+				}
+				selected = append(selected, i)
+				continue
+			}
+			found := false
+			for i, d := range dishes {
+				if strings.EqualFold(d.Name, part) {
+					selected = append(selected, i)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no dish named %q was detected", part)
+			}
+		}
+	}
+
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected, nil
+}
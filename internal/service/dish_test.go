@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const plate = `{"dishes": [
+	{"name": "Fried Plantain", "confidence": 0.8, "box_2d": [500, 600, 900, 1000]},
+	{"name": "Jollof Rice", "confidence": 0.95, "box_2d": [100, 0, 700, 500]},
+	{"name": "Coleslaw", "confidence": 0.1, "box_2d": [0, 0, 100, 100]}
+]}`
+
+func TestParseDishes(t *testing.T) {
+	dishes, err := ParseDishes([]byte(plate))
+	if !assert.NoError(t, err) || !assert.Len(t, dishes, 2) {
+		return
+	}
+	assert.Equal(t, "Jollof Rice", dishes[0].Name)
+	assert.Equal(t, BoundingBox{X: 0, Y: 0.1, Width: 0.5, Height: 0.6}, dishes[0].Box)
+	assert.Equal(t, "Fried Plantain", dishes[1].Name)
+}
+
+func TestSelectDishes(t *testing.T) {
+	dishes, _ := ParseDishes([]byte(plate))
+
+	selected, err := SelectDishes(dishes, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, selected)
+
+	selected, err = SelectDishes(dishes, "all", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, selected)
+
+	selected, err = SelectDishes(dishes, "none", 3)
+	assert.NoError(t, err)
+	assert.Empty(t, selected)
+
+	selected, err = SelectDishes(dishes, "fried plantain, 0", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, selected)
+
+	_, err = SelectDishes(dishes, "egusi soup", 3)
+	assert.Error(t, err)
+	_, err = SelectDishes(dishes, "5", 3)
+	assert.Error(t, err)
+}
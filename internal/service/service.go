@@ -6,23 +6,26 @@ import (
 	"fmt"
 
 	"github.com/Oluwaseun241/mura/cmd/client"
+	"github.com/Oluwaseun241/mura/internal/usage"
 	"github.com/google/generative-ai-go/genai"
 )
 
-func ClassifyImage(imageBytes []byte) (string, error) {
-	ctx := context.Background()
+func ClassifyImage(ctx context.Context, imageBytes []byte) (string, error) {
+	if err := usage.Use(ctx, usage.Gemini); err != nil {
+		return "", err
+	}
 
 	prompt := []genai.Part{
 		genai.ImageData("jpeg", imageBytes),
 		genai.Text("Analyze this image and classify it as either 'cooked food' or 'ingredient'. Return the result in JSON format as {\"type\": \"cooked food\"} or {\"type\": \"ingredient\"}. If the image doesn't contain food or ingredients, return {\"type\": \"invalid\"}."),
 	}
 
-	model := client.GeminiClient.GenerativeModel("gemini-2.0-flash")
+	model := client.GeminiClient.GenerativeModel(client.GeminiModel)
 	model.ResponseMIMEType = "application/json"
 
 	resp, err := model.GenerateContent(ctx, prompt...)
 	if err != nil {
-		return "", fmt.Errorf("error generating content: %v", err)
+		return "", fmt.Errorf("error generating content: %w", err)
 	}
 
 	// Extract the content from the response
@@ -43,7 +46,7 @@ func ClassifyImage(imageBytes []byte) (string, error) {
 		Type string `json:"type"`
 	}
 	if err := json.Unmarshal([]byte(combinedContent), &result); err != nil {
-		return "", fmt.Errorf("error parsing response: %v", err)
+		return "", fmt.Errorf("error parsing response: %w", err)
 	}
 
 	return result.Type, nil
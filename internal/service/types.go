@@ -29,3 +29,20 @@ type YouTubeVideo struct {
 	Thumbnail   string `json:"thumbnail"`
 	VideoURL    string `json:"videoUrl"`
 }
+
+// BoundingBox locates a dish in an image as fractions of the image's width
+// and height, measured from the top left corner.
+type BoundingBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type Dish struct {
+	Name       string      `json:"name"`
+	Confidence float64     `json:"confidence"`
+	Box        BoundingBox `json:"box"`
+	Recipe     string      `json:"recipe,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
@@ -12,6 +12,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/Oluwaseun241/mura/internal/usage"
 )
 
 func filterRelevantVideos(videos []YouTubeVideo, keywords []string) []YouTubeVideo {
@@ -47,7 +49,7 @@ func rankVideos(videos []YouTubeVideo, query string) []YouTubeVideo {
 
 const youtubeSearchURL = "https://www.googleapis.com/youtube/v3/search"
 
-func YoutubeSearch(query string) ([]YouTubeVideo, error) {
+func YoutubeSearch(ctx context.Context, query string) ([]YouTubeVideo, error) {
 	authKey := os.Getenv("GOOGLE_SERVICE_KEY")
 	encodedQuery := url.QueryEscape(query)
 
@@ -60,34 +62,38 @@ func YoutubeSearch(query string) ([]YouTubeVideo, error) {
 	var err error
 	maxAttempts := 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		videos, err = YoutubeAPICall(apiUrl)
+		videos, err = YoutubeAPICall(ctx, apiUrl)
 		if err == nil {
 			return videos, nil
 		}
-		// Retry only if the error is related to timeout
-		if !errors.Is(err, context.DeadlineExceeded) {
+		// Retry only if the error is related to timeout, and not if the
+		// request itself has run out of time
+		if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
 			break
 		}
 		time.Sleep(2 * time.Second) // wait before retrying
 	}
 
-	return nil, fmt.Errorf("YouTube API request failed after %d attempts: %v", maxAttempts, err)
+	return nil, fmt.Errorf("YouTube API request failed after %d attempts: %w", maxAttempts, err)
 }
 
 // Returns youtube video relating to the recipe
-func YoutubeAPICall(apiUrl string) ([]YouTubeVideo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func YoutubeAPICall(ctx context.Context, apiUrl string) ([]YouTubeVideo, error) {
+	if err := usage.Use(ctx, usage.YouTube); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating HTTP request: %v", err)
+		return nil, fmt.Errorf("error creating HTTP request: %w", err)
 	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request to YouTube API: %v", err)
+		return nil, fmt.Errorf("error sending request to YouTube API: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -98,7 +104,7 @@ func YoutubeAPICall(apiUrl string) ([]YouTubeVideo, error) {
 
 	var ytResponse YoutubeResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ytResponse); err != nil {
-		return nil, fmt.Errorf("error unmarshaling YouTube API response: %v", err)
+		return nil, fmt.Errorf("error unmarshaling YouTube API response: %w", err)
 	}
 
 	videos := []YouTubeVideo{}
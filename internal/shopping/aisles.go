@@ -0,0 +1,88 @@
+package shopping
+
+import "github.com/Oluwaseun241/mura/internal/ingredient"
+
+// Aisles in the order a list is printed, roughly the way people walk a
+// supermarket or market.
+var Aisles = []string{
+	"Produce", "Meat & Seafood", "Dairy & Eggs", "Bakery", "Grains, Pasta & Beans",
+	"Canned & Jarred", "Spices & Seasonings", "Oils, Sauces & Condiments", "Baking", "Frozen", "Other",
+}
+
+// aisleOf maps ingredients to where they're found in a shop.
+var aisleOf = map[string]string{
+	// Produce
+	"onion": "Produce", "spring onion": "Produce", "garlic": "Produce", "ginger": "Produce",
+	"tomato": "Produce", "bell pepper": "Produce", "red bell pepper": "Produce",
+	"scotch bonnet pepper": "Produce", "chili pepper": "Produce", "potato": "Produce",
+	"sweet potato": "Produce", "yam": "Produce", "plantain": "Produce", "carrot": "Produce",
+	"cabbage": "Produce", "lettuce": "Produce", "spinach": "Produce", "kale": "Produce",
+	"cucumber": "Produce", "zucchini": "Produce", "eggplant": "Produce", "broccoli": "Produce",
+	"cauliflower": "Produce", "mushroom": "Produce", "okra": "Produce", "celery": "Produce",
+	"coriander": "Produce", "parsley": "Produce", "basil": "Produce", "mint": "Produce",
+	"thyme": "Produce", "rosemary": "Produce", "lemon": "Produce", "lime": "Produce",
+	"orange": "Produce", "apple": "Produce", "banana": "Produce", "avocado": "Produce",
+	"mango": "Produce", "pineapple": "Produce", "green bean": "Produce", "corn": "Produce",
+	"ugu": "Produce", "bitter leaf": "Produce", "scent leaf": "Produce",
+	// Meat & Seafood
+	"chicken": "Meat & Seafood", "beef": "Meat & Seafood", "ground beef": "Meat & Seafood",
+	"goat meat": "Meat & Seafood", "pork": "Meat & Seafood", "lamb": "Meat & Seafood",
+	"turkey": "Meat & Seafood", "sausage": "Meat & Seafood", "bacon": "Meat & Seafood",
+	"fish": "Meat & Seafood", "salmon": "Meat & Seafood", "tilapia": "Meat & Seafood",
+	"mackerel": "Meat & Seafood", "shrimp": "Meat & Seafood", "crayfish": "Meat & Seafood",
+	"stockfish": "Meat & Seafood", "tuna": "Canned & Jarred",
+	// Dairy & Eggs
+	"egg": "Dairy & Eggs", "milk": "Dairy & Eggs", "butter": "Dairy & Eggs",
+	"cheese": "Dairy & Eggs", "yogurt": "Dairy & Eggs", "heavy cream": "Dairy & Eggs",
+	"sour cream": "Dairy & Eggs", "cream cheese": "Dairy & Eggs",
+	// Bakery
+	"bread": "Bakery", "tortilla": "Bakery", "pita": "Bakery", "bun": "Bakery",
+	// Grains, Pasta & Beans
+	"rice": "Grains, Pasta & Beans", "pasta": "Grains, Pasta & Beans", "spaghetti": "Grains, Pasta & Beans",
+	"noodle": "Grains, Pasta & Beans", "couscous": "Grains, Pasta & Beans", "oats": "Grains, Pasta & Beans",
+	"quinoa": "Grains, Pasta & Beans", "bean": "Grains, Pasta & Beans", "lentil": "Grains, Pasta & Beans",
+	"chickpea": "Grains, Pasta & Beans", "garri": "Grains, Pasta & Beans", "semolina": "Grains, Pasta & Beans",
+	"egusi": "Grains, Pasta & Beans", "peanut": "Grains, Pasta & Beans",
+	// Canned & Jarred
+	"tomato paste": "Canned & Jarred", "coconut milk": "Canned & Jarred", "chicken stock": "Canned & Jarred",
+	"beef stock": "Canned & Jarred", "peanut butter": "Canned & Jarred",
+	// Spices & Seasonings
+	"bouillon cube": "Spices & Seasonings", "curry powder": "Spices & Seasonings", "paprika": "Spices & Seasonings",
+	"cumin": "Spices & Seasonings", "nutmeg": "Spices & Seasonings", "cinnamon": "Spices & Seasonings",
+	"bay leaf": "Spices & Seasonings", "dried thyme": "Spices & Seasonings", "oregano": "Spices & Seasonings",
+	"turmeric": "Spices & Seasonings", "cayenne pepper": "Spices & Seasonings", "chili flake": "Spices & Seasonings",
+	// Oils, Sauces & Condiments
+	"oil": "Oils, Sauces & Condiments", "olive oil": "Oils, Sauces & Condiments", "palm oil": "Oils, Sauces & Condiments",
+	"peanut oil": "Oils, Sauces & Condiments", "soy sauce": "Oils, Sauces & Condiments", "vinegar": "Oils, Sauces & Condiments",
+	"honey": "Oils, Sauces & Condiments", "mayonnaise": "Oils, Sauces & Condiments", "ketchup": "Oils, Sauces & Condiments",
+	"mustard": "Oils, Sauces & Condiments",
+	// Baking
+	"all-purpose flour": "Baking", "sugar": "Baking", "powdered sugar": "Baking", "brown sugar": "Baking",
+	"baking powder": "Baking", "baking soda": "Baking", "yeast": "Baking", "cornstarch": "Baking",
+	"vanilla": "Baking", "vanilla extract": "Baking", "cocoa powder": "Baking",
+	// Frozen
+	"frozen pea": "Frozen", "frozen mixed vegetable": "Frozen", "ice cream": "Frozen",
+}
+
+// Aisle returns where the ingredient is found in a shop. Names we don't list
+// use the most specific listed ingredient they are a kind or cut of, so "red
+// onion" is with onions and "chicken thigh" with chicken.
+func Aisle(name string) string {
+	name = ingredient.Normalize(name)
+	if aisle, ok := aisleOf[name]; ok {
+		return aisle
+	}
+	best := ""
+	for known := range aisleOf {
+		if len(known) < len(best) || (len(known) == len(best) && known > best) {
+			continue
+		}
+		if ingredient.Same(name, known) {
+			best = known
+		}
+	}
+	if best == "" {
+		return "Other"
+	}
+	return aisleOf[best]
+}
@@ -0,0 +1,265 @@
+package shopping
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// Need is an ingredient one recipe calls for.
+type Need struct {
+	Item   ingredient.Item
+	Recipe string
+}
+
+// FromRecipe lists what a recipe needs, scaled from the servings it was
+// written for to the servings wanted. servings <= 0 keeps the recipe as is.
+func FromRecipe(r *recipe.Recipe, servings float64) []Need {
+	scale := 1.0
+	if servings > 0 && r.Servings > 0 {
+		scale = servings / float64(r.Servings)
+	}
+	needs := make([]Need, 0, len(r.Ingredients))
+	for _, item := range r.Ingredients {
+		item.Quantity *= scale
+		item.MaxQuantity *= scale
+		needs = append(needs, Need{Item: item, Recipe: r.Title})
+	}
+	return needs
+}
+
+// Line is one thing to buy. InPantry is how much of it the pantry already
+// covers, in the same unit.
+type Line struct {
+	Name      string   `json:"name"`
+	Canonical string   `json:"canonical"`
+	Quantity  float64  `json:"quantity,omitempty"`
+	Unit      string   `json:"unit,omitempty"`
+	InPantry  float64  `json:"in_pantry,omitempty"`
+	Aisle     string   `json:"aisle"`
+	Recipes   []string `json:"recipes"`
+}
+
+func (l Line) String() string {
+	return ingredient.Item{Quantity: l.Quantity, Unit: l.Unit, Name: l.Name}.String()
+}
+
+type Section struct {
+	Aisle string `json:"aisle"`
+	Items []Line `json:"items"`
+}
+
+// List is a shopping list grouped by aisle, with what the pantry already
+// covers set aside in OnHand.
+type List struct {
+	Sections []Section `json:"sections"`
+	OnHand   []Line    `json:"on_hand"`
+}
+
+// Build adds up what the recipes need, takes off what's in the pantry and
+// groups the rest by aisle. Quantities of the same ingredient are combined
+// when their units convert; pantry staples such as salt and oil are assumed
+// to be on hand.
+func Build(needs []Need, have []pantry.Item, now time.Time) List {
+	var lines []*Line
+	for _, n := range needs {
+		it := n.Item
+		if it.Canonical == "" {
+			it.Canonical = ingredient.Normalize(it.Name)
+		}
+		if it.Canonical == "" || ingredient.IsStaple(it.Canonical) {
+			continue
+		}
+		amount := it.Quantity
+		if it.MaxQuantity > amount {
+			amount = it.MaxQuantity
+		}
+		addNeed(&lines, it, amount, n.Recipe)
+	}
+
+	// What's left of each pantry item as lines use it up.
+	left := make([]float64, len(have))
+	for i, p := range have {
+		left[i] = p.Quantity
+	}
+
+	list := List{Sections: []Section{}, OnHand: []Line{}}
+	byAisle := map[string][]Line{}
+	for _, line := range lines {
+		covered := false
+		for i, p := range have {
+			if p.Expired(now) || !ingredient.Same(p.Name, line.Name) {
+				continue
+			}
+			if p.Quantity == 0 {
+				// We only know they have some, so trust it's enough.
+				covered = true
+				break
+			}
+			if left[i] <= 0 {
+				continue
+			}
+			avail, ok := convert(left[i], p.Unit, line.Unit, line.Canonical)
+			if !ok {
+				continue
+			}
+			use := math.Min(avail, line.Quantity-line.InPantry)
+			line.InPantry += use
+			back, _ := convert(use, line.Unit, p.Unit, line.Canonical)
+			left[i] -= back
+			if line.InPantry >= line.Quantity-1e-9 {
+				covered = true
+				break
+			}
+		}
+
+		if line.Quantity > 0 {
+			line.Quantity = roundUp(line.Quantity-line.InPantry, line.Unit)
+			line.InPantry = roundUp(line.InPantry, line.Unit)
+		}
+		if covered {
+			line.Quantity = 0
+			list.OnHand = append(list.OnHand, *line)
+			continue
+		}
+		byAisle[line.Aisle] = append(byAisle[line.Aisle], *line)
+	}
+
+	for _, aisle := range Aisles {
+		if items := byAisle[aisle]; len(items) > 0 {
+			list.Sections = append(list.Sections, Section{Aisle: aisle, Items: items})
+		}
+	}
+	return list
+}
+
+// addNeed adds an amount of an ingredient to the line for it, starting a new
+// line when there is none or the units can't be combined.
+func addNeed(lines *[]*Line, it ingredient.Item, amount float64, recipeName string) {
+	for _, line := range *lines {
+		if line.Canonical != it.Canonical {
+			continue
+		}
+		switch {
+		case amount == 0:
+		case line.Quantity == 0 && line.Unit == "":
+			line.Quantity, line.Unit = amount, it.Unit
+		default:
+			converted, ok := convert(amount, it.Unit, line.Unit, it.Canonical)
+			if !ok {
+				continue
+			}
+			line.Quantity += converted
+		}
+		line.Recipes = appendOnce(line.Recipes, recipeName)
+		return
+	}
+	*lines = append(*lines, &Line{
+		Name:      it.Name,
+		Canonical: it.Canonical,
+		Quantity:  amount,
+		Unit:      it.Unit,
+		Aisle:     Aisle(it.Canonical),
+		Recipes:   appendOnce([]string{}, recipeName),
+	})
+}
+
+func convert(amount float64, from, to, name string) (float64, bool) {
+	if from == to {
+		return amount, true
+	}
+	q, err := units.Convert(units.Quantity{Amount: amount, Unit: from}, to, name)
+	if err != nil {
+		return 0, false
+	}
+	return q.Amount, true
+}
+
+// roundUp rounds a quantity to something you can buy: whole pieces for
+// counted items, otherwise two decimal places.
+func roundUp(v float64, unit string) float64 {
+	if _, ok := units.LookupUnit(unit); ok {
+		return math.Ceil(v*100-1e-6) / 100
+	}
+	return math.Ceil(v - 1e-6)
+}
+
+func appendOnce(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// Text renders the list as plain text, one aisle heading per group.
+func (l List) Text() string {
+	var b strings.Builder
+	for i, s := range l.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(s.Aisle + "\n")
+		for _, line := range s.Items {
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the list as a checklist.
+func (l List) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Shopping list\n")
+	for _, s := range l.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Aisle)
+		for _, line := range s.Items {
+			fmt.Fprintf(&b, "- [ ] %s\n", line)
+		}
+	}
+	if len(l.OnHand) > 0 {
+		b.WriteString("\n## Already in your pantry\n\n")
+		for _, line := range l.OnHand {
+			fmt.Fprintf(&b, "- [x] %s\n", line.Name)
+		}
+	}
+	return b.String()
+}
+
+// CSV renders the list with one row per item.
+func (l List) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"aisle", "item", "quantity", "unit", "recipes"}); err != nil {
+		return "", err
+	}
+	for _, s := range l.Sections {
+		for _, line := range s.Items {
+			quantity := ""
+			if line.Quantity > 0 {
+				quantity = strconv.FormatFloat(line.Quantity, 'f', -1, 64)
+			}
+			if err := w.Write([]string{s.Aisle, line.Name, quantity, line.Unit, strings.Join(line.Recipes, "; ")}); err != nil {
+				return "", err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("error writing csv: %v", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,87 @@
+package shopping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func dish(title string, servings int, lines ...string) *recipe.Recipe {
+	r := &recipe.Recipe{Title: title, Servings: servings}
+	for _, line := range lines {
+		r.Ingredients = append(r.Ingredients, ingredient.Parse(line))
+	}
+	return r
+}
+
+func TestAisle(t *testing.T) {
+	assert.Equal(t, "Produce", Aisle("Red Onions"))
+	assert.Equal(t, "Meat & Seafood", Aisle("chicken thighs"))
+	assert.Equal(t, "Canned & Jarred", Aisle("tomato paste"))
+	assert.Equal(t, "Other", Aisle("ofada sauce"))
+}
+
+func TestBuild(t *testing.T) {
+	jollof := dish("Jollof Rice", 4, "500g rice", "3 onions", "2 tbsp tomato paste", "1 tsp salt", "4 chicken thighs")
+	stew := dish("Tomato Stew", 2, "1 onion", "200g tomatoes", "1/2 kg rice", "fresh thyme")
+
+	var needs []Need
+	needs = append(needs, FromRecipe(jollof, 8)...)
+	needs = append(needs, FromRecipe(stew, 0)...)
+
+	have := []pantry.Item{
+		{ID: "1", Name: "rice", Quantity: 1, Unit: "kg"},
+		{ID: "2", Name: "onions", Quantity: 2},
+		{ID: "3", Name: "tomato paste"},
+		{ID: "4", Name: "chicken thighs", Quantity: 8, ExpiresAt: &time.Time{}},
+	}
+	list := Build(needs, have, now)
+
+	var aisles []string
+	for _, s := range list.Sections {
+		aisles = append(aisles, s.Aisle)
+	}
+	assert.Equal(t, []string{"Produce", "Meat & Seafood", "Grains, Pasta & Beans"}, aisles)
+
+	produce := list.Sections[0].Items
+	if assert.Len(t, produce, 3) {
+		assert.Equal(t, "onions", produce[0].Name)
+		assert.Equal(t, 5.0, produce[0].Quantity)
+		assert.Equal(t, 2.0, produce[0].InPantry)
+		assert.Equal(t, []string{"Jollof Rice", "Tomato Stew"}, produce[0].Recipes)
+		assert.Equal(t, "tomatoes", produce[1].Name)
+		assert.Equal(t, "fresh thyme", produce[2].Name)
+		assert.Zero(t, produce[2].Quantity)
+	}
+
+	// The chicken in the pantry has expired, so all of it is needed.
+	chicken := list.Sections[1].Items[0]
+	assert.Equal(t, 8.0, chicken.Quantity)
+
+	rice := list.Sections[2].Items[0]
+	assert.Equal(t, "g", rice.Unit)
+	assert.Equal(t, 500.0, rice.Quantity)
+	assert.Equal(t, 1000.0, rice.InPantry)
+
+	if assert.Len(t, list.OnHand, 1) {
+		assert.Equal(t, "tomato paste", list.OnHand[0].Name)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	list := Build(FromRecipe(dish("Omelette", 1, "3 eggs", "1 onion, diced", "50g cheddar cheese"), 0),
+		[]pantry.Item{{Name: "onion"}}, now)
+
+	assert.Equal(t, "Dairy & Eggs\n- 3 eggs\n- 50 g cheddar cheese\n", list.Text())
+	assert.Equal(t, "# Shopping list\n\n## Dairy & Eggs\n\n- [ ] 3 eggs\n- [ ] 50 g cheddar cheese\n\n## Already in your pantry\n\n- [x] onion\n", list.Markdown())
+
+	csv, err := list.CSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "aisle,item,quantity,unit,recipes\nDairy & Eggs,eggs,3,,Omelette\nDairy & Eggs,cheddar cheese,50,g,Omelette\n", csv)
+}
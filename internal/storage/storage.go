@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// DB is the embedded database everything per-user is kept in. Values are
+// stored as JSON under a path of nested buckets, usually the feature and then
+// the user ID, e.g. ["diary", "entries", userID].
+type DB struct {
+	bolt *bolt.DB
+}
+
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+	return &DB{bolt: db}, nil
+}
+
+func (db *DB) Close() error {
+	return db.bolt.Close()
+}
+
+// Put stores v under key in the bucket at path, creating buckets as needed.
+func (db *DB) Put(path []string, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding value: %v", err)
+	}
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b, err := createBucket(tx, path)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Get decodes the value under key into v.
+func (db *DB) Get(path []string, key string, v interface{}) error {
+	return db.bolt.View(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return ErrNotFound
+		}
+		data := b.Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// Update decodes the value under key into v, calls fn to change it and
+// stores v again, all in one transaction so no other write comes between.
+// If fn returns an error nothing is stored.
+func (db *DB) Update(path []string, key string, v interface{}, fn func() error) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return ErrNotFound
+		}
+		data := b.Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("error encoding value: %w", err)
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Delete removes key from the bucket at path. Deleting a missing key
+// returns ErrNotFound.
+func (db *DB) Delete(path []string, key string) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil || b.Get([]byte(key)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// Each calls fn with every key and value in the bucket at path, in key
+// order. Nested buckets are skipped.
+func (db *DB) Each(path []string, fn func(key string, data []byte) error) error {
+	return db.bolt.View(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+			return fn(string(k), v)
+		})
+	})
+}
+
+// PutRaw stores data under key as it is, for values such as photos that
+// aren't worth encoding as JSON.
+func (db *DB) PutRaw(path []string, key string, data []byte) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b, err := createBucket(tx, path)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Prefix calls fn with every key starting with prefix in the bucket at path,
+// and its value, in key order.
+func (db *DB) Prefix(path []string, prefix string, fn func(key string, data []byte) error) error {
+	return db.bolt.View(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			if v == nil {
+				continue
+			}
+			if err := fn(string(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reverse calls fn with the keys and values in the bucket at path from the
+// last key down, starting below before if it's set, until fn returns false.
+// With time-ordered keys it pages newest first without reading the rest.
+func (db *DB) Reverse(path []string, before string, fn func(key string, data []byte) (bool, error)) error {
+	return db.bolt.View(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		var k, v []byte
+		if before == "" {
+			k, v = c.Last()
+		} else if k, v = c.Seek([]byte(before)); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil; k, v = c.Prev() {
+			if v == nil {
+				continue
+			}
+			more, err := fn(string(k), v)
+			if err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Count returns how many keys are in the bucket at path.
+func (db *DB) Count(path []string) (int, error) {
+	n := 0
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		if b := bucket(tx, path); b != nil {
+			n = b.Stats().KeyN
+		}
+		return nil
+	})
+	return n, err
+}
+
+// DeletePrefix removes every key starting with prefix from the bucket at
+// path. Nothing matching isn't an error.
+func (db *DB) DeletePrefix(path []string, prefix string) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Seek([]byte(prefix)) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Buckets lists the names of the buckets nested in the bucket at path, such
+// as every user who has stored something for a feature.
+func (db *DB) Buckets(path []string) ([]string, error) {
+	var names []string
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		b := bucket(tx, path)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				names = append(names, string(k))
+			}
+			return nil
+		})
+	})
+	return names, err
+}
+
+// DeleteBucket removes the bucket at path and everything in it. A missing
+// bucket isn't an error.
+func (db *DB) DeleteBucket(path []string) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		if len(path) == 1 {
+			err := tx.DeleteBucket([]byte(path[0]))
+			if errors.Is(err, bolt.ErrBucketNotFound) {
+				return nil
+			}
+			return err
+		}
+		parent := bucket(tx, path[:len(path)-1])
+		if parent == nil {
+			return nil
+		}
+		err := parent.DeleteBucket([]byte(path[len(path)-1]))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+func bucket(tx *bolt.Tx, path []string) *bolt.Bucket {
+	if len(path) == 0 {
+		return nil
+	}
+	b := tx.Bucket([]byte(path[0]))
+	for _, name := range path[1:] {
+		if b == nil {
+			return nil
+		}
+		b = b.Bucket([]byte(name))
+	}
+	return b
+}
+
+func createBucket(tx *bolt.Tx, path []string) (*bolt.Bucket, error) {
+	if len(path) == 0 {
+		return nil, errors.New("empty bucket path")
+	}
+	b, err := tx.CreateBucketIfNotExists([]byte(path[0]))
+	for _, name := range path[1:] {
+		if err != nil {
+			return nil, err
+		}
+		b, err = b.CreateBucketIfNotExists([]byte(name))
+	}
+	return b, err
+}
+
+// NewID returns a random ID for a stored record.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
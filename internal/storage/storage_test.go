@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func TestDB(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	path := []string{"things", "user-1"}
+	var got item
+	assert.ErrorIs(t, db.Get(path, "a", &got), ErrNotFound)
+
+	assert.NoError(t, db.Put(path, "b", item{Name: "bee"}))
+	assert.NoError(t, db.Put(path, "a", item{Name: "ay"}))
+	assert.NoError(t, db.Put([]string{"things", "user-2"}, "c", item{Name: "see"}))
+
+	assert.NoError(t, db.Get(path, "a", &got))
+	assert.Equal(t, "ay", got.Name)
+
+	var keys []string
+	assert.NoError(t, db.Each(path, func(key string, data []byte) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	assert.NoError(t, db.Update(path, "a", &got, func() error {
+		got.Name += "e"
+		return nil
+	}))
+	assert.NoError(t, db.Get(path, "a", &got))
+	assert.Equal(t, "aye", got.Name)
+	assert.Error(t, db.Update(path, "a", &got, func() error {
+		got.Name = "lost"
+		return errors.New("changed my mind")
+	}))
+	assert.NoError(t, db.Get(path, "a", &got))
+	assert.Equal(t, "aye", got.Name)
+	assert.ErrorIs(t, db.Update(path, "z", &got, func() error { return nil }), ErrNotFound)
+
+	assert.NoError(t, db.Delete(path, "a"))
+	assert.ErrorIs(t, db.Delete(path, "a"), ErrNotFound)
+	assert.ErrorIs(t, db.Get(path, "a", &got), ErrNotFound)
+
+	users, err := db.Buckets([]string{"things"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, users)
+
+	// Nothing has been stored for this user yet.
+	assert.NoError(t, db.Each([]string{"things", "user-3"}, func(string, []byte) error {
+		t.Fatal("unexpected value")
+		return nil
+	}))
+}
+
+func TestCursors(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	path := []string{"log", "user-1"}
+	for _, k := range []string{"1/a", "1/b", "2/a", "3/a"} {
+		assert.NoError(t, db.PutRaw(path, k, []byte(k)))
+	}
+	n, err := db.Count(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	var got []string
+	collect := func(key string, data []byte) error {
+		assert.Equal(t, key, string(data))
+		got = append(got, key)
+		return nil
+	}
+	assert.NoError(t, db.Prefix(path, "1/", collect))
+	assert.Equal(t, []string{"1/a", "1/b"}, got)
+
+	newest := func(before string, limit int) []string {
+		got = nil
+		assert.NoError(t, db.Reverse(path, before, func(key string, data []byte) (bool, error) {
+			got = append(got, key)
+			return len(got) < limit, nil
+		}))
+		return got
+	}
+	assert.Equal(t, []string{"3/a", "2/a"}, newest("", 2))
+	assert.Equal(t, []string{"1/b", "1/a"}, newest("2/a", 2))
+	assert.Equal(t, []string{"2/a", "1/b", "1/a"}, newest("2/b", 5))
+	assert.Equal(t, []string{"3/a"}, newest("9", 1))
+	assert.Empty(t, newest("1/a", 1))
+
+	assert.NoError(t, db.DeletePrefix(path, "1/"))
+	got = nil
+	assert.NoError(t, db.Each(path, func(key string, data []byte) error { return collect(key, data) }))
+	assert.Equal(t, []string{"2/a", "3/a"}, got)
+	assert.NoError(t, db.DeletePrefix([]string{"log", "user-2"}, "1/"))
+}
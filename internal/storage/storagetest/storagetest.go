@@ -0,0 +1,22 @@
+// Package storagetest opens databases for tests. It's kept apart from
+// storage so the testing package isn't built into the server.
+package storagetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+// Open opens a database in a temporary directory for a test and closes it
+// when the test ends.
+func Open(t testing.TB) *storage.DB {
+	t.Helper()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,151 @@
+package substitute
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/Oluwaseun241/mura/internal/units"
+)
+
+// Substitution is one way to replace an ingredient. Ratio is how much of the
+// substitute to use per unit of the original; Unit, when set, is the unit the
+// substitute is measured in for each whole original ("1 tbsp flaxseed per
+// egg"), otherwise the substitute keeps the original's unit. Per is the unit
+// of a measured original that Ratio counts ("1/4 tsp ground ginger per tbsp
+// fresh").
+type Substitution struct {
+	Ingredient    string           `json:"ingredient"`
+	Ratio         float64          `json:"ratio"`
+	Unit          string           `json:"unit,omitempty"`
+	Per           string           `json:"per,omitempty"`
+	RatioNote     string           `json:"ratio_note"`
+	Taste         string           `json:"taste"`
+	Texture       string           `json:"texture"`
+	Prep          string           `json:"prep,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Quality       float64          `json:"-"`
+	Score         float64          `json:"score"`
+	Source        string           `json:"source"`
+	Replacement   *ingredient.Item `json:"replacement,omitempty"`
+	AdjustedSteps []string         `json:"adjusted_steps,omitempty"`
+}
+
+// Constraints are dietary requirements a substitute has to meet. The table
+// can only vouch for these; anything else is left to the model.
+var Constraints = []string{
+	"vegan", "vegetarian", "dairy-free", "gluten-free", "egg-free", "nut-free", "low-fat", "halal",
+}
+
+// Lookup returns curated substitutes for the ingredient that satisfy every
+// constraint, best first. Substitutes the user already has (have) rank above
+// those they would need to buy.
+func Lookup(name string, constraints []string, have []string) []Substitution {
+	candidates, ok := table[ingredient.Normalize(name)]
+	if !ok {
+		return nil
+	}
+
+	var subs []Substitution
+	for _, s := range candidates {
+		if !satisfies(s, constraints) {
+			continue
+		}
+		s.Source = "table"
+		s.Score = s.Quality
+		for _, h := range have {
+			if ingredient.Same(h, s.Ingredient) {
+				s.Score += 0.5
+				break
+			}
+		}
+		subs = append(subs, s)
+	}
+
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].Score > subs[j].Score })
+	return subs
+}
+
+func satisfies(s Substitution, constraints []string) bool {
+	for _, c := range constraints {
+		c = strings.ToLower(strings.TrimSpace(c))
+		found := false
+		for _, tag := range s.Tags {
+			if tag == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply works out how the substitution changes a recipe: the replacement
+// ingredient line and the steps with the original ingredient swapped out.
+// The substitute's preparation, if any, becomes the first step.
+func Apply(r *recipe.Recipe, name string, s *Substitution) {
+	for _, item := range r.Ingredients {
+		if ingredient.Same(item.Name, name) {
+			s.Replacement = replacement(item, *s)
+			break
+		}
+	}
+
+	pattern := namePattern(name)
+	var steps []string
+	if s.Prep != "" {
+		steps = append(steps, s.Prep)
+	}
+	for _, step := range r.Steps {
+		steps = append(steps, pattern.ReplaceAllString(step, s.Ingredient))
+	}
+	s.AdjustedSteps = steps
+}
+
+func replacement(original ingredient.Item, s Substitution) *ingredient.Item {
+	item := ingredient.Item{
+		Name:      s.Ingredient,
+		Canonical: ingredient.Normalize(s.Ingredient),
+		Note:      s.RatioNote,
+	}
+	if original.Quantity == 0 {
+		return &item
+	}
+
+	_, measured := units.LookupUnit(original.Unit)
+	switch {
+	case s.Unit == "":
+		item.Quantity, item.MaxQuantity, item.Unit = original.Quantity*s.Ratio, original.MaxQuantity*s.Ratio, original.Unit
+	case !measured:
+		// Counted originals ("2 eggs", "3 cloves") scale per piece.
+		item.Quantity, item.MaxQuantity, item.Unit = original.Quantity*s.Ratio, original.MaxQuantity*s.Ratio, s.Unit
+	default:
+		q, err := units.Convert(units.Quantity{Amount: original.Quantity, Max: original.MaxQuantity, Unit: original.Unit}, s.Per, original.Name)
+		if s.Per != "" && err == nil {
+			item.Quantity, item.MaxQuantity, item.Unit = q.Amount*s.Ratio, q.Max*s.Ratio, s.Unit
+		} else {
+			// We can't scale this measure, so say how much it replaces
+			// rather than leave the amount out.
+			item.Quantity, item.MaxQuantity, item.Unit = original.Quantity, original.MaxQuantity, original.Unit
+			item.Note = "in place of " + strings.TrimSpace(original.String()) + "; " + s.RatioNote
+		}
+	}
+	item.Raw = item.String()
+	return &item
+}
+
+// namePattern matches the ingredient in free text, in the singular or
+// plural, as a whole word.
+func namePattern(name string) *regexp.Regexp {
+	name = strings.ToLower(strings.TrimSpace(name))
+	alts := []string{regexp.QuoteMeta(name)}
+	if canonical := ingredient.Normalize(name); canonical != name {
+		alts = append(alts, regexp.QuoteMeta(canonical))
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alts, "|") + `)(?:e?s)?\b`)
+}
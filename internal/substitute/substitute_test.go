@@ -0,0 +1,95 @@
+package substitute
+
+import (
+	"testing"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	subs := Lookup("Eggs", nil, nil)
+	if assert.NotEmpty(t, subs) {
+		assert.Equal(t, "flaxseed", subs[0].Ingredient)
+		assert.Equal(t, "table", subs[0].Source)
+	}
+
+	subs = Lookup("milk", []string{"vegan", "nut-free"}, []string{"coconut milk"})
+	if assert.NotEmpty(t, subs) {
+		assert.Equal(t, "coconut milk", subs[0].Ingredient)
+		for _, s := range subs {
+			assert.NotEqual(t, "almond milk", s.Ingredient)
+			assert.NotEqual(t, "evaporated milk", s.Ingredient)
+		}
+	}
+
+	assert.Empty(t, Lookup("milk", []string{"keto"}, nil))
+	assert.Empty(t, Lookup("unobtainium", nil, nil))
+}
+
+func TestApply(t *testing.T) {
+	r := &recipe.Recipe{
+		Title: "Pancakes",
+		Ingredients: []ingredient.Item{
+			ingredient.Parse("1 1/2 cups flour"),
+			ingredient.Parse("2 eggs"),
+			ingredient.Parse("1 cup buttermilk"),
+		},
+		Steps: []string{"Whisk the eggs and buttermilk together.", "Fold in the flour."},
+	}
+
+	s := Lookup("egg", []string{"vegan"}, nil)[0]
+	Apply(r, "egg", &s)
+	if assert.NotNil(t, s.Replacement) {
+		assert.Equal(t, 2.0, s.Replacement.Quantity)
+		assert.Equal(t, "tbsp", s.Replacement.Unit)
+	}
+	assert.Equal(t, []string{
+		"Mix the ground flaxseed with water and let it gel for 10 minutes.",
+		"Whisk the flaxseed and buttermilk together.",
+		"Fold in the flour.",
+	}, s.AdjustedSteps)
+
+	s = Lookup("buttermilk", nil, nil)[0]
+	Apply(r, "buttermilk", &s)
+	if assert.NotNil(t, s.Replacement) {
+		assert.Equal(t, "1 cup milk (1 cup milk plus 1 tbsp lemon juice or vinegar per cup of buttermilk)", s.Replacement.String())
+	}
+}
+
+func TestApplyMeasured(t *testing.T) {
+	r := &recipe.Recipe{
+		Title: "Stir Fry",
+		Ingredients: []ingredient.Item{
+			ingredient.Parse("2 tbsp ginger, grated"),
+			ingredient.Parse("500 ml chicken stock"),
+		},
+		Steps: []string{"Fry the ginger.", "Add the chicken stock."},
+	}
+
+	s := Lookup("ginger", nil, nil)[0]
+	Apply(r, "ginger", &s)
+	if assert.NotNil(t, s.Replacement) {
+		assert.Equal(t, 0.5, s.Replacement.Quantity)
+		assert.Equal(t, "tsp", s.Replacement.Unit)
+	}
+
+	s = Lookup("chicken stock", []string{"egg-free"}, nil)[1]
+	assert.Equal(t, "bouillon cube", s.Ingredient)
+	Apply(r, "chicken stock", &s)
+	if assert.NotNil(t, s.Replacement) {
+		assert.InDelta(t, 1.06, s.Replacement.Quantity, 0.01)
+		assert.Equal(t, "cube", s.Replacement.Unit)
+	}
+
+	// A weight of fresh ginger can't be turned into tablespoons
+	r.Ingredients[0] = ingredient.Parse("30 g ginger")
+	s = Lookup("ginger", nil, nil)[0]
+	Apply(r, "ginger", &s)
+	if assert.NotNil(t, s.Replacement) {
+		assert.Equal(t, 30.0, s.Replacement.Quantity)
+		assert.Equal(t, "g", s.Replacement.Unit)
+		assert.Contains(t, s.Replacement.Note, "in place of 30 g ginger")
+	}
+}
@@ -0,0 +1,151 @@
+package substitute
+
+// table is the curated substitution list, keyed by canonical ingredient name
+// (see ingredient.Normalize). Quality is our confidence that the swap works in
+// most recipes, from 0 to 1.
+var table = map[string][]Substitution{
+	"buttermilk": {
+		{Ingredient: "milk", Ratio: 1, RatioNote: "1 cup milk plus 1 tbsp lemon juice or vinegar per cup of buttermilk", Taste: "slightly less tangy", Texture: "thinner", Prep: "Stir 1 tbsp lemon juice or vinegar into each cup of milk and let it stand for 5 minutes.", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "yogurt", Ratio: 0.75, RatioNote: "3/4 cup yogurt thinned with 1/4 cup water per cup of buttermilk", Taste: "similar tang", Texture: "slightly richer", Prep: "Whisk the yogurt with water until smooth.", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "soy milk", Ratio: 1, RatioNote: "1 cup soy milk plus 1 tbsp lemon juice per cup of buttermilk", Taste: "faint bean flavour", Texture: "thinner", Prep: "Stir the lemon juice into the soy milk and let it curdle for 5 minutes.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+	},
+	"butter": {
+		{Ingredient: "oil", Ratio: 0.75, RatioNote: "3/4 the amount of neutral oil", Taste: "less rich, no buttery flavour", Texture: "moister, denser bakes; won't cream with sugar", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "margarine", Ratio: 1, RatioNote: "same amount", Taste: "milder", Texture: "very close", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "coconut oil", Ratio: 1, RatioNote: "same amount, solid for baking", Taste: "light coconut flavour", Texture: "close when solid", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+		{Ingredient: "greek yogurt", Ratio: 0.5, RatioNote: "half the amount, in baking only", Taste: "tangier", Texture: "cakier, lower fat", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.6},
+	},
+	"egg": {
+		{Ingredient: "flaxseed", Ratio: 1, Unit: "tbsp", RatioNote: "1 tbsp ground flaxseed plus 3 tbsp water per egg", Taste: "nutty", Texture: "denser; binds but doesn't leaven", Prep: "Mix the ground flaxseed with water and let it gel for 10 minutes.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "banana", Ratio: 0.25, Unit: "cup", RatioNote: "1/4 cup mashed ripe banana per egg", Taste: "sweet banana flavour", Texture: "moist, denser", Prep: "Mash the banana until smooth.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+		{Ingredient: "yogurt", Ratio: 0.25, Unit: "cup", RatioNote: "1/4 cup yogurt per egg", Taste: "slightly tangy", Texture: "moist", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.65},
+		{Ingredient: "aquafaba", Ratio: 3, Unit: "tbsp", RatioNote: "3 tbsp chickpea cooking liquid per egg", Taste: "neutral once cooked", Texture: "light; whips like egg white", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+	},
+	"milk": {
+		{Ingredient: "soy milk", Ratio: 1, RatioNote: "same amount", Taste: "faint bean flavour", Texture: "very close", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "oat milk", Ratio: 1, RatioNote: "same amount", Taste: "slightly sweet", Texture: "very close", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "almond milk", Ratio: 1, RatioNote: "same amount", Taste: "nutty", Texture: "thinner", Tags: []string{"vegan", "vegetarian", "dairy-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.85},
+		{Ingredient: "evaporated milk", Ratio: 0.5, RatioNote: "half evaporated milk, half water", Taste: "slightly caramelised", Texture: "same once diluted", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "coconut milk", Ratio: 1, RatioNote: "same amount", Taste: "coconut flavour", Texture: "richer", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+	},
+	"heavy cream": {
+		{Ingredient: "milk", Ratio: 0.75, RatioNote: "3/4 cup milk plus 1/4 cup melted butter per cup of cream", Taste: "close", Texture: "won't whip", Prep: "Whisk the melted butter into the milk.", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "coconut cream", Ratio: 1, RatioNote: "same amount", Taste: "coconut flavour", Texture: "close; whips when chilled", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+		{Ingredient: "evaporated milk", Ratio: 1, RatioNote: "same amount, in sauces and soups", Taste: "slightly caramelised", Texture: "thinner, won't whip", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.65},
+	},
+	"sour cream": {
+		{Ingredient: "greek yogurt", Ratio: 1, RatioNote: "same amount", Taste: "tangier", Texture: "very close", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.9},
+		{Ingredient: "cashew cream", Ratio: 1, RatioNote: "same amount", Taste: "mild, nutty", Texture: "close", Prep: "Blend soaked cashews with lemon juice and a little water until smooth.", Tags: []string{"vegan", "vegetarian", "dairy-free", "gluten-free", "egg-free"}, Quality: 0.7},
+	},
+	"yogurt": {
+		{Ingredient: "sour cream", Ratio: 1, RatioNote: "same amount", Taste: "richer", Texture: "thicker", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "coconut yogurt", Ratio: 1, RatioNote: "same amount", Taste: "coconut flavour", Texture: "close", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+	},
+	"all-purpose flour": {
+		{Ingredient: "gluten-free flour blend", Ratio: 1, RatioNote: "same amount of a 1:1 blend", Taste: "close", Texture: "more crumbly", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+		{Ingredient: "whole wheat flour", Ratio: 0.75, RatioNote: "3/4 the amount", Taste: "nuttier", Texture: "denser", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.7},
+		{Ingredient: "oat flour", Ratio: 1.3, RatioNote: "1 1/3 cups per cup of flour", Taste: "slightly sweet", Texture: "more tender, less structure", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.6},
+	},
+	"self-raising flour": {
+		{Ingredient: "all-purpose flour", Ratio: 1, RatioNote: "1 cup flour plus 1 1/2 tsp baking powder and 1/4 tsp salt per cup", Taste: "same", Texture: "same", Prep: "Whisk the baking powder and salt into the flour.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.95},
+	},
+	"cornstarch": {
+		{Ingredient: "all-purpose flour", Ratio: 2, RatioNote: "2 tbsp flour per tbsp of cornstarch", Taste: "same", Texture: "cloudier sauce", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.8},
+		{Ingredient: "arrowroot", Ratio: 1, RatioNote: "same amount", Taste: "neutral", Texture: "glossier", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "cassava starch", Ratio: 1, RatioNote: "same amount", Taste: "neutral", Texture: "slightly stretchier", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+	},
+	"baking powder": {
+		{Ingredient: "baking soda", Ratio: 0.25, RatioNote: "1/4 tsp baking soda plus 1/2 tsp cream of tartar per tsp", Taste: "same if balanced", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+	},
+	"baking soda": {
+		{Ingredient: "baking powder", Ratio: 3, RatioNote: "3 tsp baking powder per tsp of baking soda", Taste: "can taste slightly bitter", Texture: "similar rise", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"sugar": {
+		{Ingredient: "honey", Ratio: 0.75, RatioNote: "3/4 cup honey per cup of sugar; cut other liquid by 3 tbsp", Taste: "floral", Texture: "moister; browns faster", Prep: "Lower the oven temperature by 25°F (15°C).", Tags: []string{"vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+		{Ingredient: "brown sugar", Ratio: 1, RatioNote: "same amount", Taste: "light caramel notes", Texture: "moister", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "maple syrup", Ratio: 0.75, RatioNote: "3/4 cup per cup of sugar; cut other liquid by 3 tbsp", Taste: "maple flavour", Texture: "moister", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+	},
+	"brown sugar": {
+		{Ingredient: "sugar", Ratio: 1, RatioNote: "1 cup sugar plus 1 tbsp molasses per cup", Taste: "close", Texture: "slightly drier without molasses", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+	},
+	"honey": {
+		{Ingredient: "maple syrup", Ratio: 1, RatioNote: "same amount", Taste: "maple rather than floral", Texture: "thinner", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "sugar", Ratio: 1.25, RatioNote: "1 1/4 cups sugar plus 1/4 cup liquid per cup of honey", Taste: "less floral", Texture: "drier", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+	},
+	"lemon juice": {
+		{Ingredient: "lime juice", Ratio: 1, RatioNote: "same amount", Taste: "slightly more floral", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "vinegar", Ratio: 0.5, RatioNote: "half the amount", Taste: "sharper, no citrus", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.65},
+	},
+	"white wine": {
+		{Ingredient: "chicken stock", Ratio: 1, RatioNote: "same amount plus a splash of vinegar", Taste: "savoury, less acidic", Texture: "same", Tags: []string{"dairy-free", "nut-free", "gluten-free", "egg-free", "halal"}, Quality: 0.8},
+		{Ingredient: "white grape juice", Ratio: 1, RatioNote: "same amount plus 1 tbsp vinegar per cup", Taste: "sweeter", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free", "halal"}, Quality: 0.7},
+	},
+	"red wine": {
+		{Ingredient: "beef stock", Ratio: 1, RatioNote: "same amount plus 1 tbsp red wine vinegar per cup", Taste: "savoury, less fruity", Texture: "same", Tags: []string{"dairy-free", "nut-free", "gluten-free", "egg-free", "halal"}, Quality: 0.8},
+		{Ingredient: "pomegranate juice", Ratio: 1, RatioNote: "same amount", Taste: "fruitier, sweeter", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free", "halal"}, Quality: 0.7},
+	},
+	"soy sauce": {
+		{Ingredient: "tamari", Ratio: 1, RatioNote: "same amount", Taste: "richer, less salty", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.95},
+		{Ingredient: "coconut aminos", Ratio: 1, RatioNote: "same amount", Taste: "sweeter, much less salty", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+	},
+	"fish sauce": {
+		{Ingredient: "soy sauce", Ratio: 1, RatioNote: "same amount plus a squeeze of lime", Taste: "less funky", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.7},
+	},
+	"garlic": {
+		{Ingredient: "garlic powder", Ratio: 0.125, Unit: "tsp", RatioNote: "1/8 tsp garlic powder per clove", Taste: "milder, less sharp", Texture: "no bite", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.8},
+	},
+	"onion": {
+		{Ingredient: "shallot", Ratio: 3, RatioNote: "3 shallots per medium onion", Taste: "sweeter, milder", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "spring onion", Ratio: 4, RatioNote: "about 4 spring onions per medium onion", Taste: "milder, greener", Texture: "softer", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "onion powder", Ratio: 1, Unit: "tbsp", RatioNote: "1 tbsp onion powder per medium onion", Taste: "flatter", Texture: "none", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"ginger": {
+		{Ingredient: "ground ginger", Ratio: 0.25, Unit: "tsp", Per: "tbsp", RatioNote: "1/4 tsp ground ginger per tbsp fresh", Taste: "warmer, less zingy", Texture: "none", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+	},
+	"tomato paste": {
+		{Ingredient: "tomato", Ratio: 3, RatioNote: "3 tbsp blended tomatoes, reduced, per tbsp of paste", Taste: "fresher, less intense", Texture: "looser", Prep: "Cook the blended tomatoes down until thick before adding.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "ketchup", Ratio: 1, RatioNote: "same amount", Taste: "sweeter, vinegary", Texture: "thinner", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.55},
+	},
+	"breadcrumb": {
+		{Ingredient: "rolled oats", Ratio: 1, RatioNote: "same amount, pulsed", Taste: "nuttier", Texture: "chewier", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.75},
+		{Ingredient: "crushed crackers", Ratio: 1, RatioNote: "same amount", Taste: "saltier", Texture: "crisper", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.75},
+	},
+	"chicken stock": {
+		{Ingredient: "vegetable stock", Ratio: 1, RatioNote: "same amount", Taste: "lighter", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "bouillon cube", Ratio: 0.5, Unit: "cube", Per: "cup", RatioNote: "1 cube dissolved in 2 cups hot water per 2 cups of stock", Taste: "saltier", Texture: "same", Prep: "Dissolve the bouillon cube in hot water.", Tags: []string{"dairy-free", "nut-free", "egg-free"}, Quality: 0.8},
+	},
+	"bouillon cube": {
+		{Ingredient: "chicken stock", Ratio: 2, Unit: "cup", RatioNote: "2 cups stock per cube, replacing the same amount of water", Taste: "rounder, less salty", Texture: "same", Tags: []string{"dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.85},
+		{Ingredient: "salt", Ratio: 1, RatioNote: "1/2 tsp salt plus a pinch of dried thyme per cube", Taste: "less savoury", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.5},
+	},
+	"chicken": {
+		{Ingredient: "turkey", Ratio: 1, RatioNote: "same weight", Taste: "slightly gamier", Texture: "leaner", Tags: []string{"dairy-free", "nut-free", "gluten-free", "egg-free", "halal"}, Quality: 0.85},
+		{Ingredient: "firm tofu", Ratio: 1, RatioNote: "same weight, pressed", Taste: "neutral; takes on the sauce", Texture: "softer", Prep: "Press the tofu for 15 minutes and cut it into bite-sized pieces.", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.65},
+	},
+	"ground beef": {
+		{Ingredient: "ground turkey", Ratio: 1, RatioNote: "same weight", Taste: "milder", Texture: "leaner, drier", Tags: []string{"dairy-free", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.8},
+		{Ingredient: "lentil", Ratio: 1, RatioNote: "same weight of cooked lentils", Taste: "earthy", Texture: "softer", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.65},
+	},
+	"scotch bonnet pepper": {
+		{Ingredient: "habanero pepper", Ratio: 1, RatioNote: "same amount", Taste: "very close, slightly less fruity", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.95},
+		{Ingredient: "cayenne pepper", Ratio: 0.25, Unit: "tsp", RatioNote: "1/4 tsp cayenne per pepper", Taste: "heat without the fruitiness", Texture: "none", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"palm oil": {
+		{Ingredient: "oil", Ratio: 1, RatioNote: "same amount plus 1/2 tsp paprika for colour", Taste: "lacks palm oil's earthiness", Texture: "same", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"coconut milk": {
+		{Ingredient: "heavy cream", Ratio: 1, RatioNote: "same amount", Taste: "no coconut flavour", Texture: "richer", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.7},
+		{Ingredient: "evaporated milk", Ratio: 1, RatioNote: "same amount plus a drop of coconut extract", Taste: "milky", Texture: "thinner", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"parmesan": {
+		{Ingredient: "pecorino", Ratio: 1, RatioNote: "same amount", Taste: "saltier, sharper", Texture: "same", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free"}, Quality: 0.9},
+		{Ingredient: "nutritional yeast", Ratio: 0.5, RatioNote: "half the amount", Taste: "cheesy, nutty", Texture: "powdery", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.6},
+	},
+	"mayonnaise": {
+		{Ingredient: "greek yogurt", Ratio: 1, RatioNote: "same amount", Taste: "tangier, lighter", Texture: "close", Tags: []string{"vegetarian", "nut-free", "gluten-free", "egg-free", "low-fat"}, Quality: 0.75},
+	},
+	"rice": {
+		{Ingredient: "couscous", Ratio: 1, RatioNote: "same amount, cooked separately", Taste: "wheaty", Texture: "finer", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "egg-free"}, Quality: 0.6},
+		{Ingredient: "quinoa", Ratio: 1, RatioNote: "same amount", Taste: "nuttier", Texture: "lighter", Tags: []string{"vegan", "vegetarian", "dairy-free", "nut-free", "gluten-free", "egg-free"}, Quality: 0.65},
+	},
+}
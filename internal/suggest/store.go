@@ -0,0 +1,38 @@
+package suggest
+
+import (
+	"errors"
+
+	"github.com/Oluwaseun241/mura/internal/storage"
+)
+
+var ErrNotFound = errors.New("no suggestions yet")
+
+// Store keeps the latest suggestions computed for each user.
+type Store interface {
+	Latest(userID string) (Suggestions, error)
+	Save(userID string, s Suggestions) error
+}
+
+type BoltStore struct {
+	db *storage.DB
+}
+
+func NewBoltStore(db *storage.DB) *BoltStore {
+	return &BoltStore{db: db}
+}
+
+var suggestionsPath = []string{"suggestions"}
+
+func (s *BoltStore) Latest(userID string) (Suggestions, error) {
+	var latest Suggestions
+	err := s.db.Get(suggestionsPath, userID, &latest)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Suggestions{}, ErrNotFound
+	}
+	return latest, err
+}
+
+func (s *BoltStore) Save(userID string, latest Suggestions) error {
+	return s.db.Put(suggestionsPath, userID, latest)
+}
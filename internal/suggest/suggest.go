@@ -0,0 +1,155 @@
+package suggest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/ingredient"
+	"github.com/Oluwaseun241/mura/internal/pantry"
+)
+
+// Window is how close to its expiry date an item has to be before we push
+// recipes that use it up.
+const Window = 7 * 24 * time.Hour
+
+// Idea is a recipe idea ranked by how much of the pantry, and especially of
+// what is about to go off, it uses.
+type Idea struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Ingredients  []string `json:"ingredients"`
+	UsesExpiring []string `json:"uses_expiring"`
+	UsesPantry   []string `json:"uses_pantry"`
+	Missing      []string `json:"missing"`
+	Score        float64  `json:"score"`
+}
+
+// Suggestions are the ranked ideas for a user's pantry at a point in time.
+// Pantry is the Fingerprint of the pantry they were made for.
+type Suggestions struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Pantry      string        `json:"pantry"`
+	Expiring    []pantry.Item `json:"expiring"`
+	Ideas       []Idea        `json:"ideas"`
+}
+
+// Fingerprint identifies which items are in a pantry, so removing one shows
+// up even though no remaining item changed.
+func Fingerprint(items []pantry.Item) string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Stale reports whether the pantry has changed since the suggestions were
+// made, by an item being added, changed or removed, or they were made on an
+// earlier day than now.
+func (s Suggestions) Stale(items []pantry.Item, now time.Time) bool {
+	y1, m1, d1 := s.GeneratedAt.Date()
+	y2, m2, d2 := now.In(s.GeneratedAt.Location()).Date()
+	if y1 != y2 || m1 != m2 || d1 != d2 {
+		return true
+	}
+	if s.Pantry != Fingerprint(items) {
+		return true
+	}
+	for _, item := range items {
+		if item.UpdatedAt.After(s.GeneratedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Urgency is how pressing it is to use the item: 1 for something expiring
+// now, halving as the days left grow, and 0 outside the Window or once it
+// has expired (we don't suggest cooking with spoiled food).
+func Urgency(item pantry.Item, now time.Time) float64 {
+	if item.ExpiresAt == nil {
+		return 0
+	}
+	left := item.ExpiresAt.Sub(now)
+	if left < 0 || left > Window {
+		return 0
+	}
+	return 1 / (1 + left.Hours()/24)
+}
+
+// Expiring returns the unexpired items that are inside the Window, soonest
+// first.
+func Expiring(items []pantry.Item, now time.Time) []pantry.Item {
+	expiring := []pantry.Item{}
+	for _, item := range items {
+		if Urgency(item, now) > 0 {
+			expiring = append(expiring, item)
+		}
+	}
+	pantry.SortByExpiry(expiring)
+	return expiring
+}
+
+// Rank scores each idea and orders them best first. Using an expiring item
+// is worth up to 10 points depending on its urgency, using anything else in
+// the pantry 1, and each ingredient that would have to be bought costs half
+// a point. Staples count as on hand.
+func Rank(ideas []Idea, items []pantry.Item, now time.Time) []Idea {
+	ranked := make([]Idea, 0, len(ideas))
+	for _, idea := range ideas {
+		idea.UsesExpiring, idea.UsesPantry, idea.Missing = []string{}, []string{}, []string{}
+		idea.Score = 0
+
+		used := map[string]bool{}
+		for _, line := range idea.Ingredients {
+			name := ingredient.Parse(line).Name
+			if name == "" {
+				continue
+			}
+			found := false
+			for _, item := range items {
+				if used[item.ID] || item.Expired(now) || !ingredient.Same(item.Name, name) {
+					continue
+				}
+				used[item.ID], found = true, true
+				if u := Urgency(item, now); u > 0 {
+					idea.UsesExpiring = append(idea.UsesExpiring, item.Name)
+					idea.Score += 10 * u
+				} else {
+					idea.UsesPantry = append(idea.UsesPantry, item.Name)
+					idea.Score++
+				}
+				break
+			}
+			if !found && !ingredient.IsStaple(name) {
+				idea.Missing = append(idea.Missing, name)
+				idea.Score -= 0.5
+			}
+		}
+		idea.Score = math.Round(idea.Score*10) / 10
+		ranked = append(ranked, idea)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+// NextRun returns the next time at or after now that falls on the given
+// hour of the day in now's location.
+func NextRun(now time.Time, hour int) time.Time {
+	y, m, d := now.Date()
+	next := time.Date(y, m, d, hour, 0, 0, 0, now.Location())
+	if next.Before(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
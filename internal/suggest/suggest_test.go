@@ -0,0 +1,97 @@
+package suggest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oluwaseun241/mura/internal/pantry"
+	"github.com/Oluwaseun241/mura/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func in(d time.Duration) *time.Time {
+	t := now.Add(d)
+	return &t
+}
+
+var day = 24 * time.Hour
+
+var items = []pantry.Item{
+	{ID: "1", Name: "spinach", ExpiresAt: in(0)},
+	{ID: "2", Name: "chicken thighs", ExpiresAt: in(day)},
+	{ID: "3", Name: "tomatoes", ExpiresAt: in(3 * day)},
+	{ID: "4", Name: "rice", ExpiresAt: in(300 * day)},
+	{ID: "5", Name: "milk", ExpiresAt: in(-day)},
+	{ID: "6", Name: "onions"},
+}
+
+func TestUrgency(t *testing.T) {
+	assert.Equal(t, 1.0, Urgency(items[0], now))
+	assert.Equal(t, 0.5, Urgency(items[1], now))
+	assert.Equal(t, 0.25, Urgency(items[2], now))
+	assert.Equal(t, 0.0, Urgency(items[3], now))
+	assert.Equal(t, 0.0, Urgency(items[4], now))
+	assert.Equal(t, 0.0, Urgency(items[5], now))
+
+	var names []string
+	for _, item := range Expiring(items, now) {
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"spinach", "chicken thighs", "tomatoes"}, names)
+}
+
+func TestRank(t *testing.T) {
+	ideas := Rank([]Idea{
+		{Title: "Plain rice", Ingredients: []string{"2 cups rice", "salt"}},
+		{Title: "Chicken and spinach stew", Ingredients: []string{"4 chicken thighs", "200g spinach", "2 tomatoes", "1 onion", "palm oil"}},
+		{Title: "Milk pudding", Ingredients: []string{"500ml milk", "sugar", "vanilla"}},
+	}, items, now)
+
+	assert.Equal(t, "Chicken and spinach stew", ideas[0].Title)
+	assert.Equal(t, []string{"chicken thighs", "spinach", "tomatoes"}, ideas[0].UsesExpiring)
+	assert.Equal(t, []string{"onions"}, ideas[0].UsesPantry)
+	assert.Empty(t, ideas[0].Missing)
+	assert.Equal(t, 18.5, ideas[0].Score)
+
+	assert.Equal(t, "Plain rice", ideas[1].Title)
+	assert.Equal(t, 1.0, ideas[1].Score)
+
+	// Expired milk doesn't count as on hand.
+	assert.Equal(t, "Milk pudding", ideas[2].Title)
+	assert.Equal(t, []string{"milk", "vanilla"}, ideas[2].Missing)
+	assert.Equal(t, -1.0, ideas[2].Score)
+}
+
+func TestStale(t *testing.T) {
+	s := Suggestions{GeneratedAt: now, Pantry: Fingerprint(items)}
+	assert.False(t, s.Stale(items, now.Add(time.Hour)))
+	assert.True(t, s.Stale(items, now.Add(day)))
+
+	changed := append([]pantry.Item{{Name: "eggs", UpdatedAt: now.Add(time.Minute)}}, items...)
+	assert.True(t, s.Stale(changed, now.Add(time.Hour)))
+
+	// Nothing left changed, but an item is gone
+	assert.True(t, s.Stale(items[1:], now.Add(time.Hour)))
+}
+
+func TestNextRun(t *testing.T) {
+	assert.Equal(t, time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC), NextRun(now, 6))
+	assert.Equal(t, time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC), NextRun(now, 18))
+	assert.Equal(t, now, NextRun(now, 9))
+}
+
+func TestBoltStore(t *testing.T) {
+	db := storagetest.Open(t)
+	s := NewBoltStore(db)
+
+	_, err := s.Latest("ada")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, s.Save("ada", Suggestions{GeneratedAt: now, Ideas: []Idea{{Title: "Stew"}}}))
+	latest, err := s.Latest("ada")
+	assert.NoError(t, err)
+	assert.Equal(t, "Stew", latest.Ideas[0].Title)
+	assert.True(t, latest.GeneratedAt.Equal(now))
+}
@@ -0,0 +1,335 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// densities holds grams per millilitre for ingredients that recipes commonly
+// measure by volume in one system and by weight in another.
+var densities = map[string]float64{
+	"all-purpose flour":  0.53,
+	"plain flour":        0.53,
+	"self-raising flour": 0.53,
+	"bread flour":        0.55,
+	"flour":              0.53,
+	"whole wheat flour":  0.51,
+	"cornflour":          0.54,
+	"cornstarch":         0.54,
+	"semolina":           0.71,
+	"icing sugar":        0.51,
+	"powdered sugar":     0.51,
+	"brown sugar":        0.93,
+	"caster sugar":       0.85,
+	"sugar":              0.85,
+	"honey":              1.42,
+	"syrup":              1.37,
+	"butter":             0.96,
+	"oil":                0.92,
+	"milk":               1.03,
+	"cream":              1.0,
+	"yogurt":             1.03,
+	"water":              1.0,
+	"stock":              1.0,
+	"broth":              1.0,
+	"rice":               0.78,
+	"oats":               0.38,
+	"cocoa":              0.42,
+	"salt":               1.22,
+	"breadcrumbs":        0.45,
+	"grated cheese":      0.42,
+	"cheese":             0.47,
+	"lentils":            0.82,
+	"beans":              0.75,
+	"peanut butter":      1.08,
+	"chopped nuts":       0.51,
+	"garri":              0.55,
+}
+
+var densityKeys = func() []string {
+	keys := make([]string, 0, len(densities))
+	for k := range densities {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return keys
+}()
+
+// liquids are measured by volume in every system, so Normalize never turns
+// them into weights even though their density is known.
+var liquids = map[string]bool{
+	"milk": true, "cream": true, "water": true, "stock": true, "broth": true, "oil": true,
+}
+
+func densityKey(ingredient string) string {
+	ingredient = strings.ToLower(ingredient)
+	for _, k := range densityKeys {
+		if strings.Contains(ingredient, k) {
+			return k
+		}
+	}
+	return ""
+}
+
+// Density returns grams per millilitre for the ingredient, matching the most
+// specific known name contained in it ("brown sugar" before "sugar").
+func Density(ingredient string) (float64, bool) {
+	k := densityKey(ingredient)
+	if k == "" {
+		return 0, false
+	}
+	return densities[k], true
+}
+
+// gasMarks maps gas marks to their conventional Celsius equivalents.
+var gasMarks = []struct {
+	Mark    float64
+	Celsius float64
+}{
+	{0.25, 110}, {0.5, 120}, {1, 140}, {2, 150}, {3, 170},
+	{4, 180}, {5, 190}, {6, 200}, {7, 220}, {8, 230}, {9, 240},
+}
+
+func toCelsius(q Quantity, v float64) (float64, error) {
+	switch q.Unit {
+	case "°C":
+		return v, nil
+	case "°F":
+		return (v - 32) * 5 / 9, nil
+	case "gas mark":
+		for _, g := range gasMarks {
+			if g.Mark == v {
+				return g.Celsius, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown gas mark %v", v)
+	}
+	return 0, fmt.Errorf("%q is not a temperature unit", q.Unit)
+}
+
+func fromCelsius(unit string, c float64) (float64, error) {
+	switch unit {
+	case "°C":
+		return c, nil
+	case "°F":
+		return c*9/5 + 32, nil
+	case "gas mark":
+		best := gasMarks[0]
+		for _, g := range gasMarks {
+			if math.Abs(g.Celsius-c) < math.Abs(best.Celsius-c) {
+				best = g
+			}
+		}
+		if math.Abs(best.Celsius-c) > 10 {
+			return 0, fmt.Errorf("%v°C has no gas mark equivalent", c)
+		}
+		return best.Mark, nil
+	}
+	return 0, fmt.Errorf("%q is not a temperature unit", unit)
+}
+
+// Convert converts a quantity into the named unit. Converting between volume
+// and mass needs the ingredient so its density can be looked up; pass an
+// empty string otherwise.
+func Convert(q Quantity, to string, ingredient string) (Quantity, error) {
+	from, ok := LookupUnit(q.Unit)
+	if !ok {
+		return Quantity{}, fmt.Errorf("unknown unit %q", q.Unit)
+	}
+	target, ok := LookupUnit(to)
+	if !ok {
+		return Quantity{}, fmt.Errorf("unknown unit %q", to)
+	}
+
+	convert := func(v float64) (float64, error) {
+		if from.Dimension == Temperature || target.Dimension == Temperature {
+			if from.Dimension != target.Dimension {
+				return 0, fmt.Errorf("cannot convert %s to %s", from.Name, target.Name)
+			}
+			c, err := toCelsius(q, v)
+			if err != nil {
+				return 0, err
+			}
+			return fromCelsius(target.Name, c)
+		}
+
+		base := v * from.Factor
+		if from.Dimension != target.Dimension {
+			density, ok := Density(ingredient)
+			if !ok {
+				return 0, fmt.Errorf("cannot convert %s to %s without a known density for %q", from.Name, target.Name, ingredient)
+			}
+			if from.Dimension == Volume {
+				base *= density
+			} else {
+				base /= density
+			}
+		}
+		return base / target.Factor, nil
+	}
+
+	out := Quantity{Unit: target.Name}
+	var err error
+	if out.Amount, err = convert(q.Amount); err != nil {
+		return Quantity{}, err
+	}
+	if q.Max != 0 {
+		if out.Max, err = convert(q.Max); err != nil {
+			return Quantity{}, err
+		}
+	}
+	return out, nil
+}
+
+// Normalize converts a quantity into the unit a cook in the given system
+// would expect to read, rounding to sensible kitchen precision. Teaspoons and
+// tablespoons are left alone in every system, and volumes of ingredients with
+// a known density switch to weight for metric and imperial cooks (and back to
+// cups for US cooks). Quantities that are already appropriate, or that cannot
+// be converted, are returned unchanged.
+func Normalize(q Quantity, system System, ingredient string) Quantity {
+	u, ok := LookupUnit(q.Unit)
+	if !ok {
+		return q
+	}
+
+	if u.Dimension == Temperature {
+		target := map[System]string{Metric: "°C", US: "°F", Imperial: "gas mark"}[system]
+		out, err := Convert(q, target, "")
+		if err != nil && system == Imperial {
+			out, err = Convert(q, "°C", "")
+		}
+		if err != nil {
+			return q
+		}
+		return roundQuantity(out)
+	}
+
+	if u.Name == "tsp" || u.Name == "tbsp" {
+		return q
+	}
+
+	dim := u.Dimension
+	if k := densityKey(ingredient); k != "" && !liquids[k] {
+		switch {
+		case dim == Volume && system != US:
+			dim = Mass
+		case dim == Mass && system == US:
+			dim = Volume
+		}
+	}
+
+	// Pick the target unit from the size of the quantity in base units.
+	base := q.Amount * u.Factor
+	if dim != u.Dimension {
+		density, _ := Density(ingredient)
+		if u.Dimension == Volume {
+			base *= density
+		} else {
+			base /= density
+		}
+	}
+	target := pickUnit(dim, system, base)
+	if target == u.Name {
+		return roundQuantity(q)
+	}
+	out, err := Convert(q, target, ingredient)
+	if err != nil {
+		return q
+	}
+	return roundQuantity(out)
+}
+
+func pickUnit(dim Dimension, system System, base float64) string {
+	switch dim {
+	case Mass:
+		if system == Metric {
+			if base >= 1000 {
+				return "kg"
+			}
+			return "g"
+		}
+		if base >= 453.592 {
+			return "lb"
+		}
+		return "oz"
+	case Volume:
+		switch system {
+		case Metric:
+			if base >= 1000 {
+				return "l"
+			}
+			return "ml"
+		case Imperial:
+			switch {
+			case base < 14.7868:
+				return "tsp"
+			case base < 2*28.4131:
+				return "tbsp"
+			case base < 568.261:
+				return "imp fl oz"
+			}
+			return "imp pint"
+		default:
+			switch {
+			case base < 14.7868:
+				return "tsp"
+			case base < 59.147:
+				return "tbsp"
+			case base < 8*236.588:
+				return "cup"
+			}
+			return "quart"
+		}
+	}
+	return ""
+}
+
+func roundQuantity(q Quantity) Quantity {
+	q.Amount = roundFor(q.Unit, q.Amount)
+	if q.Max != 0 {
+		q.Max = roundFor(q.Unit, q.Max)
+	}
+	return q
+}
+
+func roundFor(unit string, v float64) float64 {
+	step := 0.01
+	switch unit {
+	case "g", "ml":
+		step = 1
+		if v >= 50 {
+			step = 5
+		}
+	case "kg", "l":
+		step = 0.05
+	case "°C":
+		step = 5
+	case "°F":
+		step = 5
+		if v >= 250 {
+			// Oven dials go up in 25°F steps.
+			step = 25
+		}
+	case "gas mark":
+		return v
+	case "oz", "fl oz", "imp fl oz":
+		step = 0.5
+	case "lb":
+		step = 0.25
+	case "cup":
+		step = 0.125
+		if v >= 1 {
+			step = 0.25
+		}
+	case "tsp", "tbsp", "pint", "imp pint", "quart", "gallon":
+		step = 0.125
+	}
+	r := math.Round(v/step) * step
+	if r == 0 && v > 0 {
+		return step
+	}
+	return r
+}
@@ -0,0 +1,124 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Locale controls how quantities are converted and written out.
+type Locale struct {
+	System       System `json:"system"`
+	DecimalComma bool   `json:"decimal_comma,omitempty"`
+}
+
+// usCustomaryRegions are the regions that cook with US customary units.
+var usCustomaryRegions = map[string]bool{"US": true, "LR": true, "MM": true}
+
+// decimalCommaLanguages write 1,5 rather than 1.5.
+var decimalCommaLanguages = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "pt": true, "nl": true,
+	"ru": true, "pl": true, "tr": true, "id": true, "sv": true, "da": true,
+	"nb": true, "fi": true, "cs": true, "ro": true, "uk": true, "el": true,
+}
+
+// ParseLocale accepts either a unit system ("metric", "us", "imperial" or
+// "uk") or a language tag such as "en-US", "en_GB" or "fr-FR".
+func ParseLocale(s string) (Locale, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "metric", "si":
+		return Locale{System: Metric}, nil
+	case "us", "us customary", "customary":
+		return Locale{System: US}, nil
+	case "imperial", "uk":
+		return Locale{System: Imperial}, nil
+	}
+
+	lang, region, _ := strings.Cut(strings.ReplaceAll(s, "_", "-"), "-")
+	lang = strings.ToLower(lang)
+	region = strings.ToUpper(region)
+	if len(lang) < 2 || len(lang) > 3 {
+		return Locale{}, fmt.Errorf("unknown units %q: use metric, us, imperial or a locale such as en-GB", s)
+	}
+
+	loc := Locale{System: Metric, DecimalComma: decimalCommaLanguages[lang]}
+	switch {
+	case usCustomaryRegions[region]:
+		loc.System = US
+	case region == "GB":
+		loc.System = Imperial
+	}
+	return loc, nil
+}
+
+// Format writes a quantity the way a cook in the locale expects: fractions
+// for US and imperial measures, decimals for metric ones.
+func (l Locale) Format(q Quantity) string {
+	u, ok := LookupUnit(q.Unit)
+	if !ok {
+		return strings.TrimSpace(l.FormatAmount(q.Amount, false) + " " + q.Unit)
+	}
+
+	useFractions := u.System != Metric && u.Dimension != Temperature
+	amount := l.FormatAmount(q.Amount, useFractions)
+	if q.Max != 0 && q.Max != q.Amount {
+		amount += "–" + l.FormatAmount(q.Max, useFractions)
+	}
+
+	switch u.Name {
+	case "gas mark":
+		return "gas mark " + amount
+	case "°C", "°F":
+		return amount + u.Name
+	}
+
+	name := displayName(u)
+	if u.Plural != "" && (q.Amount > 1 || q.Max > 1) {
+		name = displayName(Unit{Name: u.Plural})
+	}
+	return amount + " " + name
+}
+
+// displayName hides the "imp" prefix used to tell imperial measures apart
+// from their US namesakes; readers of an imperial recipe don't need it.
+func displayName(u Unit) string {
+	return strings.TrimPrefix(u.Name, "imp ")
+}
+
+var fractionGlyphs = []struct {
+	Value float64
+	Text  string
+}{
+	{0.125, "1/8"}, {0.25, "1/4"}, {1.0 / 3, "1/3"}, {0.375, "3/8"}, {0.5, "1/2"},
+	{0.625, "5/8"}, {2.0 / 3, "2/3"}, {0.75, "3/4"}, {0.875, "7/8"},
+}
+
+// FormatAmount formats a number, optionally as a kitchen fraction.
+func (l Locale) FormatAmount(v float64, fractions bool) string {
+	if fractions {
+		whole := math.Floor(v)
+		rem := v - whole
+		if rem < 0.06 {
+			return strconv.FormatFloat(whole, 'f', -1, 64)
+		}
+		if rem > 0.94 {
+			return strconv.FormatFloat(whole+1, 'f', -1, 64)
+		}
+		for _, f := range fractionGlyphs {
+			if math.Abs(rem-f.Value) < 0.03 {
+				if whole == 0 {
+					return f.Text
+				}
+				return strconv.FormatFloat(whole, 'f', -1, 64) + " " + f.Text
+			}
+		}
+	}
+
+	s := strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	if l.DecimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
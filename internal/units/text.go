@@ -0,0 +1,176 @@
+package units
+
+import (
+	"regexp"
+	"strings"
+)
+
+const amountPattern = `(?:\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?\s?[½⅓⅔¼¾⅛⅜⅝⅞]?|[½⅓⅔¼¾⅛⅜⅝⅞])`
+
+var (
+	quantityPattern    = buildQuantityPattern()
+	temperaturePattern = regexp.MustCompile(`(?i:\bgas\s+mark\s+` + amountPattern + `)|` +
+		`\b\d+(?:[.,]\d+)?\s?(?:[°º]\s?[CF]\b|(?i:(?:degrees?\s+)?(?:celsius|fahrenheit)\b|degrees?\s+[cf]\b))`)
+	// A bare "180C" is only a temperature when the oven or heat was just
+	// mentioned; otherwise "2 C flour" would be read as one.
+	bareTemperaturePattern = regexp.MustCompile(`\b\d{2,3}\s?[CF]\b`)
+	heatContext            = regexp.MustCompile(`(?i)\b(?:oven|preheat|heat|bake|roast|grill|fry|temperature)\w*\b[^.!?\n]{0,30}$`)
+	nutritionHeading       = regexp.MustCompile(`(?i)^\s*(?:#+\s*|\*\*|__)\s*nutrition`)
+	heading                = regexp.MustCompile(`^\s*(?:#+\s|\*\*[^*]+\*\*:?\s*$|__[^_]+__:?\s*$)`)
+	nutrientLine           = regexp.MustCompile(`(?i)^\s*(?:[-*•]\s*)?(?:\*\*|__)?\s*(?:nutrition\w*|calories|energy|kcal|protein|carb\w*|(?:saturated\s+)?fat|fib(?:re|er)|sugars?|sodium|salt|cholesterol)\b[^:\n]{0,20}:`)
+	alternateOpen          = regexp.MustCompile(`^\s*(\(|/)\s*(?:approx\.?\s*|about\s*)?`)
+	alternateClose         = regexp.MustCompile(`^\s*\)`)
+	leadingAmount          = regexp.MustCompile(`^\s*(` + amountPattern + `)(?:\s*(?:-|–|to)\s*(` + amountPattern + `))?`)
+	followingWords         = regexp.MustCompile(`^(?:\s+of)?\s+([A-Za-z][A-Za-z-]*(?:\s+[A-Za-z][A-Za-z-]*){0,2})`)
+)
+
+// buildQuantityPattern matches an amount (or range of amounts) followed by a
+// volume or mass unit. "c" for cups is left out because it is too ambiguous
+// in prose.
+func buildQuantityPattern() *regexp.Regexp {
+	var alts []string
+	for _, a := range UnitAliases() {
+		u, _ := LookupUnit(a)
+		if u.Dimension == Temperature || strings.EqualFold(a, "c") {
+			continue
+		}
+		alts = append(alts, regexp.QuoteMeta(a))
+	}
+	return regexp.MustCompile(`(?i)` + amountPattern + `(?:\s*(?:-|–|to)\s*` + amountPattern + `)?\s?(?:` + strings.Join(alts, "|") + `)\b\.?`)
+}
+
+// SplitAmount reads an amount, or a range such as "2-3", from the start of s
+// and returns it along with the rest of the string. The returned quantity has
+// no unit.
+func SplitAmount(s string) (Quantity, string, bool) {
+	m := leadingAmount.FindStringSubmatchIndex(s)
+	if m == nil {
+		return Quantity{}, s, false
+	}
+	var q Quantity
+	var err error
+	if q.Amount, err = ParseAmount(s[m[2]:m[3]]); err != nil {
+		return Quantity{}, s, false
+	}
+	if m[4] >= 0 {
+		if q.Max, err = ParseAmount(s[m[4]:m[5]]); err != nil {
+			return Quantity{}, s, false
+		}
+	}
+	return q, s[m[1]:], true
+}
+
+// ConvertText rewrites every quantity and oven temperature it recognises in
+// free text (such as a markdown recipe) into the locale's unit system.
+// Bilingual pairs like "200g (7 oz)" or "180°C/350°F" collapse into the
+// single converted value. Anything it cannot convert is left as written, and
+// so is nutrition information, where "20g" of protein isn't a measure.
+func (l Locale) ConvertText(text string) string {
+	var out strings.Builder
+	nutrition := false
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if heading.MatchString(line) || nutritionHeading.MatchString(line) {
+			nutrition = nutritionHeading.MatchString(line)
+		}
+		if nutrition || nutrientLine.MatchString(line) {
+			out.WriteString(line)
+			continue
+		}
+		line = l.rewrite(line, temperaturePattern, false, nil)
+		line = l.rewrite(line, bareTemperaturePattern, false, func(before string) bool {
+			return heatContext.MatchString(before)
+		})
+		out.WriteString(l.rewrite(line, quantityPattern, true, nil))
+	}
+	return out.String()
+}
+
+// rewrite converts the matches of pattern in text. If accept is set, only
+// matches it accepts, given the text before them, are converted.
+func (l Locale) rewrite(text string, pattern *regexp.Regexp, useDensity bool, accept func(before string) bool) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start < last || (accept != nil && !accept(text[:start])) {
+			continue
+		}
+		match := text[start:end]
+		q, err := Parse(strings.TrimSuffix(match, "."))
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(match, ".") {
+			end--
+		}
+
+		// Drop an alternate measurement that follows, since it would
+		// otherwise be converted into a duplicate of this one. It may come
+		// straight after, or in brackets after what's measured: "200g
+		// flour (7 oz)".
+		ingredient, words := "", ""
+		m := followingWords.FindStringSubmatchIndex(text[end:])
+		if m != nil && useDensity {
+			ingredient = text[end+m[2] : end+m[3]]
+		}
+		if n := alternateLength(text[end:], q); n > 0 {
+			end += n
+		} else if m != nil && strings.HasPrefix(strings.TrimSpace(text[end+m[1]:]), "(") {
+			if n := alternateLength(text[end+m[1]:], q); n > 0 {
+				words = text[end : end+m[1]]
+				end += m[1] + n
+			}
+		}
+
+		out.WriteString(text[last:start])
+		out.WriteString(l.Format(Normalize(q, l.System, ingredient)))
+		out.WriteString(words)
+		last = end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// alternateLength returns how many bytes at the start of rest hold an
+// alternate spelling of q, written as "(7 oz)" or "/350°F", or zero if there
+// is none.
+func alternateLength(rest string, q Quantity) int {
+	open := alternateOpen.FindStringSubmatchIndex(rest)
+	if open == nil {
+		return 0
+	}
+	n := open[1]
+	for _, pattern := range []*regexp.Regexp{temperaturePattern, quantityPattern} {
+		loc := pattern.FindStringIndex(rest[n:])
+		if loc == nil || loc[0] != 0 {
+			continue
+		}
+		other, err := Parse(strings.TrimSuffix(rest[n:n+loc[1]], "."))
+		if err != nil || !sameDimension(q, other) {
+			return 0
+		}
+		n += loc[1]
+		if rest[open[2]:open[3]] == "(" {
+			closing := alternateClose.FindStringIndex(rest[n:])
+			if closing == nil {
+				return 0
+			}
+			n += closing[1]
+		}
+		return n
+	}
+	return 0
+}
+
+func sameDimension(a, b Quantity) bool {
+	ua, ok1 := LookupUnit(a.Unit)
+	ub, ok2 := LookupUnit(b.Unit)
+	if !ok1 || !ok2 {
+		return false
+	}
+	if ua.Dimension == Temperature || ub.Dimension == Temperature {
+		return ua.Dimension == ub.Dimension
+	}
+	// Recipes often pair a weight with a cup measure for the same item.
+	return true
+}
@@ -0,0 +1,285 @@
+package units
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type System string
+
+const (
+	Metric   System = "metric"
+	US       System = "us"
+	Imperial System = "imperial"
+)
+
+type Dimension string
+
+const (
+	Volume      Dimension = "volume"
+	Mass        Dimension = "mass"
+	Temperature Dimension = "temperature"
+)
+
+// Unit describes a measurement unit. Factor converts one of the unit into the
+// base unit of its dimension (millilitres for volume, grams for mass).
+type Unit struct {
+	Name      string
+	Plural    string
+	Dimension Dimension
+	System    System
+	Factor    float64
+}
+
+type Quantity struct {
+	Amount float64 `json:"amount"`
+	Max    float64 `json:"max,omitempty"`
+	Unit   string  `json:"unit"`
+}
+
+var unitTable = []Unit{
+	{Name: "ml", Dimension: Volume, System: Metric, Factor: 1},
+	{Name: "cl", Dimension: Volume, System: Metric, Factor: 10},
+	{Name: "dl", Dimension: Volume, System: Metric, Factor: 100},
+	{Name: "l", Dimension: Volume, System: Metric, Factor: 1000},
+	{Name: "tsp", Dimension: Volume, System: US, Factor: 4.92892},
+	{Name: "tbsp", Dimension: Volume, System: US, Factor: 14.7868},
+	{Name: "fl oz", Dimension: Volume, System: US, Factor: 29.5735},
+	{Name: "cup", Plural: "cups", Dimension: Volume, System: US, Factor: 236.588},
+	{Name: "pint", Plural: "pints", Dimension: Volume, System: US, Factor: 473.176},
+	{Name: "quart", Plural: "quarts", Dimension: Volume, System: US, Factor: 946.353},
+	{Name: "gallon", Plural: "gallons", Dimension: Volume, System: US, Factor: 3785.41},
+	{Name: "imp fl oz", Dimension: Volume, System: Imperial, Factor: 28.4131},
+	{Name: "imp pint", Plural: "imp pints", Dimension: Volume, System: Imperial, Factor: 568.261},
+	{Name: "mg", Dimension: Mass, System: Metric, Factor: 0.001},
+	{Name: "g", Dimension: Mass, System: Metric, Factor: 1},
+	{Name: "kg", Dimension: Mass, System: Metric, Factor: 1000},
+	{Name: "oz", Dimension: Mass, System: US, Factor: 28.3495},
+	{Name: "lb", Dimension: Mass, System: US, Factor: 453.592},
+	{Name: "°C", Dimension: Temperature, System: Metric},
+	{Name: "°F", Dimension: Temperature, System: US},
+	{Name: "gas mark", Dimension: Temperature, System: Imperial},
+}
+
+// aliases maps the spellings we accept to a canonical unit name. Keys are
+// lower case; caseSensitiveAliases are checked first so that "C" (Celsius)
+// does not collide with "c" (cup).
+var aliases = map[string]string{
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"cl": "cl", "centiliter": "cl", "centilitre": "cl", "centiliters": "cl", "centilitres": "cl",
+	"dl": "dl", "deciliter": "dl", "decilitre": "dl", "deciliters": "dl", "decilitres": "dl",
+	"l": "l", "liter": "l", "litre": "l", "liters": "l", "litres": "l", "ltr": "l",
+	"tsp": "tsp", "tsps": "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	"tbsp": "tbsp", "tbsps": "tbsp", "tbs": "tbsp", "tbl": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp",
+	"fl oz": "fl oz", "fl. oz": "fl oz", "fl. oz.": "fl oz", "fluid ounce": "fl oz", "fluid ounces": "fl oz",
+	"c": "cup", "cup": "cup", "cups": "cup",
+	"pt": "pint", "pint": "pint", "pints": "pint",
+	"qt": "quart", "quart": "quart", "quarts": "quart",
+	"gal": "gallon", "gallon": "gallon", "gallons": "gallon",
+	"imp fl oz": "imp fl oz", "imperial fl oz": "imp fl oz", "uk fl oz": "imp fl oz",
+	"imp pint": "imp pint", "imperial pint": "imp pint", "imperial pints": "imp pint", "uk pint": "imp pint", "uk pints": "imp pint",
+	"mg": "mg", "milligram": "mg", "milligrams": "mg",
+	"g": "g", "gr": "g", "gram": "g", "grams": "g", "gramme": "g", "grammes": "g",
+	"kg": "kg", "kgs": "kg", "kilo": "kg", "kilos": "kg", "kilogram": "kg", "kilograms": "kg",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"°c": "°C", "° c": "°C", "ºc": "°C", "º c": "°C", "celsius": "°C", "deg c": "°C",
+	"degree c": "°C", "degrees c": "°C", "degree celsius": "°C", "degrees celsius": "°C",
+	"°f": "°F", "° f": "°F", "ºf": "°F", "º f": "°F", "fahrenheit": "°F", "deg f": "°F",
+	"degree f": "°F", "degrees f": "°F", "degree fahrenheit": "°F", "degrees fahrenheit": "°F",
+	"gas mark": "gas mark", "gas": "gas mark",
+}
+
+var caseSensitiveAliases = map[string]string{
+	"C": "°C",
+	"F": "°F",
+	"L": "l",
+}
+
+// LookupUnit resolves a unit spelling such as "Tablespoons" or "g" to its
+// canonical Unit.
+func LookupUnit(name string) (Unit, bool) {
+	name = strings.TrimSpace(name)
+	canonical, ok := caseSensitiveAliases[name]
+	if !ok {
+		canonical, ok = aliases[strings.ToLower(strings.TrimSuffix(name, "."))]
+	}
+	if !ok {
+		return Unit{}, false
+	}
+	for _, u := range unitTable {
+		if u.Name == canonical {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// UnitAliases returns every accepted unit spelling, longest first, which is
+// the order a scanner should try them in.
+func UnitAliases() []string {
+	names := make([]string, 0, len(aliases)+len(caseSensitiveAliases))
+	for a := range aliases {
+		names = append(names, a)
+	}
+	for a := range caseSensitiveAliases {
+		names = append(names, a)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+var wordRange = regexp.MustCompile(`(\d)\s+to\s+(\d)`)
+
+var unicodeFractions = map[rune]float64{
+	'½': 0.5, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 0.25, '¾': 0.75,
+	'⅕': 0.2, '⅖': 0.4, '⅗': 0.6, '⅘': 0.8, '⅙': 1.0 / 6, '⅚': 5.0 / 6,
+	'⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// ParseAmount parses numeric amounts as they appear in recipes: "2", "1.5",
+// "1,5", "1/2", "1 1/2", "½" and "1½".
+func ParseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	// Split off a trailing unicode fraction ("1½" or "½").
+	var frac float64
+	if r := []rune(s); len(r) > 0 {
+		if f, ok := unicodeFractions[r[len(r)-1]]; ok {
+			frac = f
+			s = strings.TrimSpace(string(r[:len(r)-1]))
+			if s == "" {
+				return frac, nil
+			}
+		}
+	}
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		v, err := parseNumber(fields[0])
+		if err != nil {
+			return 0, err
+		}
+		return v + frac, nil
+	case 2:
+		whole, err := parseDecimal(fields[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		if !strings.Contains(fields[1], "/") {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		part, err := parseNumber(fields[1])
+		if err != nil {
+			return 0, err
+		}
+		return whole + part + frac, nil
+	}
+	return 0, fmt.Errorf("invalid amount %q", s)
+}
+
+func parseNumber(s string) (float64, error) {
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := parseDecimal(num)
+		d, err2 := parseDecimal(den)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, fmt.Errorf("invalid fraction %q", s)
+		}
+		return n / d, nil
+	}
+	if whole, rest, ok := strings.Cut(s, ","); ok {
+		// "1,000" is a thousands separator, "1,5" a decimal comma.
+		if len(rest) == 3 {
+			s = whole + rest
+		} else {
+			s = whole + "." + rest
+		}
+	}
+	v, err := parseDecimal(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+// parseDecimal parses a plain decimal number. Unlike strconv.ParseFloat it
+// takes no sign, exponent, infinity or NaN, so an amount can't be negative.
+func parseDecimal(s string) (float64, error) {
+	if s == "" || strings.Trim(s, "0123456789.") != "" {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Parse parses a quantity such as "1 1/2 cups", "200g", "2-3 tbsp",
+// "180°C" or "gas mark 4".
+func Parse(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Quantity{}, fmt.Errorf("empty quantity")
+	}
+
+	// Gas marks put the unit before the number.
+	lower := strings.ToLower(s)
+	for _, prefix := range []string{"gas mark", "gas"} {
+		if rest, ok := strings.CutPrefix(lower, prefix); ok {
+			v, err := ParseAmount(rest)
+			if err != nil {
+				return Quantity{}, err
+			}
+			return Quantity{Amount: v, Unit: "gas mark"}, nil
+		}
+	}
+
+	s = wordRange.ReplaceAllString(s, "$1-$2")
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || r == '°' || r == 'º'
+	})
+	if split <= 0 {
+		return Quantity{}, fmt.Errorf("invalid quantity %q", s)
+	}
+	amount, unitName := strings.TrimSpace(s[:split]), strings.TrimSpace(s[split:])
+
+	u, ok := LookupUnit(unitName)
+	if !ok {
+		return Quantity{}, fmt.Errorf("unknown unit %q", unitName)
+	}
+
+	q := Quantity{Unit: u.Name}
+	lo, hi, isRange := splitRange(amount)
+	var err error
+	if q.Amount, err = ParseAmount(lo); err != nil {
+		return Quantity{}, err
+	}
+	if isRange {
+		if q.Max, err = ParseAmount(hi); err != nil {
+			return Quantity{}, err
+		}
+		if q.Max < q.Amount {
+			return Quantity{}, fmt.Errorf("invalid range %q", amount)
+		}
+	}
+	return q, nil
+}
+
+func splitRange(s string) (string, string, bool) {
+	for _, sep := range []string{"–", "-", " to "} {
+		if lo, hi, ok := strings.Cut(s, sep); ok && strings.TrimSpace(lo) != "" {
+			return lo, hi, true
+		}
+	}
+	return s, "", false
+}
@@ -0,0 +1,142 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Quantity
+	}{
+		{"1 1/2 cups", Quantity{Amount: 1.5, Unit: "cup"}},
+		{"200g", Quantity{Amount: 200, Unit: "g"}},
+		{"½ tsp", Quantity{Amount: 0.5, Unit: "tsp"}},
+		{"1½ Tablespoons", Quantity{Amount: 1.5, Unit: "tbsp"}},
+		{"1,5 kg", Quantity{Amount: 1.5, Unit: "kg"}},
+		{"2-3 tbsp", Quantity{Amount: 2, Max: 3, Unit: "tbsp"}},
+		{"2 to 3 cups", Quantity{Amount: 2, Max: 3, Unit: "cup"}},
+		{"180°C", Quantity{Amount: 180, Unit: "°C"}},
+		{"350 degrees Fahrenheit", Quantity{Amount: 350, Unit: "°F"}},
+		{"gas mark 4", Quantity{Amount: 4, Unit: "gas mark"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.InDelta(t, tt.want.Amount, got.Amount, 1e-9, tt.in)
+			assert.InDelta(t, tt.want.Max, got.Max, 1e-9, tt.in)
+			assert.Equal(t, tt.want.Unit, got.Unit, tt.in)
+		}
+	}
+
+	for _, in := range []string{"3 handfuls", "-1 cup", "1 -1/2 cups", "1--2 cups"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	got, err := ParseAmount("1 1/2")
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.5, got, 1e-9)
+
+	for _, in := range []string{"-1", "+2", "1/-2", "-1/2", "1e3", "NaN"} {
+		_, err := ParseAmount(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	q, err := Convert(Quantity{Amount: 1, Unit: "cup"}, "g", "all-purpose flour")
+	assert.NoError(t, err)
+	assert.InDelta(t, 125.4, q.Amount, 0.1)
+
+	q, err = Convert(Quantity{Amount: 180, Unit: "°C"}, "°F", "")
+	assert.NoError(t, err)
+	assert.InDelta(t, 356, q.Amount, 0.01)
+
+	q, err = Convert(Quantity{Amount: 375, Unit: "°F"}, "gas mark", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, q.Amount)
+
+	_, err = Convert(Quantity{Amount: 1, Unit: "cup"}, "g", "mystery")
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	metric := Locale{System: Metric}
+	us := Locale{System: US}
+	uk := Locale{System: Imperial}
+
+	assert.Equal(t, "125 g", metric.Format(Normalize(Quantity{Amount: 1, Unit: "cup"}, Metric, "flour")))
+	assert.Equal(t, "475 ml", metric.Format(Normalize(Quantity{Amount: 2, Unit: "cup"}, Metric, "milk")))
+	assert.Equal(t, "1 tbsp", metric.Format(Normalize(Quantity{Amount: 1, Unit: "tbsp"}, Metric, "oil")))
+	assert.Equal(t, "1 1/2 lb", us.Format(Normalize(Quantity{Amount: 680, Unit: "g"}, US, "chicken thighs")))
+	assert.Equal(t, "2 cups", us.Format(Normalize(Quantity{Amount: 250, Unit: "g"}, US, "plain flour")))
+	assert.Equal(t, "350°F", us.Format(Normalize(Quantity{Amount: 180, Unit: "°C"}, US, "")))
+	assert.Equal(t, "gas mark 4", uk.Format(Normalize(Quantity{Amount: 350, Unit: "°F"}, Imperial, "")))
+	assert.Equal(t, "10 fl oz", uk.Format(Normalize(Quantity{Amount: 284, Unit: "ml"}, Imperial, "")))
+}
+
+func TestParseLocale(t *testing.T) {
+	for in, want := range map[string]Locale{
+		"metric": {System: Metric},
+		"US":     {System: US},
+		"uk":     {System: Imperial},
+		"en-GB":  {System: Imperial},
+		"en_US":  {System: US},
+		"de-DE":  {System: Metric, DecimalComma: true},
+		"fr":     {System: Metric, DecimalComma: true},
+	} {
+		got, err := ParseLocale(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseLocale("parsecs")
+	assert.Error(t, err)
+}
+
+func TestConvertText(t *testing.T) {
+	text := "## Ingredients\n- 2 cups all-purpose flour\n- 1 cup (240 ml) milk\n- 1/2 tsp salt\n- 2 eggs\n\n" +
+		"Preheat the oven to 350°F (175°C). Bake for 25 minutes."
+
+	got := Locale{System: Metric}.ConvertText(text)
+	assert.Contains(t, got, "- 250 g all-purpose flour")
+	assert.Contains(t, got, "- 235 ml milk")
+	assert.Contains(t, got, "- 1/2 tsp salt")
+	assert.Contains(t, got, "- 2 eggs")
+	assert.Contains(t, got, "Preheat the oven to 175°C. Bake for 25 minutes.")
+
+	got = Locale{System: US}.ConvertText("Add 500g chicken and 1,5 l stock, then roast at 200°C/gas mark 6.")
+	assert.Equal(t, "Add 1 lb chicken and 6 1/4 cups stock, then roast at 400°F.", got)
+
+	got = Locale{System: Metric, DecimalComma: true}.ConvertText("Use 3 lb potatoes.")
+	assert.Equal(t, "Use 1,35 kg potatoes.", got)
+
+	// An alternate after what's measured goes too, but not the words
+	got = Locale{System: US}.ConvertText("Add 200g flour (7 oz) and mix. Add 200g of flour (7 oz).")
+	assert.Equal(t, "Add 1 1/2 cups flour and mix. Add 1 1/2 cups of flour.", got)
+	got = Locale{System: US}.ConvertText("Add 200g flour / 100g sugar.")
+	assert.Equal(t, "Add 1 1/2 cups flour / 1/2 cup sugar.", got)
+}
+
+func TestConvertTextLeavesLookalikes(t *testing.T) {
+	metric := Locale{System: Metric}
+
+	// A capital C for cups isn't a temperature
+	assert.Equal(t, "- 2 C flour", metric.ConvertText("- 2 C flour"))
+	assert.Equal(t, "- 2 C flour", Locale{System: US}.ConvertText("- 2 C flour"))
+	// but it is once the oven comes up
+	assert.Equal(t, "Preheat the oven to 175°C.", metric.ConvertText("Preheat the oven to 350F."))
+	assert.Equal(t, "Heat to 350°F.", Locale{System: US}.ConvertText("Heat to 175 degrees Celsius."))
+
+	text := "## Ingredients\n- 200g chicken\n\n## Nutrition (per serving)\n- Protein: 20g\n- Fat: 12 g\n\n" +
+		"## Notes\nKeeps for 2 days.\n\n**Protein:** 35g per serving"
+	got := Locale{System: US}.ConvertText(text)
+	assert.Contains(t, got, "- 7 oz chicken")
+	assert.Contains(t, got, "- Protein: 20g\n- Fat: 12 g")
+	assert.Contains(t, got, "**Protein:** 35g per serving")
+}
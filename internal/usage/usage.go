@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Service is an external API whose calls are metered.
+type Service string
+
+const (
+	Gemini  Service = "gemini"
+	YouTube Service = "youtube"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Meter counts the external calls made while handling one request and
+// refuses calls beyond its limits.
+type Meter struct {
+	mu      sync.Mutex
+	limits  map[Service]int
+	counts  map[Service]int
+	refused map[Service]bool
+}
+
+// NewMeter returns a meter that allows up to limits[s] calls to each
+// service. Services missing from limits are not limited.
+func NewMeter(limits map[Service]int) *Meter {
+	return &Meter{limits: limits, counts: map[Service]int{}, refused: map[Service]bool{}}
+}
+
+// Use records a call to s, or returns ErrQuotaExceeded if the limit for s
+// has been reached.
+func (m *Meter) Use(s Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if limit, ok := m.limits[s]; ok && m.counts[s] >= limit {
+		m.refused[s] = true
+		return fmt.Errorf("%s %w", s, ErrQuotaExceeded)
+	}
+	m.counts[s]++
+	return nil
+}
+
+// Refused returns the services a call was refused for, so a request that
+// failed can be told apart from one that ran out of quota even after the
+// error itself was turned into a message.
+func (m *Meter) Refused() []Service {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Service
+	for s := range m.refused {
+		out = append(out, s)
+	}
+	return out
+}
+
+// Counts returns how many calls have been made to each service.
+func (m *Meter) Counts() map[Service]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[Service]int, len(m.counts))
+	for s, n := range m.counts {
+		counts[s] = n
+	}
+	return counts
+}
+
+type meterKey struct{}
+
+// WithMeter returns a context whose external calls are counted by m.
+func WithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// Use records a call to s against the context's meter. Calls are always
+// allowed when there is no meter.
+func Use(ctx context.Context, s Service) error {
+	m, ok := ctx.Value(meterKey{}).(*Meter)
+	if !ok {
+		return nil
+	}
+	return m.Use(s)
+}
@@ -0,0 +1,26 @@
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeter(t *testing.T) {
+	m := NewMeter(map[Service]int{Gemini: 2, YouTube: 0})
+	ctx := WithMeter(context.Background(), m)
+
+	assert.NoError(t, Use(ctx, Gemini))
+	assert.NoError(t, Use(ctx, Gemini))
+	assert.ErrorIs(t, Use(ctx, Gemini), ErrQuotaExceeded)
+	assert.ErrorIs(t, Use(ctx, YouTube), ErrQuotaExceeded)
+	assert.Equal(t, map[Service]int{Gemini: 2}, m.Counts())
+	assert.ElementsMatch(t, []Service{Gemini, YouTube}, m.Refused())
+	assert.Empty(t, NewMeter(nil).Refused())
+
+	// Without a meter nothing is limited.
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, Use(context.Background(), YouTube))
+	}
+}
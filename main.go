@@ -2,24 +2,68 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Oluwaseun241/mura/cmd/api"
 	"github.com/Oluwaseun241/mura/cmd/client"
+	"github.com/Oluwaseun241/mura/internal/apikey"
+	"github.com/Oluwaseun241/mura/internal/auth"
+	"github.com/Oluwaseun241/mura/internal/embed"
+	"github.com/Oluwaseun241/mura/internal/ratelimit"
+	"github.com/Oluwaseun241/mura/internal/storage"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 )
 
+const (
+	// requestTimeout is how long a request may take, apart from planning
+	// a week of meals
+	requestTimeout = 30 * time.Second
+	// planTimeout is how long planning a week of meals may take, as one
+	// long generation
+	planTimeout = 3 * time.Minute
+	// corpusAnswerTime is left of a /recipe request to answer from the
+	// corpus after giving up on Gemini
+	corpusAnswerTime = 5 * time.Second
+)
+
 type Config struct {
 	Port            string
 	Environment     string
 	ShutdownTimeout time.Duration
+	RequestTimeout  time.Duration
+	DishConcurrency int
+	DBPath          string
+	SuggestionHour  int
+	JWTSecret       []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MaxKeyQuota     apikey.Quota
+	TrustProxy      bool
+	EmbeddingModel  string
+	FakeEmbeddings  bool
+	RecipeTimeout   time.Duration
+	CorpusPath      string
+	PublicURL       string
+}
+
+// envInt reads a positive integer from the environment.
+func envInt(name string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
 }
 
 func loadConfig() Config {
@@ -40,10 +84,131 @@ func loadConfig() Config {
 		env = "development"
 	}
 
+	dishConcurrency := envInt("DISH_CONCURRENCY", api.DishConcurrency)
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "mura.db"
+	}
+
+	// Hour of the day (server time) the "use it up" suggestions are refreshed
+	suggestionHour, err := strconv.Atoi(os.Getenv("SUGGESTION_HOUR"))
+	if err != nil || suggestionHour < 0 || suggestionHour > 23 {
+		suggestionHour = 6
+	}
+
+	// Tokens signed with a generated secret stop working on restart, which is
+	// fine for development but not in production
+	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		if env == "production" {
+			log.Fatal("JWT_SECRET must be set in production")
+		}
+		log.Printf("Warning: JWT_SECRET not set, using a random secret")
+		jwtSecret = make([]byte, 32)
+		if _, err := rand.Read(jwtSecret); err != nil {
+			log.Fatalf("Failed to generate JWT secret: %v", err)
+		}
+	}
+
+	accessTokenTTL, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL"))
+	if err != nil || accessTokenTTL <= 0 {
+		accessTokenTTL = 15 * time.Minute
+	}
+	refreshTokenTTL, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL"))
+	if err != nil || refreshTokenTTL <= 0 {
+		refreshTokenTTL = 30 * 24 * time.Hour
+	}
+
+	// The most any one API key may call Gemini and YouTube
+	maxKeyQuota := apikey.Quota{
+		GeminiDaily:    envInt("API_KEY_GEMINI_DAILY", api.MaxKeyQuota.GeminiDaily),
+		GeminiMonthly:  envInt("API_KEY_GEMINI_MONTHLY", api.MaxKeyQuota.GeminiMonthly),
+		YouTubeDaily:   envInt("API_KEY_YOUTUBE_DAILY", api.MaxKeyQuota.YouTubeDaily),
+		YouTubeMonthly: envInt("API_KEY_YOUTUBE_MONTHLY", api.MaxKeyQuota.YouTubeMonthly),
+	}
+
+	// Rate limits can be changed per rule, e.g. RATE_LIMIT_IMAGE=5/m or
+	// RATE_LIMIT_DEFAULT=1000/h,100, or turned off with "off"
+	for i, rule := range api.RateLimits {
+		if v := os.Getenv("RATE_LIMIT_" + strings.ToUpper(rule.Name)); v != "" {
+			limit, err := ratelimit.ParseLimit(v)
+			if err != nil {
+				log.Fatalf("RATE_LIMIT_%s: %v", strings.ToUpper(rule.Name), err)
+			}
+			api.RateLimits[i].Limit = limit
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_DEFAULT"); v != "" {
+		limit, err := ratelimit.ParseLimit(v)
+		if err != nil {
+			log.Fatalf("RATE_LIMIT_DEFAULT: %v", err)
+		}
+		api.DefaultRateLimit = limit
+	}
+	if v := os.Getenv("RATE_LIMIT_IP"); v != "" {
+		limit, err := ratelimit.ParseLimit(v)
+		if err != nil {
+			log.Fatalf("RATE_LIMIT_IP: %v", err)
+		}
+		api.IPRateLimit = limit
+	}
+
+	// Only behind a proxy can X-Forwarded-For be trusted for the client's IP
+	trustProxy := os.Getenv("TRUST_PROXY") == "true"
+
+	// EMBEDDINGS=fake embeds locally instead, for development without
+	// spending quota
+	embeddingModel := os.Getenv("EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = "text-embedding-004"
+	}
+	fakeEmbeddings := os.Getenv("EMBEDDINGS") == "fake"
+
+	// How long /recipe waits for Gemini before answering from the corpus.
+	// It has to give up well before the request times out, or the corpus
+	// answer never gets sent
+	recipeTimeout, err := time.ParseDuration(os.Getenv("RECIPE_TIMEOUT"))
+	if err != nil || recipeTimeout <= 0 {
+		recipeTimeout = api.RecipeTimeout
+	}
+	if limit := requestTimeout - corpusAnswerTime; recipeTimeout > limit {
+		log.Printf("Warning: RECIPE_TIMEOUT %s is too close to the %s request timeout, using %s", recipeTimeout, requestTimeout, limit)
+		recipeTimeout = limit
+	}
+
+	// A JSON file of extra recipes for the corpus, in the same format as
+	// the bundled ones
+	corpusPath := os.Getenv("CORPUS_PATH")
+
+	// Where clients reach the server, for the links it hands out such as
+	// calendar feeds
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if u, err := url.Parse(publicURL); publicURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		log.Fatalf("PUBLIC_URL %q must be an http or https URL", publicURL)
+	}
+	if publicURL == "" {
+		log.Printf("Warning: PUBLIC_URL is not set, so calendar feeds are not available")
+	}
+
 	return Config{
 		Port:            port,
 		Environment:     env,
 		ShutdownTimeout: 10 * time.Second,
+		RequestTimeout:  requestTimeout,
+		DishConcurrency: dishConcurrency,
+		DBPath:          dbPath,
+		SuggestionHour:  suggestionHour,
+		JWTSecret:       jwtSecret,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		MaxKeyQuota:     maxKeyQuota,
+		TrustProxy:      trustProxy,
+		EmbeddingModel:  embeddingModel,
+		FakeEmbeddings:  fakeEmbeddings,
+		RecipeTimeout:   recipeTimeout,
+		CorpusPath:      corpusPath,
+		PublicURL:       publicURL,
 	}
 }
 
@@ -53,6 +218,54 @@ func main() {
 
 	// Initialize client connection
 	client.Init()
+	api.DishConcurrency = cfg.DishConcurrency
+	api.MaxKeyQuota = cfg.MaxKeyQuota
+	api.RecipeTimeout = cfg.RecipeTimeout
+	api.PublicURL = cfg.PublicURL
+
+	// Add any imported recipes to the bundled corpus
+	if cfg.CorpusPath != "" {
+		f, err := os.Open(cfg.CorpusPath)
+		if err != nil {
+			log.Fatalf("Failed to open corpus: %v", err)
+		}
+		n, err := api.RecipeCorpus.Import(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to import corpus: %v", err)
+		}
+		log.Printf("Imported %d recipes into the corpus", n)
+	}
+
+	// Open the database for per-user data
+	db, err := storage.Open(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	api.InitStorage(db)
+	api.InitAuth(auth.NewIssuer(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL))
+
+	// Recipe similarity needs Gemini, or fake embeddings when asked for
+	// explicitly; otherwise it's turned off rather than serving made-up
+	// matches
+	switch {
+	case cfg.FakeEmbeddings:
+		log.Printf("Warning: using fake embeddings")
+		api.InitEmbeddings(embed.Fake{})
+	case client.GeminiClient != nil:
+		api.InitEmbeddings(embed.NewGemini(client.GeminiClient, cfg.EmbeddingModel))
+	default:
+		log.Printf("Warning: Gemini client not configured, similar recipes are disabled")
+	}
+
+	// Precompute expiry-aware suggestions once a day
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go api.RunSuggestionJob(jobCtx, cfg.SuggestionHour)
+
+	// Embed the dishes similar ones are picked from before anyone asks
+	go api.WarmDishCatalog(jobCtx)
 
 	// Create Echo instance
 	e := echo.New()
@@ -63,24 +276,94 @@ func main() {
 		e.Logger.SetLevel(log.DEBUG)
 	}
 
+	// Clients could dodge per-IP rate limits by sending their own
+	// X-Forwarded-For, so it's only read when a proxy sets it
+	if !cfg.TrustProxy {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	// Middleware
 	e.Use(middleware.CORS())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestID())
 	e.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
-		Timeout: 30 * time.Second,
+		// Planning a week gets longer, on its route
+		Skipper: func(c echo.Context) bool {
+			return c.Request().Method == http.MethodPost && c.Path() == "/meal-plans"
+		},
+		Timeout: cfg.RequestTimeout,
 	}))
+	// Each IP is limited before its credentials are checked, so bad keys
+	// and tokens can't be tried without limit; then each caller per route
+	e.Use(api.LimitIP)
+	e.Use(api.Authenticate)
+	e.Use(api.RateLimit)
 
 	// Health check endpoint
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "healthy"})
 	})
 
+	// Accounts. Routes with RequireUser hold per-user data and refuse
+	// anonymous requests.
+	e.POST("/auth/register", api.RegisterHandler)
+	e.POST("/auth/login", api.LoginHandler)
+	e.POST("/auth/refresh", api.RefreshTokenHandler)
+	e.POST("/auth/logout", api.LogoutHandler)
+	e.GET("/auth/me", api.MeHandler, api.RequireUser)
+	e.POST("/api-keys", api.CreateAPIKeyHandler, api.RequireUser)
+	e.GET("/api-keys", api.ListAPIKeysHandler, api.RequireUser)
+	e.DELETE("/api-keys/:id", api.RevokeAPIKeyHandler, api.RequireUser)
+
 	// Routes
 	e.POST("/detect-food", api.FoodHandler)
 	e.POST("/detect", api.IngredientHandler)
+	e.POST("/estimate-portion", api.EstimatePortionHandler)
 	e.POST("/recipe", api.RecipeHandler)
+	e.POST("/recipe/variation", api.VariationHandler)
+	e.POST("/ingredients/parse", api.ParseIngredientsHandler)
+	e.POST("/substitute", api.SubstituteHandler)
+	e.POST("/chat", api.CreateChatHandler)
+	e.GET("/chat/:id", api.GetChatHandler)
+	e.POST("/chat/:id/messages", api.ChatMessageHandler)
+	e.DELETE("/chat/:id", api.DeleteChatHandler)
+	e.POST("/diary", api.CreateDiaryEntryHandler, api.RequireUser)
+	e.GET("/diary", api.GetDiaryHandler, api.RequireUser)
+	e.GET("/diary/week", api.GetDiaryWeekHandler, api.RequireUser)
+	e.GET("/diary/export", api.ExportDiaryHandler, api.RequireUser)
+	e.GET("/diary/targets", api.GetDiaryTargetsHandler, api.RequireUser)
+	e.PUT("/diary/targets", api.SetDiaryTargetsHandler, api.RequireUser)
+	e.DELETE("/diary/:id", api.DeleteDiaryEntryHandler, api.RequireUser)
+	e.POST("/shopping-list", api.ShoppingListHandler)
+	e.GET("/recipes/search", api.SearchRecipesHandler, api.RequireUser)
+	e.POST("/recipes/import", api.ImportRecipeHandler, api.RequireUser)
+	e.GET("/recipes/:id/similar", api.SimilarRecipesHandler, api.RequireUser)
+	e.GET("/history", api.ListHistoryHandler, api.RequireUser)
+	e.GET("/history/:id", api.GetHistoryHandler, api.RequireUser)
+	e.POST("/history/:id/regenerate", api.RegenerateHistoryHandler, api.RequireUser)
+	e.POST("/saved-recipes", api.SaveRecipeHandler, api.RequireUser)
+	e.GET("/saved-recipes", api.ListSavedRecipesHandler, api.RequireUser)
+	e.GET("/saved-recipes/tags", api.SavedRecipeTagsHandler, api.RequireUser)
+	e.GET("/saved-recipes/:id", api.GetSavedRecipeHandler, api.RequireUser)
+	e.PATCH("/saved-recipes/:id", api.UpdateSavedRecipeHandler, api.RequireUser)
+	e.DELETE("/saved-recipes/:id", api.DeleteSavedRecipeHandler, api.RequireUser)
+	e.POST("/meal-plans", api.CreateMealPlanHandler, api.RequireUser, middleware.TimeoutWithConfig(middleware.TimeoutConfig{Timeout: planTimeout}))
+	e.GET("/meal-plans", api.ListMealPlansHandler, api.RequireUser)
+	e.GET("/meal-plans/:id", api.GetMealPlanHandler, api.RequireUser)
+	e.POST("/meal-plans/:id/swap", api.SwapMealHandler, api.RequireUser)
+	e.GET("/meal-plans/:id/days/:day/:slot", api.GetPlanMealHandler, api.RequireUser)
+	e.GET("/meal-plans/:id/shopping-list", api.PlanShoppingListHandler, api.RequireUser)
+	e.GET("/meal-plans/:id/calendar.ics", api.PlanCalendarHandler, api.RequireUser)
+	e.GET("/calendar/feed", api.CalendarFeedHandler, api.RequireUser)
+	e.POST("/calendar/feed/rotate", api.RotateCalendarFeedHandler, api.RequireUser)
+	e.GET("/calendar/:token", api.CalendarSubscriptionHandler)
+	e.GET("/pantry", api.GetPantryHandler, api.RequireUser)
+	e.GET("/pantry/suggestions", api.SuggestionsHandler, api.RequireUser)
+	e.POST("/pantry", api.AddPantryItemsHandler, api.RequireUser)
+	e.POST("/pantry/detected", api.AddDetectedToPantryHandler, api.RequireUser)
+	e.PATCH("/pantry/:id", api.UpdatePantryItemHandler, api.RequireUser)
+	e.DELETE("/pantry/:id", api.DeletePantryItemHandler, api.RequireUser)
 
 	// Start server in a goroutine
 	go func() {